@@ -13,7 +13,11 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/sungp/gophership/internal/config"
 	"github.com/sungp/gophership/internal/control"
+	"github.com/sungp/gophership/internal/exporter"
+	"github.com/sungp/gophership/internal/hooks"
 	"github.com/sungp/gophership/internal/ingester"
+	"github.com/sungp/gophership/internal/processor"
+	"github.com/sungp/gophership/internal/somatic"
 	"github.com/sungp/gophership/internal/stochastic"
 	"github.com/sungp/gophership/internal/web"
 	"github.com/sungp/gophership/pkg/otel"
@@ -50,18 +54,108 @@ func main() {
 	// Budgets: Centralized in internal/config
 	monitor := stochastic.NewSensingMonitor(
 		1024,                  // Check every 1024 ops
-		cfg.Monitoring.MaxRAM, // Max Host RAM
+		cfg.Monitoring.MaxRAM, // Max RAM; 0 detects the cgroup limit
 		cfg.Monitoring.YellowThreshold,
 		cfg.Monitoring.RedThreshold,   // Thresholds
 		cfg.Monitoring.IngesterBudget, // Ingester Budget
 		cfg.Monitoring.VaultBudget,    // Vault Budget
 	)
-	stochastic.SetGlobalMonitor(monitor)
+	monitor.SetProcessorBudget(cfg.Monitoring.ProcessorBudget)
+	monitor.SetCPUThresholds(cfg.Monitoring.CPUYellowThreshold, cfg.Monitoring.CPURedThreshold)
+	if !cfg.Monitoring.DisableGoMemLimit {
+		if limit := monitor.ApplyGoMemoryLimit(); limit > 0 {
+			log.Info().Int64("gomemlimit", limit).Msg("Go runtime soft memory limit set")
+		}
+	}
+	if psi := cfg.Monitoring.PSI; !psi.Disabled {
+		monitor.EnablePSI(stochastic.PSIConfig{
+			SomeYellow:    psi.SomeYellow,
+			SomeRed:       psi.SomeRed,
+			FullYellow:    psi.FullYellow,
+			FullRed:       psi.FullRed,
+			TriggerStall:  psi.TriggerStall,
+			TriggerWindow: psi.TriggerWindow,
+		})
+	}
+	if disk := cfg.Monitoring.Disk; !disk.Disabled {
+		for _, p := range disk.Paths {
+			monitor.WatchDisk(p)
+		}
+		monitor.EnableDisk(stochastic.DiskConfig{YellowFree: disk.YellowFree, RedFree: disk.RedFree})
+	}
+	prediction := stochastic.PredictionConfig{
+		Horizon:  cfg.Monitoring.Prediction.Horizon,
+		HalfLife: cfg.Monitoring.Prediction.HalfLife,
+	}
+	monitor.SetPrediction(prediction)
+
+	// The engine's state lives in a Runtime passed to each component. The
+	// process runs one engine, so it uses the default runtime.
+	rt := stochastic.Default
+	rt.SetMonitor(monitor)
+
+	history := stochastic.NewHistory(cfg.Monitoring.History.Size)
+	if path := cfg.Monitoring.History.Path; path != "" {
+		if err := history.Persist(path); err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("Failed to load zone history; starting empty")
+		}
+	}
+	rt.Zones().SetHistory(history)
 
 	// 1. Initialize Ingester (Core Reflex Engine)
-	ing := ingester.NewIngester(cfg.Ingester.BufferSize)
+	ing := ingester.NewIngesterWithRuntime(rt, cfg.Ingester.BufferSize)
+	zones := cfg.Monitoring.Zones
+	if err := ing.Somatic().SetPolicy(somatic.Policy{
+		YellowEnter:    zones.YellowEnter,
+		YellowExit:     zones.YellowExit,
+		RedEnter:       zones.RedEnter,
+		RedExit:        zones.RedExit,
+		Dwell:          [3]time.Duration{zones.GreenDwell, zones.YellowDwell, zones.RedDwell},
+		MaxTransitions: zones.MaxTransitions,
+		RateWindow:     zones.RateWindow,
+		Prediction:     prediction,
+	}); err != nil {
+		log.Fatal().Err(err).Msg("Invalid monitoring.zones configuration")
+	}
+	windows, err := somatic.BuildWindows(cfg.Monitoring.Overrides)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid monitoring.overrides configuration")
+	}
+	go ing.Somatic().RunSchedule(ctx, windows)
+
+	// 1a. Downstream Exporters (Green path delivery)
+	exporters, err := exporter.Build(rt, cfg.Exporters)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize exporters")
+	}
+	var sink exporter.Exporter
+	if len(exporters) > 0 {
+		router, err := exporter.NewRouter(rt, cfg.Routing, exporters)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to compile routing table")
+		}
+		sink = router
+
+		// 1b. Processors run on every exported batch, including vault replays.
+		chain, err := processor.Build(rt, cfg.Processors)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to initialize processors")
+		}
+		if chain.Len() > 0 {
+			sink = processor.NewPipeline(chain, router)
+		}
+		ing.SetExporter(sink)
+		log.Info().Int("exporters", len(exporters)).Int("processors", chain.Len()).Int("routes", len(cfg.Routing.Routes)).Msg("Downstream exporters enabled")
+	}
 	ing.StartWorkerLoop(ctx)
 
+	// 1c. Zone hooks react to somatic transitions off the reflex path.
+	dispatcher, err := hooks.Build(rt, cfg.Monitoring.Hooks, exporters)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid monitoring.hooks configuration")
+	}
+	dispatcher.Start(ctx)
+
 	// 2. Start OTLP gRPC Ingestion Server (GS.1.2)
 	// [AC1, AC3, AC4] TLS 1.3 and mTLS Configuration
 	var grpcOpts []grpc.ServerOption
@@ -94,7 +188,7 @@ func main() {
 	}
 
 	// 3. Start Prometheus Metrics Server (AC5)
-	metricsShutdown := stochastic.StartMetricsServer(ctx, ":9091")
+	metricsShutdown := rt.StartMetricsServer(ctx, ":9091")
 	defer metricsShutdown()
 
 	// 4. Initialize Secure Control Plane (Story 5.1)
@@ -115,7 +209,7 @@ func main() {
 	defer ctrl.Stop(ctx)
 
 	// 5. Start Web UI Server (Story 6.2 integration)
-	webSrv := web.NewMetricsServer(gophership.DashboardAssets)
+	webSrv := web.NewMetricsServerWithRuntime(rt, gophership.DashboardAssets)
 	if err := webSrv.Start(ctx, ":8080"); err != nil {
 		log.Fatal().Err(err).Msg("Failed to start Web UI server")
 	}
@@ -143,5 +237,12 @@ func main() {
 	}
 
 	log.Info().Msg("Shutdown signal received. Initiating graceful shutdown sequence...")
+	if sink != nil {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := sink.Shutdown(flushCtx); err != nil {
+			log.Error().Err(err).Msg("Exporter shutdown failed; pending batches may be lost")
+		}
+		cancel()
+	}
 	log.Info().Msg("Shutdown sequence complete. All biological reflexes stopped.")
 }
@@ -23,7 +23,7 @@ func (f *TableFormatter) Format(w io.Writer, v interface{}) error {
 	switch data := v.(type) {
 	case map[string]interface{}:
 		// Deterministic order (AC-Review #2)
-		keys := []string{"Somatic Zone", "Pressure Score", "Memory Usage", "Heap Objects"}
+		keys := []string{"Somatic Zone", "Zone Driver", "Override", "Pressure Score", "Memory Usage", "Heap Objects", "Goroutines", "Downstreams"}
 		for _, k := range keys {
 			if val, ok := data[k]; ok {
 				fmt.Fprintf(tw, "%s:\t%v\n", k, val)
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sungp/gophership/pkg/protocol"
+)
+
+// executeHistory prints zone transitions, newest first.
+func executeHistory(resp *protocol.ZoneHistoryResponse, format string) int {
+	if err := writeHistory(os.Stdout, resp, format); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 128
+	}
+	return 0
+}
+
+// writeHistory renders transitions as a table, or as a list of records for
+// json and yaml.
+func writeHistory(w io.Writer, resp *protocol.ZoneHistoryResponse, format string) error {
+	if format != "table" {
+		f, err := NewFormatter(format)
+		if err != nil {
+			return err
+		}
+		records := make([]map[string]interface{}, 0, len(resp.Transitions))
+		for _, t := range resp.Transitions {
+			votes := make([]map[string]interface{}, 0, len(t.Votes))
+			for _, v := range t.Votes {
+				votes = append(votes, map[string]interface{}{
+					"sensor": v.Sensor,
+					"zone":   v.Zone.String(),
+					"reason": v.Reason,
+					"value":  v.Value,
+				})
+			}
+			records = append(records, map[string]interface{}{
+				"time":   time.Unix(0, t.TimestampUnixNano).UTC().Format(time.RFC3339Nano),
+				"from":   t.From.String(),
+				"to":     t.To.String(),
+				"sensor": t.Sensor,
+				"reason": t.Reason,
+				"votes":  votes,
+			})
+		}
+		return f.Format(w, records)
+	}
+
+	if len(resp.Transitions) == 0 {
+		_, err := fmt.Fprintln(w, "No zone transitions recorded.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tFROM\tTO\tSENSOR\tREASON\tVOTES")
+	for _, t := range resp.Transitions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			time.Unix(0, t.TimestampUnixNano).Local().Format(time.DateTime),
+			t.From, t.To, orDash(t.Sensor), orDash(t.Reason), formatVotes(t.Votes))
+	}
+	return tw.Flush()
+}
+
+// formatVotes renders the sensors that were not Green, e.g.
+// "memory=RED(0.97) buffer=YELLOW(0.62)".
+func formatVotes(votes []*protocol.SensorVote) string {
+	parts := make([]string, 0, len(votes))
+	for _, v := range votes {
+		if v.Zone == protocol.SomaticZone_ZONE_GREEN {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s=%s(%.2f)", v.Sensor, v.Zone, v.Value))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sungp/gophership/pkg/protocol"
+)
+
+func TestWriteHistory(t *testing.T) {
+	resp := &protocol.ZoneHistoryResponse{Transitions: []*protocol.ZoneTransition{{
+		TimestampUnixNano: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC).UnixNano(),
+		From:              protocol.SomaticZone_ZONE_GREEN,
+		To:                protocol.SomaticZone_ZONE_RED,
+		Sensor:            "memory",
+		Reason:            "Memory usage near limit",
+		Votes: []*protocol.SensorVote{
+			{Sensor: "memory", Zone: protocol.SomaticZone_ZONE_RED, Value: 0.971},
+			{Sensor: "cpu", Zone: protocol.SomaticZone_ZONE_GREEN, Value: 0.2},
+			{Sensor: "buffer", Zone: protocol.SomaticZone_ZONE_YELLOW, Value: 0.62},
+		},
+	}}}
+
+	var buf bytes.Buffer
+	if err := writeHistory(&buf, resp, "table"); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"TIME", "GREEN", "RED", "Memory usage near limit", "memory=RED(0.97) buffer=YELLOW(0.62)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "cpu=") {
+		t.Errorf("table output lists Green votes:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := writeHistory(&buf, resp, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("Failed to unmarshal JSON: %v", err)
+	}
+	if len(records) != 1 || records[0]["time"] != "2026-01-02T03:04:05Z" || records[0]["sensor"] != "memory" {
+		t.Errorf("Unexpected JSON records: %v", records)
+	}
+
+	buf.Reset()
+	if err := writeHistory(&buf, &protocol.ZoneHistoryResponse{}, "table"); err != nil || !strings.Contains(buf.String(), "No zone transitions") {
+		t.Errorf("empty history output = %q, %v", buf.String(), err)
+	}
+}
@@ -39,6 +39,10 @@ func main() {
 	mockZone := flag.Int("mock-zone", -1, "Mock somatic zone for testing (0=Green, 1=Yellow, 2=Red)")
 	overrideZone := flag.String("zone", "", "Somatic zone to force (green, yellow, red, none)")
 	refreshInterval := flag.Duration("refresh", 1*time.Second, "Refresh interval for the dashboard (e.g. 500ms, 2s)")
+	overrideTTL := flag.Duration("ttl", time.Hour, "How long an override lasts before it clears itself (0 = until cleared)")
+	overrideReason := flag.String("reason", "", "Why the zone is overridden, shown in status and history")
+	overrideBy := flag.String("by", os.Getenv("USER"), "On whose behalf the zone is overridden (unverified; the server records the caller's identity)")
+	historyLimit := flag.Uint("limit", 20, "Maximum number of zone transitions to show with history (0 = all retained)")
 	flag.Parse()
 
 	// Initialize structured logging
@@ -177,25 +181,51 @@ func main() {
 
 		client := protocol.NewControlServiceClient(conn)
 		_, err = client.OverrideSomaticZone(opCtx, &protocol.OverrideSomaticZoneRequest{
-			Zone: target,
+			Zone:        target,
+			TtlSeconds:  uint32((*overrideTTL).Seconds()),
+			Reason:      *overrideReason,
+			RequestedBy: *overrideBy,
 		})
 		if err != nil {
 			diagnoseAndExit(err)
 		}
 
-		if target == protocol.SomaticZone_ZONE_UNSPECIFIED {
+		switch {
+		case target == protocol.SomaticZone_ZONE_UNSPECIFIED:
 			fmt.Println("Somatic override cleared. Sensor control restored.")
-		} else {
-			fmt.Printf("Somatic zone successfully overridden to %s\n", strings.ToUpper(zoneStr))
+		case *overrideTTL > 0:
+			fmt.Printf("Somatic zone successfully overridden to %s for %s\n", strings.ToUpper(zoneStr), *overrideTTL)
+		default:
+			fmt.Printf("Somatic zone successfully overridden to %s until cleared\n", strings.ToUpper(zoneStr))
 		}
 		os.Exit(0)
 
+	case "history":
+		opCtx, opCancel := context.WithTimeout(ctx, 5*time.Second)
+		defer opCancel()
+
+		conn, err := dialControlPlane(opCtx, *addr, *socketPath, *useTLS, *certFile, *keyFile, *caFile)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to connect to control plane")
+			os.Exit(129)
+		}
+		defer conn.Close()
+
+		client := protocol.NewControlServiceClient(conn)
+		resp, err := client.GetZoneHistory(opCtx, &protocol.ZoneHistoryRequest{Limit: uint32(*historyLimit)})
+		if err != nil {
+			diagnoseAndExit(err)
+		}
+		os.Exit(executeHistory(resp, *outputFormat))
+
 	default:
 		fmt.Printf("GopherShip %s (%s)\n", Version, Commit)
 		fmt.Println("Usage: gs-ctl [flags] <command>")
 		fmt.Println("\nCommands:")
 		fmt.Println("  status    Show internal engine health and pressure zone")
 		fmt.Println("  top       Start a real-time somatic dashboard")
+		fmt.Println("  override  Force a somatic zone (-zone, -ttl, -reason, -by); -zone none clears it")
+		fmt.Println("  history   Show recent somatic zone transitions and why they happened")
 		fmt.Println("\nFlags:")
 		flag.PrintDefaults()
 	}
@@ -236,6 +266,15 @@ func executeStatus(s *protocol.StatusResponse, format string) int {
 		"Heap Objects":   s.HeapObjects,
 		"Goroutines":     s.GoroutineCount,
 	}
+	if s.ZoneDriver != "" {
+		data["Zone Driver"] = fmt.Sprintf("%s (%s)", s.ZoneDriver, s.ZoneReason)
+	}
+	if s.Override != nil {
+		data["Override"] = formatOverride(s.Override)
+	}
+	if len(s.Downstreams) > 0 {
+		data["Downstreams"] = formatDownstreams(s.Downstreams)
+	}
 
 	if format == "table" {
 		fmt.Println("GopherShip Engine Status")
@@ -260,6 +299,39 @@ func executeStatus(s *protocol.StatusResponse, format string) int {
 	}
 }
 
+// formatOverride renders the override in force as "ZONE by who until
+// <local time> (reason)".
+func formatOverride(o *protocol.ZoneOverride) string {
+	out := o.Zone.String()
+	if o.SetBy != "" {
+		out += " by " + o.SetBy
+	}
+	if o.RequestedBy != "" {
+		out += " for " + o.RequestedBy
+	}
+	if o.ExpiresUnixNano != 0 {
+		out += " until " + time.Unix(0, o.ExpiresUnixNano).Local().Format(time.DateTime)
+	}
+	if o.Reason != "" {
+		out += " (" + o.Reason + ")"
+	}
+	return out
+}
+
+// formatDownstreams renders exporter health as "name=STATE" pairs, with the
+// failure count for exporters that are not healthy.
+func formatDownstreams(ds []*protocol.DownstreamStatus) string {
+	parts := make([]string, 0, len(ds))
+	for _, d := range ds {
+		p := d.Name + "=" + d.State.String()
+		if d.State != protocol.DownstreamState_DOWNSTREAM_HEALTHY {
+			p += fmt.Sprintf(" (%d failures)", d.ConsecutiveFailures)
+		}
+		parts = append(parts, p)
+	}
+	return strings.Join(parts, ", ")
+}
+
 func loadClientTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
 	if certFile == "" || keyFile == "" || caFile == "" {
 		return nil, fmt.Errorf("cert, key, and ca flags are required for mTLS")
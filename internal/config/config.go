@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -18,12 +19,439 @@ type Config struct {
 		} `yaml:"tls,omitempty"`
 	} `yaml:"ingester,omitempty"`
 	Monitoring struct {
+		// MaxRAM is the memory limit zones are measured against. Zero detects
+		// it from the cgroup (memory.max/memory.high, or the v1 limit),
+		// falling back to the host's total memory.
 		MaxRAM          uint64  `yaml:"max_ram,omitempty"`
 		YellowThreshold float64 `yaml:"yellow_threshold,omitempty"`
 		RedThreshold    float64 `yaml:"red_threshold,omitempty"`
 		IngesterBudget  uint64  `yaml:"ingester_budget,omitempty"`
 		VaultBudget     uint64  `yaml:"vault_budget,omitempty"`
+		ProcessorBudget uint64  `yaml:"processor_budget,omitempty"`
+		// CPU pressure thresholds (0-1) against the worst of process/cgroup
+		// utilisation, host utilisation and cgroup throttling.
+		CPUYellowThreshold float64   `yaml:"cpu_yellow_threshold,omitempty"`
+		CPURedThreshold    float64   `yaml:"cpu_red_threshold,omitempty"`
+		PSI                PSIConfig `yaml:"psi,omitempty"`
+		// Disk votes on free space of the vault directories (exporter
+		// fallback and queue WALs) and any extra Paths.
+		Disk DiskConfig `yaml:"disk,omitempty"`
+		// DisableGoMemLimit stops the engine from setting the Go runtime's
+		// soft memory limit to the Red threshold of MaxRAM.
+		DisableGoMemLimit bool       `yaml:"disable_go_memlimit,omitempty"`
+		Zones             ZoneConfig `yaml:"zones,omitempty"`
+		// Prediction enters Yellow early when buffer occupancy, ingester or
+		// memory usage trends toward Red.
+		Prediction PredictionConfig `yaml:"prediction,omitempty"`
+		// Overrides force a zone during recurring windows, e.g. Yellow
+		// during a nightly batch. Manual overrides take precedence.
+		Overrides []OverrideWindowConfig `yaml:"overrides,omitempty"`
+		// Hooks react to zone changes.
+		Hooks HooksConfig `yaml:"hooks,omitempty"`
+		// History keeps the last Size zone transitions for gs-ctl history,
+		// persisted to Path when set so they survive restarts.
+		History struct {
+			Size int    `yaml:"size,omitempty"`
+			Path string `yaml:"path,omitempty"`
+		} `yaml:"history,omitempty"`
 	} `yaml:"monitoring,omitempty"`
+	Processors []ProcessorConfig `yaml:"processors,omitempty"`
+	Exporters  []ExporterConfig  `yaml:"exporters,omitempty"`
+	Routing    RoutingConfig     `yaml:"routing,omitempty"`
+}
+
+// ZoneConfig tunes the somatic controller's hysteresis on ingestion buffer
+// occupancy. Watermarks are fractions of buffer capacity (0-1): a zone is
+// entered above its enter mark and left below its exit mark.
+type ZoneConfig struct {
+	YellowEnter float64 `yaml:"yellow_enter,omitempty"`
+	YellowExit  float64 `yaml:"yellow_exit,omitempty"`
+	RedEnter    float64 `yaml:"red_enter,omitempty"`
+	RedExit     float64 `yaml:"red_exit,omitempty"`
+	// Minimum time spent in each zone before leaving it. Escalating to Red
+	// is never delayed.
+	GreenDwell  time.Duration `yaml:"green_dwell,omitempty"`
+	YellowDwell time.Duration `yaml:"yellow_dwell,omitempty"`
+	RedDwell    time.Duration `yaml:"red_dwell,omitempty"`
+	// At most MaxTransitions zone changes within any RateWindow.
+	MaxTransitions int           `yaml:"max_transitions,omitempty"`
+	RateWindow     time.Duration `yaml:"rate_window,omitempty"`
+}
+
+// HooksConfig declares reactions to somatic zone changes. Rules run built-in
+// actions; Policies enables ZonePolicy implementations compiled into the
+// binary, by the name they were registered under.
+type HooksConfig struct {
+	Rules    []HookConfig `yaml:"rules,omitempty"`
+	Policies []string     `yaml:"policies,omitempty"`
+}
+
+// HookConfig runs an action when the zone changes.
+type HookConfig struct {
+	Name string `yaml:"name"`
+	// On lists the zones ("green", "yellow", "red") whose entry fires the
+	// hook; From optionally restricts the zone being left.
+	On   []string `yaml:"on"`
+	From []string `yaml:"from,omitempty"`
+	// Action is "webhook", "exec", "compression" or "log".
+	Action string `yaml:"action"`
+	// Timeout bounds each run. Default: 10s.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// URL receives a JSON POST of the zone change (webhook).
+	URL string `yaml:"url,omitempty"`
+	// Command is the program and arguments to run (exec). The zone change
+	// is passed in GS_ZONE, GS_ZONE_FROM, GS_ZONE_SENSOR and GS_ZONE_REASON.
+	Command []string `yaml:"command,omitempty"`
+	// Exporter and Compression switch an exporter's codec (compression).
+	Exporter    string `yaml:"exporter,omitempty"`
+	Compression string `yaml:"compression,omitempty"`
+}
+
+// OverrideWindowConfig is a recurring window during which the somatic zone
+// is forced.
+type OverrideWindowConfig struct {
+	Name string `yaml:"name"`
+	// Zone is "green", "yellow" or "red".
+	Zone string `yaml:"zone"`
+	// Start is the local time of day the window opens, as "HH:MM".
+	Start    string        `yaml:"start"`
+	Duration time.Duration `yaml:"duration"`
+	// Days restricts the window to weekdays ("mon" ... "sun"). Default: daily.
+	Days   []string `yaml:"days,omitempty"`
+	Reason string   `yaml:"reason,omitempty"`
+}
+
+// PredictionConfig tunes predictive pressure. Each sensor's rate of change
+// is smoothed with an EWMA; when it projects reaching Red within Horizon, the
+// sensor votes Yellow ahead of its threshold.
+type PredictionConfig struct {
+	// Horizon is how far ahead projections may trigger Yellow. Zero disables prediction.
+	Horizon time.Duration `yaml:"horizon,omitempty"`
+	// HalfLife is the age at which a rate sample weighs half as much as the newest. Default: 5s.
+	HalfLife time.Duration `yaml:"half_life,omitempty"`
+}
+
+// PSIConfig tunes the Linux pressure stall sensor. Thresholds are avg10
+// percentages (0-100) of wall time in which some or all tasks stalled on
+// cpu, memory or io; zero keeps the built-in default.
+type PSIConfig struct {
+	Disabled   bool    `yaml:"disabled,omitempty"`
+	SomeYellow float64 `yaml:"some_yellow,omitempty"`
+	SomeRed    float64 `yaml:"some_red,omitempty"`
+	FullYellow float64 `yaml:"full_yellow,omitempty"`
+	FullRed    float64 `yaml:"full_red,omitempty"`
+	// TriggerStall arms a kernel trigger on memory pressure that fires once
+	// tasks stall this long within TriggerWindow (default 2s). Unset disables it.
+	TriggerStall  time.Duration `yaml:"trigger_stall,omitempty"`
+	TriggerWindow time.Duration `yaml:"trigger_window,omitempty"`
+}
+
+// DiskConfig tunes the disk space sensor. Thresholds are the share (0-1) of
+// a filesystem's space still available; zero keeps the built-in default.
+type DiskConfig struct {
+	Disabled   bool    `yaml:"disabled,omitempty"`
+	YellowFree float64 `yaml:"yellow_free,omitempty"` // Default: 0.15
+	RedFree    float64 `yaml:"red_free,omitempty"`    // Default: 0.05
+	// Paths are watched in addition to the vault directories.
+	Paths []string `yaml:"paths,omitempty"`
+}
+
+// ProcessorConfig declares one stage of the processing chain, run in order
+// between the ingester and the exporters.
+type ProcessorConfig struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+	// MaxZone is the highest somatic zone in which the processor runs. The
+	// default depends on the type: opportunistic work stops above green.
+	MaxZone string             `yaml:"max_zone,omitempty"`
+	JSON    JSONParserConfig   `yaml:"json,omitempty"`
+	Regex   RegexParserConfig  `yaml:"regex,omitempty"`
+	Logfmt  LogfmtParserConfig `yaml:"logfmt,omitempty"`
+	Redact  RedactionConfig    `yaml:"redact,omitempty"`
+	// Attributes lists the actions of an "attributes" processor, applied in order.
+	Attributes []AttributeAction `yaml:"attributes,omitempty"`
+	Filter     FilterConfig      `yaml:"filter,omitempty"`
+	Enrich     EnrichConfig      `yaml:"enrich,omitempty"`
+	Dedup      DedupConfig       `yaml:"dedup,omitempty"`
+	Sampling   SamplingConfig    `yaml:"sampling,omitempty"`
+	Multiline  MultilineConfig   `yaml:"multiline,omitempty"`
+	// Metrics lists the rules of a "metrics" processor.
+	Metrics []LogMetricRule `yaml:"metrics,omitempty"`
+}
+
+// MultilineConfig stitches continuation lines (e.g. stack frames) into the
+// record that started them. At least one pattern is required: with only
+// StartPattern, every other line continues; with only ContinuePattern, every
+// other line starts a record.
+type MultilineConfig struct {
+	StartPattern    string `yaml:"start_pattern,omitempty"`
+	ContinuePattern string `yaml:"continue_pattern,omitempty"`
+	// StreamKeys are attribute keys (record, then resource) separating
+	// interleaved streams, e.g. log.file.path. Default: service.name.
+	StreamKeys []string `yaml:"stream_keys,omitempty"`
+	// Timeout releases a record when no continuation arrived for this long. Default: 2s.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// MaxLines releases a record once it has this many lines. Default: 500.
+	MaxLines int `yaml:"max_lines,omitempty"`
+}
+
+// SamplingConfig keeps a fraction of records. Records with a trace ID are
+// sampled consistently by hashing it, so a trace is kept or dropped as a whole.
+type SamplingConfig struct {
+	// Rates maps severity names (TRACE, DEBUG, INFO, WARN, ERROR, FATAL) or
+	// "default" to the fraction of records kept, from 0 to 1. Unlisted
+	// severities use "default", which itself defaults to 1.
+	Rates map[string]float64 `yaml:"rates,omitempty"`
+	// HashSeed varies the trace hash so that tiers sample independently.
+	HashSeed uint32 `yaml:"hash_seed,omitempty"`
+	// KeepErrorTraces holds the records of unsampled traces for Window and
+	// keeps them, along with the rest of the trace, if the trace logs an
+	// ERROR or worse in that time. Errors are then always kept.
+	KeepErrorTraces bool          `yaml:"keep_error_traces,omitempty"`
+	Window          time.Duration `yaml:"window,omitempty"`
+	// MaxTraces bounds the traces tracked by KeepErrorTraces. Default: 10000.
+	MaxTraces int `yaml:"max_traces,omitempty"`
+}
+
+// LogMetricRule derives a Prometheus metric from log records.
+type LogMetricRule struct {
+	// Name is the full metric name, e.g. gophership_logs_errors_total.
+	Name string `yaml:"name"`
+	Help string `yaml:"help,omitempty"`
+	// Type is "counter" (default) or "histogram".
+	Type string `yaml:"type,omitempty"`
+	// Filter is an optional filter expression selecting the counted records.
+	Filter string `yaml:"filter,omitempty"`
+	// Labels are attribute keys (record, then resource) used as labels, with
+	// dots replaced by underscores. "severity" is the record severity name.
+	Labels []string `yaml:"labels,omitempty"`
+	// Value is the numeric attribute observed by histograms. For counters it
+	// is optional and adds the value instead of 1.
+	Value   string    `yaml:"value,omitempty"`
+	Buckets []float64 `yaml:"buckets,omitempty"`
+	// MaxSeries caps distinct label combinations; further combinations are
+	// folded into a single series labelled "_other". Default: 1000.
+	MaxSeries int `yaml:"max_series,omitempty"`
+}
+
+// DedupConfig collapses repeated records. Records are identical when their
+// body, severity and the listed Keys match.
+type DedupConfig struct {
+	// Keys are attribute keys (record, then resource) that are part of the
+	// identity, e.g. service.name.
+	Keys []string `yaml:"keys,omitempty"`
+	// Window is how long repeats are collapsed after the first occurrence. Default: 10s.
+	Window time.Duration `yaml:"window,omitempty"`
+	// MaxEntries bounds the LRU of tracked records. Default: 10000.
+	MaxEntries int `yaml:"max_entries,omitempty"`
+}
+
+// EnrichConfig adds host, container and Kubernetes metadata to resources.
+type EnrichConfig struct {
+	// Detectors selects "host", "container" and "k8s". Empty enables all of them.
+	Detectors []string `yaml:"detectors,omitempty"`
+	// PodInfoDir is the mount path of the downward-API volume. Default: /etc/podinfo.
+	PodInfoDir string `yaml:"pod_info_dir,omitempty"`
+	// Override replaces attributes already set by the sender.
+	Override bool `yaml:"override,omitempty"`
+}
+
+// FilterConfig drops records matching any of its rules.
+type FilterConfig struct {
+	Drop []FilterRule `yaml:"drop"`
+}
+
+// FilterRule is a named filter expression, e.g.
+// `attributes["http.target"] == "/healthz" or severity < INFO`.
+type FilterRule struct {
+	Name string `yaml:"name"`
+	Expr string `yaml:"expr"`
+}
+
+// AttributeAction transforms one attribute key.
+type AttributeAction struct {
+	Key string `yaml:"key"`
+	// Action is "insert" (only if absent), "update" (only if present),
+	// "upsert", "rename", "delete", "hash" or "truncate".
+	Action string `yaml:"action"`
+	// Scope selects "log" (default), "scope" or "resource" attributes.
+	Scope string `yaml:"scope,omitempty"`
+	// Value and Type (as in RegexParserConfig.Types) for insert/update/upsert.
+	Value string `yaml:"value,omitempty"`
+	Type  string `yaml:"type,omitempty"`
+	// NewKey is the rename target; an existing attribute with that key is replaced.
+	NewKey string `yaml:"new_key,omitempty"`
+	// MaxLength is the truncate limit in bytes, cut at a UTF-8 boundary.
+	MaxLength int `yaml:"max_length,omitempty"`
+	// HashKey optionally keys the SHA-256 used by hash (HMAC).
+	HashKey string `yaml:"hash_key,omitempty"`
+}
+
+// RedactionConfig scrubs sensitive values from bodies and attribute values.
+type RedactionConfig struct {
+	// Detectors selects built-in detectors: "email", "credit_card", "ip",
+	// "jwt" and "bearer". Empty enables all of them.
+	Detectors []string `yaml:"detectors,omitempty"`
+	// Rules adds custom patterns. When a pattern has a capture group, only
+	// the first group is redacted.
+	Rules []RedactionRule `yaml:"rules,omitempty"`
+	// Action is the default for detectors and rules: "mask" (default),
+	// "hash" or "drop" (removes the attribute or clears the body).
+	Action string `yaml:"action,omitempty"`
+	// HashKey keys the HMAC used by the hash action so that hashes cannot be
+	// reversed by brute force without it.
+	HashKey string `yaml:"hash_key,omitempty"`
+	// SkipAttributes lists attribute keys that are never scanned.
+	SkipAttributes []string `yaml:"skip_attributes,omitempty"`
+}
+
+// RedactionRule is a custom redaction pattern.
+type RedactionRule struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+	Action  string `yaml:"action,omitempty"`
+}
+
+// RegexParserConfig extracts named capture groups from string bodies into
+// record attributes.
+type RegexParserConfig struct {
+	Pattern string `yaml:"pattern"`
+	// Types coerces captures to "int", "float", "bool" or "duration"
+	// (stored as int64 nanoseconds). Unlisted captures stay strings.
+	Types map[string]string `yaml:"types,omitempty"`
+}
+
+// LogfmtParserConfig extracts key=value pairs from string bodies into record
+// attributes.
+type LogfmtParserConfig struct {
+	// Types coerces values like RegexParserConfig.Types.
+	Types map[string]string `yaml:"types,omitempty"`
+}
+
+// JSONParserConfig parses JSON string bodies into structured bodies.
+type JSONParserConfig struct {
+	// Promote lists body fields (dotted paths for nested objects) that are
+	// moved from the body into record attributes.
+	Promote []string `yaml:"promote,omitempty"`
+	// Well-known keys; defaults cover common logging libraries.
+	TimestampKeys []string `yaml:"timestamp_keys,omitempty"`
+	SeverityKeys  []string `yaml:"severity_keys,omitempty"`
+	TraceIDKeys   []string `yaml:"trace_id_keys,omitempty"`
+	SpanIDKeys    []string `yaml:"span_id_keys,omitempty"`
+}
+
+// RoutingConfig maps log records to exporters. Every matching route receives
+// the record; records that match no route go to Default. With no routes and
+// no default, every exporter receives everything.
+type RoutingConfig struct {
+	Default []string      `yaml:"default,omitempty"`
+	Routes  []RouteConfig `yaml:"routes,omitempty"`
+}
+
+// RouteConfig is a single routing rule.
+type RouteConfig struct {
+	Name      string      `yaml:"name"`
+	Match     MatchConfig `yaml:"match,omitempty"`
+	Exporters []string    `yaml:"exporters"`
+	// MaxZone is the highest somatic zone in which the route runs ("green",
+	// "yellow" or "red"). Matching records are skipped above it. Default: red.
+	MaxZone string `yaml:"max_zone,omitempty"`
+}
+
+// MatchConfig selects records. All configured conditions must hold; an empty
+// MatchConfig matches everything. BodyContains holds when any substring occurs.
+type MatchConfig struct {
+	Resource     map[string]string `yaml:"resource,omitempty"`
+	Attributes   map[string]string `yaml:"attributes,omitempty"`
+	MinSeverity  string            `yaml:"min_severity,omitempty"`
+	BodyContains []string          `yaml:"body_contains,omitempty"`
+	BodyRegex    string            `yaml:"body_regex,omitempty"`
+}
+
+// ExporterConfig declares a named downstream sink and its batching policy.
+type ExporterConfig struct {
+	Name  string      `yaml:"name"`
+	Type  string      `yaml:"type"`
+	Batch BatchConfig `yaml:"batch,omitempty"`
+	Retry RetryConfig `yaml:"retry,omitempty"`
+	Queue QueueConfig `yaml:"queue,omitempty"`
+	S3    S3Config    `yaml:"s3,omitempty"`
+	// LoadBalancing configures the "loadbalancing" OTLP exporter.
+	LoadBalancing LoadBalancingConfig `yaml:"loadbalancing,omitempty"`
+}
+
+// LoadBalancingConfig forwards to a pool of OTLP/gRPC collectors, keeping
+// records with the same routing key on the same backend.
+type LoadBalancingConfig struct {
+	// Endpoints is a static list of host:port collectors.
+	Endpoints []string `yaml:"endpoints,omitempty"`
+	// DNS discovers endpoints instead of (or in addition to) the static list.
+	DNS struct {
+		// Hostname is resolved via A/AAAA records and combined with Port.
+		Hostname string `yaml:"hostname,omitempty"`
+		Port     string `yaml:"port,omitempty"`
+		// SRV is a full SRV name (e.g. _otlp._tcp.collectors.svc) and takes precedence over Hostname.
+		SRV      string        `yaml:"srv,omitempty"`
+		Interval time.Duration `yaml:"interval,omitempty"`
+	} `yaml:"dns,omitempty"`
+	// RoutingKey is "trace_id" (default), "service" or "attribute".
+	RoutingKey string `yaml:"routing_key,omitempty"`
+	// RoutingAttribute is the record or resource attribute used when RoutingKey is "attribute".
+	RoutingAttribute string        `yaml:"routing_attribute,omitempty"`
+	Timeout          time.Duration `yaml:"timeout,omitempty"`
+	Insecure         bool          `yaml:"insecure,omitempty"`
+	CAFile           string        `yaml:"ca_file,omitempty"`
+	// RetryAfter is how long an endpoint is skipped after a failed export.
+	RetryAfter time.Duration `yaml:"retry_after,omitempty"`
+}
+
+// RetryConfig controls the retry, circuit breaker and vault fallback wrapper
+// applied to every exporter.
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts,omitempty"`
+	InitialBackoff time.Duration `yaml:"initial_backoff,omitempty"`
+	MaxBackoff     time.Duration `yaml:"max_backoff,omitempty"`
+	Multiplier     float64       `yaml:"multiplier,omitempty"`
+	// FailureThreshold is the number of consecutive failed exports that opens the breaker.
+	FailureThreshold int `yaml:"failure_threshold,omitempty"`
+	// OpenTimeout is how long the breaker stays open before a trial export.
+	OpenTimeout time.Duration `yaml:"open_timeout,omitempty"`
+	// FallbackDir is a vault directory that receives batches while the
+	// breaker is open; they are replayed to the exporter once it closes. It
+	// cannot be combined with a queue.
+	FallbackDir string `yaml:"fallback_dir,omitempty"`
+}
+
+// QueueConfig enables a disk-backed sending queue. Batches are acknowledged
+// and reclaimed only after a successful export (at-least-once delivery).
+type QueueConfig struct {
+	// Dir is a vault directory dedicated to this exporter's queue; empty disables it.
+	Dir         string `yaml:"dir,omitempty"`
+	SegmentSize int64  `yaml:"segment_size,omitempty"`
+}
+
+// BatchConfig controls how many records are accumulated before an export.
+type BatchConfig struct {
+	MaxRecords    int           `yaml:"max_records,omitempty"`
+	FlushInterval time.Duration `yaml:"flush_interval,omitempty"`
+}
+
+// S3Config targets an S3-compatible object store (AWS S3, MinIO, Ceph RGW).
+type S3Config struct {
+	Endpoint        string `yaml:"endpoint,omitempty"`
+	Region          string `yaml:"region,omitempty"`
+	Bucket          string `yaml:"bucket,omitempty"`
+	Prefix          string `yaml:"prefix,omitempty"`
+	AccessKeyID     string `yaml:"access_key_id,omitempty"`
+	SecretAccessKey string `yaml:"secret_access_key,omitempty"`
+	SessionToken    string `yaml:"session_token,omitempty"`
+	ForcePathStyle  bool   `yaml:"force_path_style,omitempty"`
+	// Compression is one of "gzip" (default), "lz4" or "none".
+	Compression string `yaml:"compression,omitempty"`
+	// MultipartThreshold is the object size above which multipart upload is used.
+	MultipartThreshold int64         `yaml:"multipart_threshold,omitempty"`
+	PartSize           int64         `yaml:"part_size,omitempty"`
+	Timeout            time.Duration `yaml:"timeout,omitempty"`
 }
 
 func Load(path string) (*Config, error) {
@@ -75,6 +503,11 @@ func Load(path string) (*Config, error) {
 			cfg.Monitoring.VaultBudget = v
 		}
 	}
+	if env := os.Getenv("GS_MONITOR_PROCESSOR_BUDGET"); env != "" {
+		if v, err := parseUint64(env); err == nil {
+			cfg.Monitoring.ProcessorBudget = v
+		}
+	}
 
 	return cfg, nil
 }
@@ -89,10 +522,28 @@ func Default() *Config {
 	cfg := &Config{}
 	cfg.Ingester.BufferSize = 8192
 	cfg.Ingester.Addr = ":4317"
-	cfg.Monitoring.MaxRAM = 1024 * 1024 * 1024 // 1GB
+	cfg.Monitoring.MaxRAM = 0 // Detect from cgroup or host
 	cfg.Monitoring.YellowThreshold = 0.80
 	cfg.Monitoring.RedThreshold = 0.95
 	cfg.Monitoring.IngesterBudget = 256 * 1024 * 1024 // 256MB
 	cfg.Monitoring.VaultBudget = 512 * 1024 * 1024    // 512MB
+	cfg.Monitoring.ProcessorBudget = 64 * 1024 * 1024 // 64MB
+	cfg.Monitoring.CPUYellowThreshold = 0.75
+	cfg.Monitoring.CPURedThreshold = 0.90
+	cfg.Monitoring.History.Size = 256
+	cfg.Monitoring.Zones = ZoneConfig{
+		YellowEnter:    0.60,
+		YellowExit:     0.20,
+		RedEnter:       0.85,
+		RedExit:        0.40,
+		YellowDwell:    5 * time.Second,
+		RedDwell:       10 * time.Second,
+		MaxTransitions: 6,
+		RateWindow:     time.Minute,
+	}
+	cfg.Monitoring.Prediction = PredictionConfig{
+		Horizon:  30 * time.Second,
+		HalfLife: 5 * time.Second,
+	}
 	return cfg
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/sungp/gophership/internal/somatic"
 	"github.com/sungp/gophership/pkg/protocol"
@@ -89,3 +90,59 @@ func TestServer_OverrideSomaticZone(t *testing.T) {
 		t.Errorf("expected zone Green after clearing, got %v", resp.Zone)
 	}
 }
+
+func TestServer_OverrideWithTTLAndIdentity(t *testing.T) {
+	sc := somatic.NewController(&mockPressureProvider{})
+	socketPath := filepath.Join(t.TempDir(), "gophership_test.sock")
+	srv := NewServer("9094", socketPath, nil, sc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer srv.Stop(ctx)
+
+	conn, err := grpc.Dial("unix:"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+	client := protocol.NewControlServiceClient(conn)
+
+	before := time.Now()
+	_, err = client.OverrideSomaticZone(ctx, &protocol.OverrideSomaticZoneRequest{
+		Zone:        protocol.SomaticZone_ZONE_YELLOW,
+		TtlSeconds:  1,
+		Reason:      "nightly reindex",
+		RequestedBy: "alice",
+	})
+	if err != nil {
+		t.Fatalf("failed to override: %v", err)
+	}
+
+	resp, err := client.GetSomaticStatus(ctx, &emptypb.Empty{})
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	o := resp.Override
+	if resp.Zone != protocol.SomaticZone_ZONE_YELLOW || o == nil {
+		t.Fatalf("expected a Yellow override, got zone %v override %+v", resp.Zone, o)
+	}
+	// The server records the caller's transport whatever requested_by claims.
+	if o.Zone != protocol.SomaticZone_ZONE_YELLOW || o.Reason != "nightly reindex" || o.SetBy != "unix socket" || o.RequestedBy != "alice" {
+		t.Errorf("unexpected override: %+v", o)
+	}
+	if expires := time.Unix(0, o.ExpiresUnixNano); expires.Before(before.Add(time.Second)) || expires.After(time.Now().Add(time.Second)) {
+		t.Errorf("override expires at %s, want about 1s from now", expires)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	resp, err = client.GetSomaticStatus(ctx, &emptypb.Empty{})
+	if err != nil {
+		t.Fatalf("failed to get status: %v", err)
+	}
+	if resp.Override != nil || resp.Zone != protocol.SomaticZone_ZONE_GREEN {
+		t.Errorf("expected the override to expire back to Green, got zone %v override %+v", resp.Zone, resp.Override)
+	}
+}
@@ -36,10 +36,17 @@ type Server struct {
 	tlsConfig  *tls.Config
 	startTime  time.Time
 	somatic    *somatic.Controller
+	rt         *stochastic.Runtime
 }
 
-// NewServer initializes the GopherShip control plane.
+// NewServer initializes the GopherShip control plane. It reports on and
+// overrides the runtime of the somatic controller, or the default runtime
+// when somatic is nil.
 func NewServer(port, socketPath string, tlsConfig *tls.Config, somatic *somatic.Controller) *Server {
+	rt := stochastic.Default
+	if somatic != nil {
+		rt = somatic.Runtime()
+	}
 	if port == "" {
 		port = DefaultPort
 	}
@@ -52,6 +59,7 @@ func NewServer(port, socketPath string, tlsConfig *tls.Config, somatic *somatic.
 		tlsConfig:  tlsConfig,
 		startTime:  time.Now(),
 		somatic:    somatic,
+		rt:         rt,
 	}
 }
 
@@ -163,23 +171,35 @@ func (s *Server) WatchSomaticStatus(req *protocol.WatchStatusRequest, stream pro
 
 // populateStatus is a zero-allocation helper to fill a StatusResponse.
 func (s *Server) populateStatus(resp *protocol.StatusResponse) error {
-	status := stochastic.GetAmbientStatus()
-
-	zone := protocol.SomaticZone_ZONE_GREEN
-	switch status {
-	case stochastic.StatusYellow:
-		zone = protocol.SomaticZone_ZONE_YELLOW
-	case stochastic.StatusRed:
-		zone = protocol.SomaticZone_ZONE_RED
-	}
+	zone := protoZone(s.rt.Status())
 
 	var usage, heap uint64
 	var score uint32
-	if stochastic.Monitor != nil {
-		usage, heap, score = stochastic.Monitor.Telemetry()
+	resp.Downstreams = resp.Downstreams[:0]
+	if m := s.rt.Monitor(); m != nil {
+		usage, heap, score = m.Telemetry()
+		for _, d := range m.Downstreams() {
+			resp.Downstreams = append(resp.Downstreams, &protocol.DownstreamStatus{
+				Name:                d.Name,
+				State:               protocol.DownstreamState(d.State),
+				ConsecutiveFailures: d.ConsecutiveFailures,
+				LastError:           d.LastError,
+			})
+		}
+	}
+
+	decision := s.rt.Zones().Decision()
+
+	resp.Override = nil
+	if s.somatic != nil {
+		if o, ok := s.somatic.ActiveOverride(); ok {
+			resp.Override = protoOverride(o)
+		}
 	}
 
 	resp.Zone = zone
+	resp.ZoneDriver = decision.Sensor
+	resp.ZoneReason = decision.Reason
 	resp.PressureScore = score
 	resp.MemoryUsageBytes = usage
 	resp.HeapObjects = heap
@@ -188,6 +208,33 @@ func (s *Server) populateStatus(resp *protocol.StatusResponse) error {
 	return nil
 }
 
+// protoZone maps an ambient status to its wire enum.
+func protoZone(status stochastic.AmbientStatus) protocol.SomaticZone {
+	switch status {
+	case stochastic.StatusYellow:
+		return protocol.SomaticZone_ZONE_YELLOW
+	case stochastic.StatusRed:
+		return protocol.SomaticZone_ZONE_RED
+	default:
+		return protocol.SomaticZone_ZONE_GREEN
+	}
+}
+
+// protoOverride maps an override to its wire form.
+func protoOverride(o somatic.Override) *protocol.ZoneOverride {
+	po := &protocol.ZoneOverride{
+		Zone:          protoZone(o.Zone),
+		Reason:        o.Reason,
+		SetBy:         o.By,
+		SetAtUnixNano: o.SetAt.UnixNano(),
+		RequestedBy:   o.OnBehalfOf,
+	}
+	if !o.Until.IsZero() {
+		po.ExpiresUnixNano = o.Until.UnixNano()
+	}
+	return po
+}
+
 // GetSomaticStatus implements protocol.ControlServiceServer.
 func (s *Server) GetSomaticStatus(ctx context.Context, _ *emptypb.Empty) (*protocol.StatusResponse, error) {
 	resp := &protocol.StatusResponse{}
@@ -203,9 +250,13 @@ func (s *Server) OverrideSomaticZone(ctx context.Context, req *protocol.Override
 		return nil, fmt.Errorf("somatic controller not initialized")
 	}
 
+	// The audit trail records the verified caller; requested_by is only
+	// the operator it claims to act for.
+	by := peerIdentity(ctx)
+
 	if req.Zone == protocol.SomaticZone_ZONE_UNSPECIFIED {
 		s.somatic.ClearOverride()
-		log.Info().Msg("Somatic zone override cleared (Manual Control End)")
+		log.Info().Str("by", by).Str("on_behalf_of", req.RequestedBy).Msg("Somatic zone override cleared (Manual Control End)")
 		return &emptypb.Empty{}, nil
 	}
 
@@ -221,17 +272,53 @@ func (s *Server) OverrideSomaticZone(ctx context.Context, req *protocol.Override
 		return nil, fmt.Errorf("invalid somatic zone: %v", req.Zone)
 	}
 
-	s.somatic.Override(target)
+	o := somatic.Override{Zone: target, Reason: req.Reason, By: by, OnBehalfOf: req.RequestedBy}
+	if req.TtlSeconds > 0 {
+		o.Until = time.Now().Add(time.Duration(req.TtlSeconds) * time.Second)
+	}
+	s.somatic.SetOverride(o)
 	log.Info().
 		Str("target_zone", target.String()).
+		Str("by", by).
+		Str("on_behalf_of", req.RequestedBy).
+		Str("reason", req.Reason).
+		Uint32("ttl_seconds", req.TtlSeconds).
 		Msg("Somatic zone override triggered (Emergency Manual Override)")
 
 	return &emptypb.Empty{}, nil
 }
 
+// GetZoneHistory implements protocol.ControlServiceServer.
+func (s *Server) GetZoneHistory(ctx context.Context, req *protocol.ZoneHistoryRequest) (*protocol.ZoneHistoryResponse, error) {
+	transitions := s.rt.Zones().History().Recent(int(req.Limit))
+	resp := &protocol.ZoneHistoryResponse{
+		Transitions: make([]*protocol.ZoneTransition, 0, len(transitions)),
+	}
+	for _, t := range transitions {
+		pt := &protocol.ZoneTransition{
+			TimestampUnixNano: t.At.UnixNano(),
+			From:              protoZone(t.From),
+			To:                protoZone(t.To),
+			Sensor:            t.Sensor,
+			Reason:            t.Reason,
+			Votes:             make([]*protocol.SensorVote, 0, len(t.Votes)),
+		}
+		for _, v := range t.Votes {
+			pt.Votes = append(pt.Votes, &protocol.SensorVote{
+				Sensor: v.Sensor,
+				Zone:   protoZone(v.Status),
+				Reason: v.Reason,
+				Value:  v.Value,
+			})
+		}
+		resp.Transitions = append(resp.Transitions, pt)
+	}
+	return resp, nil
+}
+
 // Check implements grpc_health_v1.HealthServer.
 func (s *Server) Check(ctx context.Context, _ *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
-	status := stochastic.GetAmbientStatus()
+	status := s.rt.Status()
 	servingStatus := grpc_health_v1.HealthCheckResponse_SERVING
 
 	// Red zone means we are in "Store Raw" mode - core reflex is saturated.
@@ -262,6 +349,24 @@ func (s *Server) securityInterceptor(ctx context.Context, req interface{}, info
 	return handler(ctx, req)
 }
 
+// peerIdentity names the caller for the audit trail: the subject of its
+// client certificate under mTLS, otherwise its transport.
+func peerIdentity(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+		if cn := info.State.PeerCertificates[0].Subject.CommonName; cn != "" {
+			return "cn=" + cn
+		}
+	}
+	if p.Addr != nil && p.Addr.Network() == "unix" {
+		return "unix socket"
+	}
+	return ""
+}
+
 func (s *Server) isUDS(ctx context.Context) bool {
 	p, ok := peer.FromContext(ctx)
 	if !ok {
@@ -272,7 +377,7 @@ func (s *Server) isUDS(ctx context.Context) bool {
 
 // Watch implements grpc_health_v1.HealthServer.
 func (s *Server) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
-	statusChan, cleanup := stochastic.SubscribeStatus()
+	statusChan, cleanup := s.rt.Subscribe()
 	defer cleanup()
 
 	// Send initial status immediately
@@ -6,9 +6,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/sungp/gophership/internal/stochastic"
 	"github.com/sungp/gophership/pkg/protocol"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 func TestWatchSomaticStatus(t *testing.T) {
@@ -59,3 +61,84 @@ func TestWatchSomaticStatus(t *testing.T) {
 		t.Logf("Received update %d: Zone=%v, Pressure=%d", i, resp.Zone, resp.PressureScore)
 	}
 }
+
+func TestGetSomaticStatus_Downstreams(t *testing.T) {
+	monitor := stochastic.NewSensingMonitor(1024, 1<<40, 0.80, 0.95, 0, 0)
+	monitor.ReportDownstream(stochastic.DownstreamHealth{Name: "archive", State: stochastic.DownstreamDegraded, ConsecutiveFailures: 7, LastError: "503"})
+	stochastic.SetGlobalMonitor(monitor)
+	t.Cleanup(func() { stochastic.SetGlobalMonitor(nil) })
+
+	lis := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	protocol.RegisterControlServiceServer(grpcServer, NewServer("", "", nil, nil))
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		}),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := protocol.NewControlServiceClient(conn).GetSomaticStatus(context.Background(), &emptypb.Empty{})
+	if err != nil {
+		t.Fatalf("GetSomaticStatus failed: %v", err)
+	}
+	if len(resp.Downstreams) != 1 {
+		t.Fatalf("Expected 1 downstream over the wire, got %d", len(resp.Downstreams))
+	}
+	d := resp.Downstreams[0]
+	if d.Name != "archive" || d.State != protocol.DownstreamState_DOWNSTREAM_DOWN || d.ConsecutiveFailures != 7 || d.LastError != "503" {
+		t.Errorf("Unexpected downstream status: %+v", d)
+	}
+}
+
+func TestGetZoneHistory(t *testing.T) {
+	stochastic.MustSetAmbientStatus(stochastic.StatusGreen)
+	history := stochastic.NewHistory(16)
+	stochastic.Zones.SetHistory(history)
+	t.Cleanup(func() {
+		stochastic.Zones.SetHistory(stochastic.NewHistory(stochastic.DefaultHistorySize))
+		stochastic.MustSetAmbientStatus(stochastic.StatusGreen)
+	})
+	history.Record(stochastic.Transition{At: time.Unix(100, 0), From: stochastic.StatusGreen, To: stochastic.StatusYellow, Sensor: "cpu"})
+	history.Record(stochastic.Transition{
+		At: time.Unix(200, 0), From: stochastic.StatusYellow, To: stochastic.StatusRed, Sensor: "buffer", Reason: "Ingestion buffer occupancy",
+		Votes: []stochastic.Vote{{Sensor: "buffer", Status: stochastic.StatusRed, Value: 0.91}},
+	})
+
+	lis := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	protocol.RegisterControlServiceServer(grpcServer, NewServer("", "", nil, nil))
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		}),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("Failed to dial bufnet: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := protocol.NewControlServiceClient(conn).GetZoneHistory(context.Background(), &protocol.ZoneHistoryRequest{Limit: 1})
+	if err != nil {
+		t.Fatalf("GetZoneHistory failed: %v", err)
+	}
+	if len(resp.Transitions) != 1 {
+		t.Fatalf("Expected 1 transition with limit 1, got %d", len(resp.Transitions))
+	}
+	tr := resp.Transitions[0]
+	if tr.TimestampUnixNano != time.Unix(200, 0).UnixNano() || tr.From != protocol.SomaticZone_ZONE_YELLOW || tr.To != protocol.SomaticZone_ZONE_RED || tr.Sensor != "buffer" {
+		t.Errorf("Unexpected transition: %+v", tr)
+	}
+	if len(tr.Votes) != 1 || tr.Votes[0].Zone != protocol.SomaticZone_ZONE_RED || tr.Votes[0].Value != 0.91 {
+		t.Errorf("Unexpected votes: %+v", tr.Votes)
+	}
+}
@@ -0,0 +1,167 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sungp/gophership/internal/stochastic"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+const (
+	// DefaultBatchRecords is the number of records that triggers a flush.
+	DefaultBatchRecords = 8192
+	// DefaultFlushInterval bounds how long a partial batch may wait.
+	DefaultFlushInterval = 5 * time.Second
+	// DefaultFlushTimeout bounds a single downstream export.
+	DefaultFlushTimeout = 30 * time.Second
+)
+
+// Batcher accumulates resource logs and hands full batches to the wrapped
+// exporter from a single background goroutine. When the downstream is slower
+// than ingestion, Export blocks, which backs up the ingester buffer and lets
+// the somatic reflex take over.
+type Batcher struct {
+	next       Exporter
+	maxRecords int
+	interval   time.Duration
+	metrics    *metrics
+
+	mu      sync.Mutex
+	pending []*logsv1.ResourceLogs
+	records int
+
+	ready chan []*logsv1.ResourceLogs
+	quit  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+// NewBatcher wraps next with size- and time-based batching, counting delivered
+// and failed records on rt.
+func NewBatcher(rt *stochastic.Runtime, next Exporter, maxRecords int, interval time.Duration) *Batcher {
+	if maxRecords <= 0 {
+		maxRecords = DefaultBatchRecords
+	}
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+
+	b := &Batcher{
+		next:       next,
+		maxRecords: maxRecords,
+		interval:   interval,
+		metrics:    metricsFor(rt),
+		ready:      make(chan []*logsv1.ResourceLogs),
+		quit:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go b.loop()
+	return b
+}
+
+func (b *Batcher) Name() string { return b.next.Name() }
+
+// Unwrap returns the wrapped exporter.
+func (b *Batcher) Unwrap() Exporter { return b.next }
+
+// Export appends logs to the pending batch. The Batcher takes ownership of the slice contents.
+func (b *Batcher) Export(ctx context.Context, logs []*logsv1.ResourceLogs) error {
+	b.mu.Lock()
+	b.pending = append(b.pending, logs...)
+	b.records += countRecords(logs)
+	if b.records < b.maxRecords {
+		b.mu.Unlock()
+		return nil
+	}
+	batch := b.take()
+	b.mu.Unlock()
+
+	select {
+	case b.ready <- batch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.quit:
+		return ErrExporterClosed
+	}
+}
+
+// take detaches the pending batch. Callers must hold b.mu.
+func (b *Batcher) take() []*logsv1.ResourceLogs {
+	batch := b.pending
+	b.pending = nil
+	b.records = 0
+	return batch
+}
+
+func (b *Batcher) loop() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case batch := <-b.ready:
+			b.flush(batch)
+		case <-ticker.C:
+			b.mu.Lock()
+			batch := b.take()
+			b.mu.Unlock()
+			b.flush(batch)
+		case <-b.quit:
+			return
+		}
+	}
+}
+
+func (b *Batcher) flush(batch []*logsv1.ResourceLogs) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultFlushTimeout)
+	defer cancel()
+
+	n := countRecords(batch)
+	err := b.next.Export(ctx, batch)
+	if err == nil {
+		b.metrics.exportedRecordsTotal.WithLabelValues(b.next.Name()).Add(float64(n))
+		return
+	}
+	var pe *PartialError
+	if errors.As(err, &pe) {
+		left := countRecords(pe.Remaining)
+		b.metrics.exportedRecordsTotal.WithLabelValues(b.next.Name()).Add(float64(n - left))
+		n = left
+	}
+	if errors.Is(err, ErrDiverted) {
+		// Counted as fallback records; neither delivered nor lost yet.
+		return
+	}
+	b.metrics.failedRecordsTotal.WithLabelValues(b.next.Name()).Add(float64(n))
+	log.Error().Err(err).Str("exporter", b.next.Name()).Int("records", n).Msg("Batch export failed")
+}
+
+// Shutdown stops the flush loop, exports the remaining partial batch and
+// shuts down the wrapped exporter.
+func (b *Batcher) Shutdown(ctx context.Context) error {
+	b.once.Do(func() { close(b.quit) })
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	b.mu.Lock()
+	batch := b.take()
+	b.mu.Unlock()
+	b.flush(batch)
+
+	return b.next.Shutdown(ctx)
+}
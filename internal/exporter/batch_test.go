@@ -0,0 +1,128 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sungp/gophership/internal/stochastic"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// recordingExporter captures every batch it receives.
+type recordingExporter struct {
+	mu       sync.Mutex
+	batches  [][]*logsv1.ResourceLogs
+	err      error
+	shutdown bool
+}
+
+func (r *recordingExporter) Name() string { return "recorder" }
+
+func (r *recordingExporter) Export(_ context.Context, logs []*logsv1.ResourceLogs) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, logs)
+	return r.err
+}
+
+func (r *recordingExporter) Shutdown(context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.shutdown = true
+	return nil
+}
+
+func (r *recordingExporter) records() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, b := range r.batches {
+		n += countRecords(b)
+	}
+	return n
+}
+
+func TestBatcher_FlushOnSize(t *testing.T) {
+	rec := &recordingExporter{}
+	b := NewBatcher(stochastic.Default, rec, 4, time.Hour)
+	defer b.Shutdown(context.Background())
+
+	for i := 0; i < 2; i++ {
+		if err := b.Export(context.Background(), []*logsv1.ResourceLogs{testResourceLogs("svc", "a", "b")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for rec.records() < 4 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := rec.records(); got != 4 {
+		t.Errorf("expected size-triggered flush of 4 records, got %d", got)
+	}
+}
+
+func TestBatcher_FlushOnIntervalAndShutdown(t *testing.T) {
+	rec := &recordingExporter{}
+	b := NewBatcher(stochastic.Default, rec, 1000, 20*time.Millisecond)
+
+	_ = b.Export(context.Background(), []*logsv1.ResourceLogs{testResourceLogs("svc", "a")})
+	time.Sleep(100 * time.Millisecond)
+	if got := rec.records(); got != 1 {
+		t.Errorf("expected interval flush of 1 record, got %d", got)
+	}
+
+	_ = b.Export(context.Background(), []*logsv1.ResourceLogs{testResourceLogs("svc", "b", "c")})
+	if err := b.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := rec.records(); got != 3 {
+		t.Errorf("expected Shutdown to flush the partial batch, got %d records", got)
+	}
+	if !rec.shutdown {
+		t.Error("expected wrapped exporter to be shut down")
+	}
+	if err := b.Export(context.Background(), make([]*logsv1.ResourceLogs, 0)); err != nil {
+		t.Errorf("empty export after shutdown should be a no-op, got %v", err)
+	}
+}
+
+func TestBatcher_CountsOutcomes(t *testing.T) {
+	tests := []struct {
+		name             string
+		err              error
+		exported, failed float64
+	}{
+		{"Delivered", nil, 2, 0},
+		{"Diverted", fmt.Errorf("retry: %w", ErrDiverted), 0, 0},
+		{"Failed", errors.New("downstream unavailable"), 0, 2},
+		{"PartlyDelivered", &PartialError{Remaining: []*logsv1.ResourceLogs{testResourceLogs("svc", "b")}, Err: errors.New("downstream unavailable")}, 1, 1},
+		{"PartlyDiverted", &PartialError{Remaining: []*logsv1.ResourceLogs{testResourceLogs("svc", "b")}, Err: ErrDiverted}, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recordingExporter{err: tt.err}
+			rt := stochastic.NewRuntime()
+			b := NewBatcher(rt, rec, 0, time.Hour)
+			if err := b.Export(context.Background(), []*logsv1.ResourceLogs{testResourceLogs("svc", "a", "b")}); err != nil {
+				t.Fatal(err)
+			}
+			if err := b.Shutdown(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			m := metricsFor(rt)
+			if got := testutil.ToFloat64(m.exportedRecordsTotal.WithLabelValues("recorder")); got != tt.exported {
+				t.Errorf("exported records = %v, want %v", got, tt.exported)
+			}
+			if got := testutil.ToFloat64(m.failedRecordsTotal.WithLabelValues("recorder")); got != tt.failed {
+				t.Errorf("failed records = %v, want %v", got, tt.failed)
+			}
+		})
+	}
+}
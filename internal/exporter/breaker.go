@@ -0,0 +1,122 @@
+package exporter
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState is the classic three-state circuit breaker state.
+type BreakerState uint32
+
+const (
+	BreakerClosed   BreakerState = 0
+	BreakerHalfOpen BreakerState = 1
+	BreakerOpen     BreakerState = 2
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "CLOSED"
+	case BreakerHalfOpen:
+		return "HALF_OPEN"
+	case BreakerOpen:
+		return "OPEN"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// CircuitBreaker opens after a run of consecutive failures and allows a single
+// trial request once the open timeout has elapsed.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	state     BreakerState
+	failures  uint32
+	threshold uint32
+	timeout   time.Duration
+	openedAt  time.Time
+	probing   bool
+
+	now      func() time.Time
+	onChange func(BreakerState, uint32)
+}
+
+// NewCircuitBreaker creates a closed breaker. onChange, if non-nil, is invoked
+// under the breaker lock on every state transition and must not block.
+func NewCircuitBreaker(threshold int, timeout time.Duration, onChange func(BreakerState, uint32)) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		threshold: uint32(threshold),
+		timeout:   timeout,
+		now:       time.Now,
+		onChange:  onChange,
+	}
+}
+
+// State returns the current breaker state.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a request may be attempted. In the open state it
+// returns false until the timeout elapses, then admits exactly one probe.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.timeout {
+			return false
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return true
+	default: // Half-open: only the single in-flight probe is allowed.
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+}
+
+// Success records a successful request and closes the breaker.
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	if b.state != BreakerClosed {
+		b.setState(BreakerClosed)
+	}
+}
+
+// Failure records a failed request, opening the breaker when the threshold is
+// reached or when a half-open probe fails.
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.threshold) {
+		b.openedAt = b.now()
+		b.setState(BreakerOpen)
+	}
+}
+
+func (b *CircuitBreaker) setState(s BreakerState) {
+	b.state = s
+	if b.onChange != nil {
+		b.onChange(s, b.failures)
+	}
+}
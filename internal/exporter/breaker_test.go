@@ -0,0 +1,77 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	now := time.Unix(0, 0)
+	var transitions []BreakerState
+	b := NewCircuitBreaker(3, 10*time.Second, func(s BreakerState, _ uint32) {
+		transitions = append(transitions, s)
+	})
+	b.now = func() time.Time { return now }
+
+	// Two failures stay below the threshold.
+	b.Failure()
+	b.Failure()
+	if b.State() != BreakerClosed || !b.Allow() {
+		t.Fatalf("expected breaker to stay closed below threshold, got %s", b.State())
+	}
+
+	// Third consecutive failure opens it.
+	b.Failure()
+	if b.State() != BreakerOpen {
+		t.Fatalf("expected OPEN after 3 failures, got %s", b.State())
+	}
+	if b.Allow() {
+		t.Error("open breaker must reject requests before the timeout")
+	}
+
+	// After the timeout exactly one probe is admitted.
+	now = now.Add(11 * time.Second)
+	if !b.Allow() {
+		t.Fatal("expected probe to be admitted after open timeout")
+	}
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("expected HALF_OPEN during probe, got %s", b.State())
+	}
+	if b.Allow() {
+		t.Error("half-open breaker must admit only one probe")
+	}
+
+	// A failed probe re-opens immediately.
+	b.Failure()
+	if b.State() != BreakerOpen {
+		t.Fatalf("expected failed probe to re-open breaker, got %s", b.State())
+	}
+
+	// A successful probe closes it.
+	now = now.Add(11 * time.Second)
+	b.Allow()
+	b.Success()
+	if b.State() != BreakerClosed {
+		t.Fatalf("expected successful probe to close breaker, got %s", b.State())
+	}
+
+	want := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v; want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("transition %d = %s; want %s", i, transitions[i], want[i])
+		}
+	}
+}
+
+func TestCircuitBreaker_SuccessResetsFailures(t *testing.T) {
+	b := NewCircuitBreaker(2, time.Second, nil)
+	b.Failure()
+	b.Success()
+	b.Failure()
+	if b.State() != BreakerClosed {
+		t.Errorf("failures must be consecutive to open the breaker, got %s", b.State())
+	}
+}
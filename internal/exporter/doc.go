@@ -0,0 +1,3 @@
+// Package exporter delivers processed OTLP log batches to downstream sinks.
+// Exporters run on the deferred (Green path) side of the engine and never on the ingestion reflex.
+package exporter
@@ -0,0 +1,159 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	"github.com/sungp/gophership/internal/vault"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// ErrExporterClosed is returned when exporting to a sink that has been shut down.
+var ErrExporterClosed = errors.New("exporter: closed")
+
+// Exporter is implemented by every downstream sink.
+type Exporter interface {
+	// Name returns the configured identifier used in logs and metrics.
+	Name() string
+	// Export delivers a batch of resource logs. Implementations must not retain
+	// the slice after returning unless they document otherwise.
+	Export(ctx context.Context, logs []*logsv1.ResourceLogs) error
+	// Shutdown flushes any pending data and releases resources.
+	Shutdown(ctx context.Context) error
+}
+
+// PartialError reports that only part of a batch was delivered. Retrying
+// Remaining alone avoids sending the delivered records twice.
+type PartialError struct {
+	Remaining []*logsv1.ResourceLogs
+	Err       error
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("%d records undelivered: %v", countRecords(e.Remaining), e.Err)
+}
+
+func (e *PartialError) Unwrap() error { return e.Err }
+
+// Compressor is implemented by exporters whose payload codec can be switched
+// at runtime.
+type Compressor interface {
+	// CheckCompression reports whether compression is a codec the exporter
+	// can switch to, without switching.
+	CheckCompression(compression string) error
+	SetCompression(compression string) error
+}
+
+// SetCompression switches the codec of e, looking through the Batcher,
+// PersistentQueue and Resilient wrappers added by Build.
+func SetCompression(e Exporter, compression string) error {
+	c, err := compressor(e)
+	if err != nil {
+		return err
+	}
+	return c.SetCompression(compression)
+}
+
+// CheckCompression reports whether SetCompression(e, compression) would
+// succeed, so callers can reject a bad codec before they need it.
+func CheckCompression(e Exporter, compression string) error {
+	c, err := compressor(e)
+	if err != nil {
+		return err
+	}
+	return c.CheckCompression(compression)
+}
+
+// compressor finds the Compressor behind e's wrappers.
+func compressor(e Exporter) (Compressor, error) {
+	for {
+		if c, ok := e.(Compressor); ok {
+			return c, nil
+		}
+		w, ok := e.(interface{ Unwrap() Exporter })
+		if !ok {
+			return nil, fmt.Errorf("exporter %s does not support switching compression", e.Name())
+		}
+		e = w.Unwrap()
+	}
+}
+
+// Build constructs the exporters declared in the configuration. Each one is
+// wrapped in a Resilient (retries, breaker, vault fallback), optionally a
+// PersistentQueue, and then a Batcher. A queue and a vault fallback cannot be
+// combined: the queue acknowledges what the fallback diverted as if it had
+// been delivered. Downstream health is reported to rt's
+// monitor and fallback vaults count against it.
+func Build(rt *stochastic.Runtime, cfgs []config.ExporterConfig) ([]Exporter, error) {
+	var out []Exporter
+	for _, c := range cfgs {
+		if c.Name == "" {
+			return nil, fmt.Errorf("exporter of type %q has no name", c.Type)
+		}
+		if c.Queue.Dir != "" && c.Retry.FallbackDir != "" {
+			return nil, fmt.Errorf("exporter %s: queue.dir and retry.fallback_dir cannot be combined; the queue already keeps undelivered batches", c.Name)
+		}
+
+		var e Exporter
+		switch c.Type {
+		case "s3":
+			s3, err := NewS3Exporter(rt, c.Name, c.S3)
+			if err != nil {
+				return nil, fmt.Errorf("exporter %s: %w", c.Name, err)
+			}
+			e = s3
+		case "loadbalancing":
+			lb, err := NewLoadBalancer(rt, c.Name, c.LoadBalancing)
+			if err != nil {
+				return nil, fmt.Errorf("exporter %s: %w", c.Name, err)
+			}
+			e = lb
+		default:
+			return nil, fmt.Errorf("exporter %s: unknown type %q", c.Name, c.Type)
+		}
+
+		var fallback *vault.Queue
+		if c.Retry.FallbackDir != "" {
+			q, err := vault.OpenQueueWithRuntime(rt, c.Retry.FallbackDir, vault.DefaultSegmentSize)
+			if err != nil {
+				return nil, fmt.Errorf("exporter %s: fallback vault: %w", c.Name, err)
+			}
+			fallback = q
+		}
+
+		var sink Exporter = NewResilient(rt, e, c.Retry, fallback)
+		if c.Queue.Dir != "" {
+			q, err := NewPersistentQueue(rt, sink, c.Queue, c.Retry)
+			if err != nil {
+				return nil, fmt.Errorf("exporter %s: %w", c.Name, err)
+			}
+			sink = q
+		}
+		out = append(out, NewBatcher(rt, sink, c.Batch.MaxRecords, c.Batch.FlushInterval))
+	}
+	return out, nil
+}
+
+// countRecords returns the number of log records in a batch.
+func countRecords(logs []*logsv1.ResourceLogs) int {
+	n := 0
+	for _, rl := range logs {
+		for _, sl := range rl.GetScopeLogs() {
+			n += len(sl.GetLogRecords())
+		}
+	}
+	return n
+}
+
+// serviceName returns the service.name resource attribute, or "" if absent.
+func serviceName(rl *logsv1.ResourceLogs) string {
+	for _, kv := range rl.GetResource().GetAttributes() {
+		if kv.GetKey() == "service.name" {
+			return kv.GetValue().GetStringValue()
+		}
+	}
+	return ""
+}
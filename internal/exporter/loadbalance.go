@@ -0,0 +1,504 @@
+package exporter
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	logcol "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	// lbVirtualNodes is the number of ring points per endpoint. More points
+	// give a more even spread at the cost of a larger ring.
+	lbVirtualNodes = 128
+
+	DefaultLBTimeout     = 10 * time.Second
+	DefaultLBRetryAfter  = 5 * time.Second
+	DefaultLBDNSInterval = 30 * time.Second
+)
+
+// ErrNoEndpoints is returned when the load balancer has no backends to export to.
+var ErrNoEndpoints = errors.New("exporter: no load balancing endpoints")
+
+// hashRing is an immutable consistent hash ring. Adding or removing an
+// endpoint only remaps the keys owned by that endpoint.
+type hashRing struct {
+	points    []uint64
+	owners    []string
+	endpoints []string
+}
+
+func newHashRing(endpoints []string) *hashRing {
+	r := &hashRing{endpoints: endpoints}
+	type point struct {
+		hash  uint64
+		owner string
+	}
+	pts := make([]point, 0, len(endpoints)*lbVirtualNodes)
+	for _, ep := range endpoints {
+		for v := 0; v < lbVirtualNodes; v++ {
+			pts = append(pts, point{hash: hashKey([]byte(ep + "#" + strconv.Itoa(v))), owner: ep})
+		}
+	}
+	sort.Slice(pts, func(i, j int) bool { return pts[i].hash < pts[j].hash })
+
+	r.points = make([]uint64, len(pts))
+	r.owners = make([]string, len(pts))
+	for i, p := range pts {
+		r.points[i] = p.hash
+		r.owners[i] = p.owner
+	}
+	return r
+}
+
+// lookup returns the first endpoint clockwise from key that is not skipped,
+// or "" if every endpoint is skipped.
+func (r *hashRing) lookup(key []byte, skip func(string) bool) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	h := hashKey(key)
+	start := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	for n := 0; n < len(r.points); n++ {
+		owner := r.owners[(start+n)%len(r.points)]
+		if skip == nil || !skip(owner) {
+			return owner
+		}
+	}
+	return ""
+}
+
+// hashKey is FNV-1a followed by the murmur3 finalizer; FNV alone clusters
+// short keys that differ only in their last bytes.
+func hashKey(b []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(b)
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// lbBackend is one collector connection with passive health tracking.
+type lbBackend struct {
+	endpoint  string
+	conn      *grpc.ClientConn
+	client    logcol.LogsServiceClient
+	downUntil atomic.Int64 // Unix nanoseconds; zero when healthy
+	failures  atomic.Uint32
+}
+
+// LoadBalancer exports to a pool of OTLP/gRPC collectors, consistent-hashing
+// records by trace ID (or service / attribute) so that related records land
+// on the same backend. Endpoints that fail an export are skipped for
+// RetryAfter, and their records are re-hashed onto the remaining endpoints.
+type LoadBalancer struct {
+	name    string
+	cfg     config.LoadBalancingConfig
+	creds   credentials.TransportCredentials
+	rt      *stochastic.Runtime // Whose monitor is told of endpoint health
+	metrics *metrics
+
+	mu       sync.RWMutex
+	ring     *hashRing
+	backends map[string]*lbBackend
+
+	quit chan struct{}
+	done chan struct{}
+	once sync.Once
+	now  func() time.Time
+}
+
+// NewLoadBalancer creates the exporter, reporting endpoint health to rt's
+// monitor, and, when DNS discovery is configured, starts a background resolver.
+func NewLoadBalancer(rt *stochastic.Runtime, name string, cfg config.LoadBalancingConfig) (*LoadBalancer, error) {
+	if len(cfg.Endpoints) == 0 && cfg.DNS.Hostname == "" && cfg.DNS.SRV == "" {
+		return nil, fmt.Errorf("loadbalancing: endpoints or dns discovery required")
+	}
+	if cfg.DNS.Hostname != "" && cfg.DNS.SRV == "" && cfg.DNS.Port == "" {
+		return nil, fmt.Errorf("loadbalancing: dns.port is required with dns.hostname")
+	}
+	switch cfg.RoutingKey {
+	case "":
+		cfg.RoutingKey = "trace_id"
+	case "trace_id", "service":
+	case "attribute":
+		if cfg.RoutingAttribute == "" {
+			return nil, fmt.Errorf("loadbalancing: routing_attribute is required with routing_key attribute")
+		}
+	default:
+		return nil, fmt.Errorf("loadbalancing: unknown routing_key %q", cfg.RoutingKey)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultLBTimeout
+	}
+	if cfg.RetryAfter <= 0 {
+		cfg.RetryAfter = DefaultLBRetryAfter
+	}
+	if cfg.DNS.Interval <= 0 {
+		cfg.DNS.Interval = DefaultLBDNSInterval
+	}
+
+	creds := insecure.NewCredentials()
+	if !cfg.Insecure {
+		tlsCfg := &tls.Config{MinVersion: tls.VersionTLS13}
+		if cfg.CAFile != "" {
+			pem, err := os.ReadFile(cfg.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("loadbalancing: read CA: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("loadbalancing: no certificates found in %s", cfg.CAFile)
+			}
+			tlsCfg.RootCAs = pool
+		}
+		creds = credentials.NewTLS(tlsCfg)
+	}
+
+	lb := &LoadBalancer{
+		name:     name,
+		cfg:      cfg,
+		creds:    creds,
+		rt:       rt,
+		metrics:  metricsFor(rt),
+		ring:     newHashRing(nil),
+		backends: make(map[string]*lbBackend),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+		now:      time.Now,
+	}
+
+	if err := lb.UpdateEndpoints(cfg.Endpoints); err != nil {
+		return nil, err
+	}
+
+	if cfg.DNS.Hostname != "" || cfg.DNS.SRV != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+		lb.refresh(ctx)
+		cancel()
+		go lb.resolveLoop()
+	} else {
+		close(lb.done)
+	}
+	return lb, nil
+}
+
+func (lb *LoadBalancer) Name() string { return lb.name }
+
+// Endpoints returns the current ring membership.
+func (lb *LoadBalancer) Endpoints() []string {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+	return slices.Clone(lb.ring.endpoints)
+}
+
+// UpdateEndpoints replaces the ring membership, dialing new endpoints and
+// closing removed ones. Unchanged endpoints keep their connection and health.
+func (lb *LoadBalancer) UpdateEndpoints(endpoints []string) error {
+	eps := slices.Clone(endpoints)
+	sort.Strings(eps)
+	eps = slices.Compact(eps)
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+
+	if slices.Equal(eps, lb.ring.endpoints) {
+		return nil
+	}
+
+	next := make(map[string]*lbBackend, len(eps))
+	for _, ep := range eps {
+		if b, ok := lb.backends[ep]; ok {
+			next[ep] = b
+			continue
+		}
+		conn, err := grpc.NewClient(ep, grpc.WithTransportCredentials(lb.creds))
+		if err != nil {
+			for ep, b := range next {
+				if _, existing := lb.backends[ep]; !existing {
+					b.conn.Close()
+				}
+			}
+			return fmt.Errorf("loadbalancing: dial %s: %w", ep, err)
+		}
+		next[ep] = &lbBackend{endpoint: ep, conn: conn, client: logcol.NewLogsServiceClient(conn)}
+		lb.reportEndpoint(ep, true, 0)
+	}
+	for ep, b := range lb.backends {
+		if _, keep := next[ep]; !keep {
+			b.conn.Close()
+			lb.metrics.lbEndpointHealthy.DeleteLabelValues(lb.name, ep)
+			if m := lb.rt.Monitor(); m != nil {
+				m.ForgetDownstream(lb.name + "/" + ep)
+			}
+		}
+	}
+
+	log.Info().Str("exporter", lb.name).Strs("endpoints", eps).Msg("Load balancer membership changed; ring rebalanced")
+	lb.backends = next
+	lb.ring = newHashRing(eps)
+	return nil
+}
+
+func (lb *LoadBalancer) resolveLoop() {
+	defer close(lb.done)
+	ticker := time.NewTicker(lb.cfg.DNS.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), lb.cfg.Timeout)
+			lb.refresh(ctx)
+			cancel()
+		case <-lb.quit:
+			return
+		}
+	}
+}
+
+// refresh re-resolves DNS and updates the ring. On resolution failure the
+// previous membership is kept.
+func (lb *LoadBalancer) refresh(ctx context.Context) {
+	var found []string
+	if lb.cfg.DNS.SRV != "" {
+		_, srvs, err := net.DefaultResolver.LookupSRV(ctx, "", "", lb.cfg.DNS.SRV)
+		if err != nil {
+			log.Warn().Err(err).Str("srv", lb.cfg.DNS.SRV).Msg("Load balancer SRV lookup failed; keeping previous endpoints")
+			return
+		}
+		for _, s := range srvs {
+			found = append(found, net.JoinHostPort(strings.TrimSuffix(s.Target, "."), strconv.Itoa(int(s.Port))))
+		}
+	} else {
+		hosts, err := net.DefaultResolver.LookupHost(ctx, lb.cfg.DNS.Hostname)
+		if err != nil {
+			log.Warn().Err(err).Str("hostname", lb.cfg.DNS.Hostname).Msg("Load balancer DNS lookup failed; keeping previous endpoints")
+			return
+		}
+		for _, h := range hosts {
+			found = append(found, net.JoinHostPort(h, lb.cfg.DNS.Port))
+		}
+	}
+
+	if err := lb.UpdateEndpoints(append(found, lb.cfg.Endpoints...)); err != nil {
+		log.Error().Err(err).Msg("Failed to apply resolved load balancer endpoints")
+	}
+}
+
+// routingKey extracts the consistent-hashing key for a record.
+func (lb *LoadBalancer) routingKey(rl *logsv1.ResourceLogs, lr *logsv1.LogRecord) []byte {
+	switch lb.cfg.RoutingKey {
+	case "attribute":
+		for _, kv := range lr.GetAttributes() {
+			if kv.GetKey() == lb.cfg.RoutingAttribute {
+				return []byte(kv.GetValue().GetStringValue())
+			}
+		}
+		for _, kv := range rl.GetResource().GetAttributes() {
+			if kv.GetKey() == lb.cfg.RoutingAttribute {
+				return []byte(kv.GetValue().GetStringValue())
+			}
+		}
+		return nil
+	case "service":
+		return []byte(serviceName(rl))
+	default:
+		if len(lr.GetTraceId()) > 0 {
+			return lr.GetTraceId()
+		}
+		// Records outside a trace stay together per service.
+		return []byte(serviceName(rl))
+	}
+}
+
+// split assigns every record to a backend, skipping endpoints in avoid and
+// those marked down. If every endpoint is down, the ring owner is used anyway.
+func (lb *LoadBalancer) split(ring *hashRing, logs []*logsv1.ResourceLogs, avoid map[string]bool) map[string]*subBatch {
+	now := lb.now().UnixNano()
+	lb.mu.RLock()
+	backends := lb.backends
+	lb.mu.RUnlock()
+
+	skip := func(ep string) bool {
+		if avoid[ep] {
+			return true
+		}
+		b := backends[ep]
+		return b == nil || b.downUntil.Load() > now
+	}
+
+	out := make(map[string]*subBatch)
+	for i, rl := range logs {
+		for j, sl := range rl.GetScopeLogs() {
+			for _, lr := range sl.GetLogRecords() {
+				key := lb.routingKey(rl, lr)
+				ep := ring.lookup(key, skip)
+				if ep == "" {
+					ep = ring.lookup(key, func(ep string) bool { return avoid[ep] })
+				}
+				if ep == "" {
+					continue
+				}
+				b := out[ep]
+				if b == nil {
+					b = &subBatch{}
+					out[ep] = b
+				}
+				b.add(i, j, rl, sl, lr)
+			}
+		}
+	}
+	return out
+}
+
+// Export delivers each backend's share concurrently. Shares that fail are
+// re-hashed once onto the remaining endpoints before an error is returned;
+// when other shares were delivered it is a PartialError holding only the
+// undelivered records.
+func (lb *LoadBalancer) Export(ctx context.Context, logs []*logsv1.ResourceLogs) error {
+	lb.mu.RLock()
+	ring := lb.ring
+	lb.mu.RUnlock()
+	if len(ring.endpoints) == 0 {
+		return ErrNoEndpoints
+	}
+
+	avoid := make(map[string]bool)
+	pending := logs
+	delivered := false
+	var errs []error
+	for pass := 0; pass < 2 && len(pending) > 0; pass++ {
+		batches := lb.split(ring, pending, avoid)
+		failed, passErrs := lb.send(ctx, batches)
+		errs = passErrs
+		if len(failed) < len(batches) {
+			delivered = true
+		}
+		pending = nil
+		for ep, b := range failed {
+			avoid[ep] = true
+			pending = append(pending, b.logs...)
+		}
+		if len(avoid) == len(ring.endpoints) {
+			break
+		}
+	}
+	if len(pending) > 0 {
+		if delivered {
+			return &PartialError{Remaining: pending, Err: errors.Join(errs...)}
+		}
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// send exports every sub-batch in parallel and returns the ones that failed.
+func (lb *LoadBalancer) send(ctx context.Context, batches map[string]*subBatch) (map[string]*subBatch, []error) {
+	lb.mu.RLock()
+	backends := lb.backends
+	lb.mu.RUnlock()
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed = make(map[string]*subBatch)
+		errs   []error
+	)
+	for ep, b := range batches {
+		backend := backends[ep]
+		if backend == nil {
+			failed[ep] = b
+			continue
+		}
+		wg.Add(1)
+		go func(backend *lbBackend, b *subBatch) {
+			defer wg.Done()
+			callCtx, cancel := context.WithTimeout(ctx, lb.cfg.Timeout)
+			defer cancel()
+
+			_, err := backend.client.Export(callCtx, &logcol.ExportLogsServiceRequest{ResourceLogs: b.logs})
+			if err != nil {
+				lb.markDown(backend, err)
+				mu.Lock()
+				failed[backend.endpoint] = b
+				errs = append(errs, fmt.Errorf("%s: %w", backend.endpoint, err))
+				mu.Unlock()
+				return
+			}
+			lb.markUp(backend)
+		}(backend, b)
+	}
+	wg.Wait()
+	return failed, errs
+}
+
+func (lb *LoadBalancer) markDown(b *lbBackend, err error) {
+	failures := b.failures.Add(1)
+	first := b.downUntil.Swap(lb.now().Add(lb.cfg.RetryAfter).UnixNano()) == 0
+	if first {
+		log.Warn().Err(err).Str("exporter", lb.name).Str("endpoint", b.endpoint).Msg("Load balancer endpoint marked unhealthy")
+	}
+	lb.reportEndpoint(b.endpoint, false, failures)
+}
+
+func (lb *LoadBalancer) markUp(b *lbBackend) {
+	if b.downUntil.Swap(0) != 0 {
+		log.Info().Str("exporter", lb.name).Str("endpoint", b.endpoint).Msg("Load balancer endpoint recovered")
+		b.failures.Store(0)
+		lb.reportEndpoint(b.endpoint, true, 0)
+	}
+}
+
+func (lb *LoadBalancer) reportEndpoint(ep string, healthy bool, failures uint32) {
+	state := stochastic.DownstreamDegraded
+	gauge := 0.0
+	if healthy {
+		state = stochastic.DownstreamHealthy
+		gauge = 1
+	}
+	lb.metrics.lbEndpointHealthy.WithLabelValues(lb.name, ep).Set(gauge)
+	if m := lb.rt.Monitor(); m != nil {
+		m.ReportDownstream(stochastic.DownstreamHealth{
+			Name:                lb.name + "/" + ep,
+			State:               state,
+			ConsecutiveFailures: failures,
+			Since:               lb.now(),
+		})
+	}
+}
+
+// Shutdown stops DNS discovery and closes every backend connection.
+func (lb *LoadBalancer) Shutdown(ctx context.Context) error {
+	lb.once.Do(func() { close(lb.quit) })
+	select {
+	case <-lb.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return lb.UpdateEndpoints(nil)
+}
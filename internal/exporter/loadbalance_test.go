@@ -0,0 +1,244 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	logcol "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeCollector is an in-process OTLP/gRPC logs receiver.
+type fakeCollector struct {
+	logcol.UnimplementedLogsServiceServer
+	addr string
+	srv  *grpc.Server
+
+	mu     sync.Mutex
+	traces map[string]int
+	calls  int
+	reject map[int]bool // Calls (1-based) that fail
+}
+
+func startFakeCollector(t *testing.T) *fakeCollector {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	c := &fakeCollector{addr: lis.Addr().String(), srv: grpc.NewServer(), traces: make(map[string]int)}
+	logcol.RegisterLogsServiceServer(c.srv, c)
+	go c.srv.Serve(lis)
+	t.Cleanup(c.srv.Stop)
+	return c
+}
+
+func (c *fakeCollector) Export(_ context.Context, req *logcol.ExportLogsServiceRequest) (*logcol.ExportLogsServiceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.reject[c.calls] {
+		return nil, status.Error(codes.Unavailable, "rejected")
+	}
+	for _, rl := range req.ResourceLogs {
+		for _, sl := range rl.ScopeLogs {
+			for _, lr := range sl.LogRecords {
+				c.traces[string(lr.TraceId)]++
+			}
+		}
+	}
+	return &logcol.ExportLogsServiceResponse{}, nil
+}
+
+func (c *fakeCollector) received() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]int, len(c.traces))
+	for k, v := range c.traces {
+		out[k] = v
+	}
+	return out
+}
+
+// tracedLogs builds one record per trace ID, each carrying that ID.
+func tracedLogs(traceIDs ...string) []*logsv1.ResourceLogs {
+	rl := testResourceLogs("api")
+	for _, id := range traceIDs {
+		rl.ScopeLogs[0].LogRecords = append(rl.ScopeLogs[0].LogRecords, &logsv1.LogRecord{TraceId: []byte(id)})
+	}
+	return []*logsv1.ResourceLogs{rl}
+}
+
+func traceIDs(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("trace-%04d", i)
+	}
+	return ids
+}
+
+func newTestLoadBalancer(t *testing.T, endpoints ...string) *LoadBalancer {
+	t.Helper()
+	lb, err := NewLoadBalancer(stochastic.Default, "lb", config.LoadBalancingConfig{Endpoints: endpoints, Insecure: true})
+	if err != nil {
+		t.Fatalf("NewLoadBalancer: %v", err)
+	}
+	t.Cleanup(func() { lb.Shutdown(context.Background()) })
+	return lb
+}
+
+func TestHashRing_MinimalMovement(t *testing.T) {
+	before := newHashRing([]string{"a:1", "b:1", "c:1"})
+	after := newHashRing([]string{"a:1", "b:1", "c:1", "d:1"})
+
+	ids := traceIDs(2000)
+	moved := 0
+	for _, id := range ids {
+		from, to := before.lookup([]byte(id), nil), after.lookup([]byte(id), nil)
+		if from != to {
+			if to != "d:1" {
+				t.Fatalf("key %s moved %s -> %s; only moves onto the new endpoint are allowed", id, from, to)
+			}
+			moved++
+		}
+	}
+	// Roughly a quarter of the keys should move to the new endpoint.
+	if moved < len(ids)/8 || moved > len(ids)/2 {
+		t.Errorf("moved %d of %d keys, expected about %d", moved, len(ids), len(ids)/4)
+	}
+
+	if got := before.lookup([]byte("x"), func(string) bool { return true }); got != "" {
+		t.Errorf("lookup with every endpoint skipped = %q, want empty", got)
+	}
+}
+
+func TestLoadBalancer_SameTraceSameBackend(t *testing.T) {
+	c1, c2, c3 := startFakeCollector(t), startFakeCollector(t), startFakeCollector(t)
+	lb := newTestLoadBalancer(t, c1.addr, c2.addr, c3.addr)
+
+	ids := traceIDs(300)
+	for round := 0; round < 2; round++ {
+		if err := lb.Export(context.Background(), tracedLogs(ids...)); err != nil {
+			t.Fatalf("Export: %v", err)
+		}
+	}
+
+	owner := make(map[string]string)
+	for _, c := range []*fakeCollector{c1, c2, c3} {
+		got := c.received()
+		if len(got) == 0 {
+			t.Errorf("collector %s received nothing", c.addr)
+		}
+		for id, n := range got {
+			if prev, ok := owner[id]; ok {
+				t.Fatalf("trace %s split across %s and %s", id, prev, c.addr)
+			}
+			owner[id] = c.addr
+			if n != 2 {
+				t.Errorf("trace %s: %d records on %s, want 2", id, n, c.addr)
+			}
+		}
+	}
+	if len(owner) != len(ids) {
+		t.Errorf("delivered %d traces, want %d", len(owner), len(ids))
+	}
+}
+
+func TestLoadBalancer_FailoverAndMembership(t *testing.T) {
+	c1, c2 := startFakeCollector(t), startFakeCollector(t)
+	lb := newTestLoadBalancer(t, c1.addr, c2.addr)
+
+	// Stop c2: its share must be re-hashed onto c1 within the same export.
+	c2.srv.Stop()
+	ids := traceIDs(100)
+	if err := lb.Export(context.Background(), tracedLogs(ids...)); err != nil {
+		t.Fatalf("Export with one endpoint down: %v", err)
+	}
+	if got := len(c1.received()); got != len(ids) {
+		t.Errorf("surviving collector received %d traces, want %d", got, len(ids))
+	}
+
+	// Removing the dead endpoint drops it from the ring.
+	if err := lb.UpdateEndpoints([]string{c1.addr}); err != nil {
+		t.Fatalf("UpdateEndpoints: %v", err)
+	}
+	if eps := lb.Endpoints(); len(eps) != 1 || eps[0] != c1.addr {
+		t.Errorf("Endpoints() = %v, want [%s]", eps, c1.addr)
+	}
+
+	if err := lb.UpdateEndpoints(nil); err != nil {
+		t.Fatalf("UpdateEndpoints(nil): %v", err)
+	}
+	if err := lb.Export(context.Background(), tracedLogs("t")); err != ErrNoEndpoints {
+		t.Errorf("Export with empty ring = %v, want ErrNoEndpoints", err)
+	}
+}
+
+func TestLoadBalancer_PartialFailure(t *testing.T) {
+	c1, c2 := startFakeCollector(t), startFakeCollector(t)
+	lb := newTestLoadBalancer(t, c1.addr, c2.addr)
+	c2.srv.Stop()
+	// c1 takes its own share, then rejects c2's re-hashed share once.
+	c1.reject = map[int]bool{2: true}
+
+	ids := traceIDs(100)
+	err := lb.Export(context.Background(), tracedLogs(ids...))
+	var pe *PartialError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Export = %v, want a PartialError", err)
+	}
+	delivered := len(c1.received())
+	if left := countRecords(pe.Remaining); delivered == 0 || delivered+left != len(ids) {
+		t.Fatalf("delivered %d and %d remaining, want %d in total", delivered, left, len(ids))
+	}
+}
+
+func TestResilient_RetriesOnlyUndeliveredRecords(t *testing.T) {
+	c1, c2 := startFakeCollector(t), startFakeCollector(t)
+	lb := newTestLoadBalancer(t, c1.addr, c2.addr)
+	c2.srv.Stop()
+	c1.reject = map[int]bool{2: true}
+
+	r := NewResilient(stochastic.NewRuntime(), lb, config.RetryConfig{MaxAttempts: 3}, nil)
+	r.sleep = noSleep
+	ids := traceIDs(100)
+	if err := r.Export(context.Background(), tracedLogs(ids...)); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	got := c1.received()
+	if len(got) != len(ids) {
+		t.Errorf("healthy collector received %d traces, want %d", len(got), len(ids))
+	}
+	for id, n := range got {
+		if n != 1 {
+			t.Errorf("trace %s received %d times, want once", id, n)
+		}
+	}
+}
+
+func TestNewLoadBalancer_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.LoadBalancingConfig
+	}{
+		{"no endpoints", config.LoadBalancingConfig{}},
+		{"unknown key", config.LoadBalancingConfig{Endpoints: []string{"a:1"}, RoutingKey: "span"}},
+		{"attribute without name", config.LoadBalancingConfig{Endpoints: []string{"a:1"}, RoutingKey: "attribute"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewLoadBalancer(stochastic.Default, "lb", tt.cfg); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package exporter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sungp/gophership/internal/stochastic"
+)
+
+// metrics holds the collectors of the exporters built on one runtime.
+type metrics struct {
+	exportedRecordsTotal    *prometheus.CounterVec
+	failedRecordsTotal      *prometheus.CounterVec
+	exportedBytesTotal      *prometheus.CounterVec
+	retriesTotal            *prometheus.CounterVec
+	fallbackRecordsTotal    *prometheus.CounterVec
+	replayedRecordsTotal    *prometheus.CounterVec
+	routePausedRecordsTotal *prometheus.CounterVec
+	queueItems              *prometheus.GaugeVec
+	lbEndpointHealthy       *prometheus.GaugeVec
+	circuitState            *prometheus.GaugeVec
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		exportedRecordsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gophership_exporter_sent_records_total",
+			Help: "Total number of log records successfully exported.",
+		}, []string{"exporter"}),
+		failedRecordsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gophership_exporter_failed_records_total",
+			Help: "Total number of log records that failed to export.",
+		}, []string{"exporter"}),
+		exportedBytesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gophership_exporter_sent_bytes_total",
+			Help: "Total number of (compressed) payload bytes written to downstream sinks.",
+		}, []string{"exporter"}),
+		retriesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gophership_exporter_retries_total",
+			Help: "Total number of export retry attempts.",
+		}, []string{"exporter"}),
+		fallbackRecordsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gophership_exporter_fallback_records_total",
+			Help: "Total number of log records diverted to the vault fallback.",
+		}, []string{"exporter"}),
+		replayedRecordsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gophership_exporter_fallback_replayed_records_total",
+			Help: "Total number of log records replayed from the vault fallback to the downstream.",
+		}, []string{"exporter"}),
+		routePausedRecordsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gophership_router_paused_records_total",
+			Help: "Total number of log records skipped by routes paused in the current somatic zone.",
+		}, []string{"route"}),
+		queueItems: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gophership_exporter_queue_items",
+			Help: "Number of batches awaiting acknowledgement in the persistent queue.",
+		}, []string{"exporter"}),
+		lbEndpointHealthy: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gophership_exporter_lb_endpoint_healthy",
+			Help: "Load balancer endpoint health (1: Healthy, 0: Down).",
+		}, []string{"exporter", "endpoint"}),
+		circuitState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gophership_exporter_circuit_state",
+			Help: "Exporter circuit breaker state (0: Closed, 1: Half-Open, 2: Open).",
+		}, []string{"exporter"}),
+	}
+}
+
+func (m *metrics) mustRegister(reg *prometheus.Registry) {
+	reg.MustRegister(m.exportedRecordsTotal)
+	reg.MustRegister(m.failedRecordsTotal)
+	reg.MustRegister(m.exportedBytesTotal)
+	reg.MustRegister(m.retriesTotal)
+	reg.MustRegister(m.fallbackRecordsTotal)
+	reg.MustRegister(m.replayedRecordsTotal)
+	reg.MustRegister(m.routePausedRecordsTotal)
+	reg.MustRegister(m.queueItems)
+	reg.MustRegister(m.lbEndpointHealthy)
+	reg.MustRegister(m.circuitState)
+}
+
+type metricsKey struct{}
+
+// metricsFor returns the collectors of the exporters built on rt, registered on
+// its registry.
+func metricsFor(rt *stochastic.Runtime) *metrics {
+	if rt == stochastic.Default {
+		return defaultMetrics
+	}
+	return rt.Collectors(metricsKey{}, func(reg *prometheus.Registry) any {
+		m := newMetrics()
+		m.mustRegister(reg)
+		return m
+	}).(*metrics)
+}
+
+var defaultMetrics = newMetrics()
+
+// The collectors of the exporters built on stochastic.Default, registered on its
+// registry at init.
+var (
+	// ExportedRecordsTotal counts log records successfully delivered per exporter.
+	ExportedRecordsTotal = defaultMetrics.exportedRecordsTotal
+
+	// FailedRecordsTotal counts log records whose export failed per exporter.
+	FailedRecordsTotal = defaultMetrics.failedRecordsTotal
+
+	// ExportedBytesTotal counts payload bytes written by exporters that serialize batches.
+	ExportedBytesTotal = defaultMetrics.exportedBytesTotal
+
+	// RetriesTotal counts export retry attempts per exporter.
+	RetriesTotal = defaultMetrics.retriesTotal
+
+	// FallbackRecordsTotal counts records diverted to the vault because a downstream failed.
+	FallbackRecordsTotal = defaultMetrics.fallbackRecordsTotal
+
+	// ReplayedRecordsTotal counts diverted records later delivered from the vault fallback.
+	ReplayedRecordsTotal = defaultMetrics.replayedRecordsTotal
+
+	// RoutePausedRecordsTotal counts records skipped because their route is paused in the current zone.
+	RoutePausedRecordsTotal = defaultMetrics.routePausedRecordsTotal
+
+	// QueueItems tracks batches waiting in each exporter's persistent queue.
+	QueueItems = defaultMetrics.queueItems
+
+	// LBEndpointHealthy tracks the passive health of each load balancer endpoint (1: healthy, 0: down).
+	LBEndpointHealthy = defaultMetrics.lbEndpointHealthy
+
+	// CircuitState tracks each exporter's circuit breaker.
+	// 0: Closed, 1: Half-Open, 2: Open.
+	CircuitState = defaultMetrics.circuitState
+)
+
+func init() {
+	defaultMetrics.mustRegister(stochastic.Registry)
+}
@@ -0,0 +1,180 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sungp/gophership/internal/buffer"
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	"github.com/sungp/gophership/internal/vault"
+	logcol "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// PersistentQueue decouples callers from the downstream with a vault-backed
+// FIFO. Export durably enqueues the batch and returns; a single consumer
+// goroutine delivers items in order and acknowledges each one only after the
+// wrapped exporter succeeds, so batches survive downstream outages and restarts.
+type PersistentQueue struct {
+	next    Exporter
+	queue   *vault.Queue
+	policy  RetryPolicy
+	metrics *metrics
+
+	notify chan struct{}
+	quit   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+
+	// sleep is overridable so tests do not wait on real backoff.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewPersistentQueue opens the queue in cfg.Dir on rt and starts delivering
+// any items left over from a previous run. Redelivery backoff follows retry.
+func NewPersistentQueue(rt *stochastic.Runtime, next Exporter, cfg config.QueueConfig, retry config.RetryConfig) (*PersistentQueue, error) {
+	q, err := vault.OpenQueueWithRuntime(rt, cfg.Dir, cfg.SegmentSize)
+	if err != nil {
+		return nil, fmt.Errorf("queue: %w", err)
+	}
+
+	p := &PersistentQueue{
+		next:    next,
+		queue:   q,
+		policy:  NewRetryPolicy(retry),
+		metrics: metricsFor(rt),
+		notify:  make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+		sleep:   sleepContext,
+	}
+	p.metrics.queueItems.WithLabelValues(p.Name()).Set(float64(q.Len()))
+	go p.loop()
+	return p, nil
+}
+
+func (p *PersistentQueue) Name() string { return p.next.Name() }
+
+// Unwrap returns the wrapped exporter.
+func (p *PersistentQueue) Unwrap() Exporter { return p.next }
+
+// Len returns the number of batches awaiting acknowledgement.
+func (p *PersistentQueue) Len() int { return p.queue.Len() }
+
+// Export persists the batch; delivery happens asynchronously.
+func (p *PersistentQueue) Export(_ context.Context, logs []*logsv1.ResourceLogs) error {
+	req := &logcol.ExportLogsServiceRequest{ResourceLogs: logs}
+	bufPtr := buffer.MustAcquire(proto.Size(req))
+	defer buffer.MustRelease(bufPtr)
+
+	out, err := proto.MarshalOptions{}.MarshalAppend((*bufPtr)[:0], req)
+	if err != nil {
+		return fmt.Errorf("queue marshal: %w", err)
+	}
+	*bufPtr = out
+	if err := p.queue.Append(out); err != nil {
+		return err
+	}
+	p.metrics.queueItems.WithLabelValues(p.Name()).Set(float64(p.queue.Len()))
+
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (p *PersistentQueue) loop() {
+	defer close(p.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-p.quit
+		cancel()
+	}()
+
+	attempt := 0
+	for {
+		data, err := p.queue.Peek()
+		if errors.Is(err, vault.ErrQueueEmpty) {
+			select {
+			case <-p.notify:
+				continue
+			case <-p.quit:
+				return
+			}
+		}
+		if err != nil {
+			log.Error().Err(err).Str("exporter", p.Name()).Msg("Persistent queue read failed")
+			if p.sleep(ctx, p.policy.MaxBackoff) != nil {
+				return
+			}
+			continue
+		}
+
+		req := &logcol.ExportLogsServiceRequest{}
+		if err := proto.Unmarshal(data, req); err != nil {
+			// A batch that cannot be decoded will never succeed; drop it rather than wedge the queue.
+			log.Error().Err(err).Str("exporter", p.Name()).Msg("Dropping undecodable persistent queue item")
+			p.ack()
+			continue
+		}
+
+		exportCtx, exportCancel := context.WithTimeout(ctx, DefaultFlushTimeout)
+		err = p.next.Export(exportCtx, req.ResourceLogs)
+		exportCancel()
+		var pe *PartialError
+		if errors.As(err, &pe) && p.requeue(pe.Remaining) {
+			// Only the undelivered records are redelivered, after the rest of the queue.
+			p.ack()
+		}
+		if err != nil {
+			attempt++
+			log.Debug().Err(err).Str("exporter", p.Name()).Int("attempt", attempt).Msg("Queued export failed; will redeliver")
+			if p.sleep(ctx, p.policy.Backoff(attempt)) != nil {
+				return
+			}
+			continue
+		}
+		attempt = 0
+		p.ack()
+	}
+}
+
+// requeue appends logs as a new item, reporting whether it succeeded.
+func (p *PersistentQueue) requeue(logs []*logsv1.ResourceLogs) bool {
+	data, err := proto.Marshal(&logcol.ExportLogsServiceRequest{ResourceLogs: logs})
+	if err == nil {
+		err = p.queue.Append(data)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("exporter", p.Name()).Msg("Failed to requeue undelivered records")
+		return false
+	}
+	return true
+}
+
+func (p *PersistentQueue) ack() {
+	if err := p.queue.Ack(); err != nil {
+		log.Error().Err(err).Str("exporter", p.Name()).Msg("Failed to acknowledge persistent queue item")
+	}
+	p.metrics.queueItems.WithLabelValues(p.Name()).Set(float64(p.queue.Len()))
+}
+
+// Shutdown stops delivery, leaving unacknowledged batches on disk for the
+// next start, then shuts down the wrapped exporter.
+func (p *PersistentQueue) Shutdown(ctx context.Context) error {
+	p.once.Do(func() { close(p.quit) })
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return errors.Join(p.queue.Close(), p.next.Shutdown(ctx))
+}
@@ -0,0 +1,165 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func shortSleep(ctx context.Context, _ time.Duration) error {
+	return sleepContext(ctx, time.Millisecond)
+}
+
+func newTestQueue(t *testing.T, next Exporter, dir string) *PersistentQueue {
+	t.Helper()
+	q, err := NewPersistentQueue(stochastic.Default, next, config.QueueConfig{Dir: dir}, config.RetryConfig{})
+	if err != nil {
+		t.Fatalf("NewPersistentQueue: %v", err)
+	}
+	q.sleep = shortSleep
+	return q
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPersistentQueue_RedeliversAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+	down := &recordingExporter{err: errors.New("downstream unavailable")}
+
+	q := newTestQueue(t, down, dir)
+	for _, body := range []string{"a", "b"} {
+		if err := q.Export(context.Background(), []*logsv1.ResourceLogs{testResourceLogs("api", body)}); err != nil {
+			t.Fatalf("Export: %v", err)
+		}
+	}
+	waitFor(t, "delivery attempts", func() bool { return down.records() > 0 })
+	if q.Len() != 2 {
+		t.Fatalf("Len while downstream is down = %d, want 2", q.Len())
+	}
+	if err := q.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	// A new process picks up the unacknowledged batches in order.
+	up := &recordingExporter{}
+	q = newTestQueue(t, up, dir)
+	defer q.Shutdown(context.Background())
+	q.Export(context.Background(), []*logsv1.ResourceLogs{testResourceLogs("api", "c")})
+
+	waitFor(t, "redelivery", func() bool { return q.Len() == 0 })
+	var bodies []string
+	up.mu.Lock()
+	for _, b := range up.batches {
+		for _, rl := range b {
+			for _, sl := range rl.ScopeLogs {
+				for _, lr := range sl.LogRecords {
+					bodies = append(bodies, lr.Body.GetStringValue())
+				}
+			}
+		}
+	}
+	up.mu.Unlock()
+	assertBodies(t, "redelivered", bodies, "a", "b", "c")
+}
+
+// halfExporter delivers only the first record of its first batch, reporting
+// the rest as a PartialError, and every batch after that.
+type halfExporter struct {
+	mu        sync.Mutex
+	calls     int
+	delivered []string
+}
+
+func (h *halfExporter) Name() string { return "half" }
+
+func (h *halfExporter) Export(_ context.Context, logs []*logsv1.ResourceLogs) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls++
+	records := logs[0].ScopeLogs[0].LogRecords
+	if h.calls == 1 && len(records) > 1 {
+		h.delivered = append(h.delivered, records[0].Body.GetStringValue())
+		rest := testResourceLogs("api")
+		rest.ScopeLogs[0].LogRecords = records[1:]
+		return &PartialError{Remaining: []*logsv1.ResourceLogs{rest}, Err: errors.New("endpoint down")}
+	}
+	for _, lr := range records {
+		h.delivered = append(h.delivered, lr.Body.GetStringValue())
+	}
+	return nil
+}
+
+func (h *halfExporter) Shutdown(context.Context) error { return nil }
+
+func TestPersistentQueue_RedeliversOnlyUndeliveredRecords(t *testing.T) {
+	half := &halfExporter{}
+	q := newTestQueue(t, half, t.TempDir())
+	defer q.Shutdown(context.Background())
+
+	if err := q.Export(context.Background(), []*logsv1.ResourceLogs{testResourceLogs("api", "a", "b", "c")}); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	waitFor(t, "delivery", func() bool { return q.Len() == 0 })
+
+	half.mu.Lock()
+	defer half.mu.Unlock()
+	assertBodies(t, "delivered", half.delivered, "a", "b", "c")
+}
+
+func TestBuild_QueueKeepsBatchesWhileDown(t *testing.T) {
+	collector := startFakeCollector(t)
+	collector.srv.Stop()
+	cfg := config.ExporterConfig{
+		Name:          "lb",
+		Type:          "loadbalancing",
+		Batch:         config.BatchConfig{FlushInterval: 10 * time.Millisecond},
+		Retry:         config.RetryConfig{MaxAttempts: 1, FailureThreshold: 1, OpenTimeout: time.Hour, FallbackDir: t.TempDir()},
+		Queue:         config.QueueConfig{Dir: t.TempDir()},
+		LoadBalancing: config.LoadBalancingConfig{Endpoints: []string{collector.addr}, Insecure: true},
+	}
+	rt := stochastic.NewRuntime()
+	if _, err := Build(rt, []config.ExporterConfig{cfg}); err == nil {
+		t.Fatal("expected queue.dir with retry.fallback_dir to be rejected")
+	}
+
+	// Without the fallback, an open breaker leaves batches in the queue.
+	cfg.Retry.FallbackDir = ""
+	exporters, err := Build(rt, []config.ExporterConfig{cfg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exporters[0].Shutdown(context.Background())
+	queue := exporters[0].(*Batcher).Unwrap().(*PersistentQueue)
+	queue.sleep = shortSleep
+
+	if err := exporters[0].Export(context.Background(), tracedLogs("t")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the batch to be queued", func() bool { return queue.Len() == 1 })
+	resilient := queue.Unwrap().(*Resilient)
+	waitFor(t, "the breaker to open", func() bool { return resilient.Breaker().State() == BreakerOpen })
+	time.Sleep(50 * time.Millisecond) // Several redeliveries against the open breaker
+	if n := queue.Len(); n != 1 {
+		t.Errorf("queue holds %d batches while the downstream is down, want 1", n)
+	}
+	// The runtime the exporter was built on exports its metrics.
+	if n, err := testutil.GatherAndCount(rt.Registry(), "gophership_exporter_queue_items", "gophership_exporter_circuit_state"); err != nil || n != 2 {
+		t.Errorf("runtime registry exports %d exporter series (err %v), want 2", n, err)
+	}
+}
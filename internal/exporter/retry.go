@@ -0,0 +1,370 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sungp/gophership/internal/buffer"
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	"github.com/sungp/gophership/internal/vault"
+	logcol "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	DefaultMaxAttempts      = 3
+	DefaultInitialBackoff   = 200 * time.Millisecond
+	DefaultMaxBackoff       = 10 * time.Second
+	DefaultBackoffFactor    = 2.0
+	DefaultFailureThreshold = 5
+	DefaultOpenTimeout      = 30 * time.Second
+	// DefaultReplayInterval is how often a Resilient checks whether its
+	// vault fallback can be drained, besides whenever the breaker closes.
+	DefaultReplayInterval = 5 * time.Second
+)
+
+// ErrCircuitOpen is returned when the breaker rejects an export and no vault
+// fallback is configured.
+var ErrCircuitOpen = errors.New("exporter: circuit open")
+
+// ErrDiverted is returned when a batch was not delivered but is safe in the
+// vault fallback, to be replayed later.
+var ErrDiverted = errors.New("exporter: diverted to vault fallback")
+
+// RetryPolicy describes bounded retries with jittered exponential backoff.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+}
+
+// NewRetryPolicy fills zero values in cfg with defaults.
+func NewRetryPolicy(cfg config.RetryConfig) RetryPolicy {
+	p := RetryPolicy{
+		MaxAttempts:    cfg.MaxAttempts,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+		Multiplier:     cfg.Multiplier,
+	}
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultMaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultMaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultBackoffFactor
+	}
+	return p
+}
+
+// Backoff returns the delay before retry number attempt (1-based), using
+// "equal jitter": half the exponential delay is fixed, half is random.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		d *= p.Multiplier
+		if d >= float64(p.MaxBackoff) {
+			d = float64(p.MaxBackoff)
+			break
+		}
+	}
+	half := d / 2
+	return time.Duration(half + rand.Float64()*half)
+}
+
+// Resilient wraps an exporter with retries, a circuit breaker and an optional
+// vault fallback. While the breaker is open, batches are appended to the
+// fallback queue; a background loop replays them through the wrapped exporter
+// once the breaker has closed again and the engine is out of Red. Replayed
+// batches are delivered at least once and may overtake, or be overtaken by,
+// live ones.
+type Resilient struct {
+	next     Exporter
+	policy   RetryPolicy
+	breaker  *CircuitBreaker
+	fallback *vault.Queue
+	rt       *stochastic.Runtime // Whose monitor is told of downstream health
+	metrics  *metrics
+
+	replay chan struct{} // Signalled when the breaker closes
+	quit   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+
+	// sleep is overridable so tests do not wait on real backoff.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewResilient wraps next and reports its health to rt's monitor. fallback may
+// be nil, in which case batches rejected by an open breaker are returned as
+// ErrCircuitOpen; otherwise the fallback is replayed in the background until
+// Shutdown, starting with anything left by a previous run.
+func NewResilient(rt *stochastic.Runtime, next Exporter, cfg config.RetryConfig, fallback *vault.Queue) *Resilient {
+	threshold := cfg.FailureThreshold
+	if threshold <= 0 {
+		threshold = DefaultFailureThreshold
+	}
+	timeout := cfg.OpenTimeout
+	if timeout <= 0 {
+		timeout = DefaultOpenTimeout
+	}
+
+	r := &Resilient{
+		next:     next,
+		policy:   NewRetryPolicy(cfg),
+		fallback: fallback,
+		rt:       rt,
+		metrics:  metricsFor(rt),
+		replay:   make(chan struct{}, 1),
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+		sleep:    sleepContext,
+	}
+	r.breaker = NewCircuitBreaker(threshold, timeout, r.onBreakerChange)
+	r.onBreakerChange(BreakerClosed, 0)
+	if fallback != nil {
+		go r.replayLoop(DefaultReplayInterval)
+	} else {
+		close(r.done)
+	}
+	return r
+}
+
+func (r *Resilient) Name() string { return r.next.Name() }
+
+// Unwrap returns the wrapped exporter.
+func (r *Resilient) Unwrap() Exporter { return r.next }
+
+// Breaker exposes the circuit breaker for inspection.
+func (r *Resilient) Breaker() *CircuitBreaker { return r.breaker }
+
+// Export attempts delivery up to MaxAttempts times, retrying only the records
+// a PartialError reports as undelivered. If every attempt fails, or the
+// breaker is open, what is left is diverted to the vault fallback and
+// ErrDiverted is returned, as a PartialError if some records got through.
+func (r *Resilient) Export(ctx context.Context, logs []*logsv1.ResourceLogs) error {
+	if !r.breaker.Allow() {
+		return r.divert(logs, ErrCircuitOpen)
+	}
+
+	partial := false
+	var lastErr error
+	for attempt := 1; attempt <= r.policy.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			r.metrics.retriesTotal.WithLabelValues(r.Name()).Inc()
+			if err := r.sleep(ctx, r.policy.Backoff(attempt-1)); err != nil {
+				lastErr = err
+				break
+			}
+		}
+
+		lastErr = r.next.Export(ctx, logs)
+		if lastErr == nil {
+			r.breaker.Success()
+			return nil
+		}
+		var pe *PartialError
+		if errors.As(lastErr, &pe) {
+			logs, partial = pe.Remaining, true
+		}
+		log.Debug().Err(lastErr).Str("exporter", r.Name()).Int("attempt", attempt).Msg("Export attempt failed")
+
+		// A half-open probe gets exactly one attempt.
+		if r.breaker.State() == BreakerHalfOpen {
+			break
+		}
+	}
+
+	r.breaker.Failure()
+	r.recordError(lastErr)
+	err := r.divert(logs, lastErr)
+	if partial {
+		return &PartialError{Remaining: logs, Err: err}
+	}
+	return err
+}
+
+// divert appends the batch to the vault fallback. It returns ErrDiverted when
+// the batch is safely persisted, so callers neither count it as delivered nor
+// as lost.
+func (r *Resilient) divert(logs []*logsv1.ResourceLogs, cause error) error {
+	if r.fallback == nil {
+		return cause
+	}
+
+	req := &logcol.ExportLogsServiceRequest{ResourceLogs: logs}
+	bufPtr := buffer.MustAcquire(proto.Size(req))
+	defer buffer.MustRelease(bufPtr)
+	out, err := proto.MarshalOptions{}.MarshalAppend((*bufPtr)[:0], req)
+	if err != nil {
+		return errors.Join(cause, err)
+	}
+	*bufPtr = out
+	if err := r.fallback.Append(out); err != nil {
+		return errors.Join(cause, err)
+	}
+
+	// Not replayed right away: the breaker may still be closed below its
+	// threshold, and the downstream just failed. Its closing, or the next
+	// replay tick, picks the batch up.
+	r.metrics.fallbackRecordsTotal.WithLabelValues(r.Name()).Add(float64(countRecords(logs)))
+	return ErrDiverted
+}
+
+func (r *Resilient) signalReplay() {
+	select {
+	case r.replay <- struct{}{}:
+	default:
+	}
+}
+
+// replayLoop drains the fallback whenever the breaker closes, and checks
+// again every interval in case a drain was cut short.
+func (r *Resilient) replayLoop(interval time.Duration) {
+	defer close(r.done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-r.quit
+		cancel()
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		r.drain(ctx)
+		select {
+		case <-r.replay:
+		case <-ticker.C:
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+// drain exports fallback batches in order while the breaker is closed and the
+// engine is out of Red, acknowledging each after it is delivered. A failure
+// counts against the breaker and stops the drain.
+func (r *Resilient) drain(ctx context.Context) {
+	for r.fallback.Len() > 0 {
+		if ctx.Err() != nil || r.breaker.State() != BreakerClosed || r.rt.Status() == stochastic.StatusRed {
+			return
+		}
+		data, err := r.fallback.Peek()
+		if err != nil {
+			log.Error().Err(err).Str("exporter", r.Name()).Msg("Vault fallback read failed")
+			return
+		}
+		req := &logcol.ExportLogsServiceRequest{}
+		if err := proto.Unmarshal(data, req); err != nil {
+			log.Error().Err(err).Str("exporter", r.Name()).Msg("Dropping undecodable vault fallback item")
+			r.ackFallback()
+			continue
+		}
+
+		exportCtx, exportCancel := context.WithTimeout(ctx, DefaultFlushTimeout)
+		err = r.next.Export(exportCtx, req.ResourceLogs)
+		exportCancel()
+		var pe *PartialError
+		if errors.As(err, &pe) && r.requeue(pe.Remaining) {
+			// Keep only what is still undelivered.
+			r.metrics.replayedRecordsTotal.WithLabelValues(r.Name()).Add(float64(countRecords(req.ResourceLogs) - countRecords(pe.Remaining)))
+			r.ackFallback()
+		}
+		if err != nil {
+			r.breaker.Failure()
+			r.recordError(err)
+			log.Warn().Err(err).Str("exporter", r.Name()).Int("pending", r.fallback.Len()).Msg("Vault fallback replay failed; will retry")
+			return
+		}
+		r.breaker.Success()
+		r.metrics.replayedRecordsTotal.WithLabelValues(r.Name()).Add(float64(countRecords(req.ResourceLogs)))
+		r.ackFallback()
+	}
+}
+
+// requeue appends logs to the fallback, reporting whether it succeeded.
+func (r *Resilient) requeue(logs []*logsv1.ResourceLogs) bool {
+	data, err := proto.Marshal(&logcol.ExportLogsServiceRequest{ResourceLogs: logs})
+	if err == nil {
+		err = r.fallback.Append(data)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("exporter", r.Name()).Msg("Failed to requeue undelivered records in the vault fallback")
+		return false
+	}
+	return true
+}
+
+func (r *Resilient) ackFallback() {
+	if err := r.fallback.Ack(); err != nil {
+		log.Error().Err(err).Str("exporter", r.Name()).Msg("Failed to acknowledge vault fallback item")
+	}
+}
+
+func (r *Resilient) onBreakerChange(s BreakerState, failures uint32) {
+	r.metrics.circuitState.WithLabelValues(r.Name()).Set(float64(s))
+	if s != BreakerClosed {
+		log.Warn().Str("exporter", r.Name()).Str("state", s.String()).Uint32("consecutive_failures", failures).Msg("Exporter circuit breaker transition")
+	} else {
+		r.signalReplay()
+	}
+	if m := r.rt.Monitor(); m != nil {
+		m.ReportDownstream(stochastic.DownstreamHealth{
+			Name:                r.Name(),
+			State:               stochastic.DownstreamState(s),
+			ConsecutiveFailures: failures,
+			Since:               time.Now(),
+		})
+	}
+}
+
+// recordError attaches the most recent failure to the reported downstream health.
+func (r *Resilient) recordError(err error) {
+	m := r.rt.Monitor()
+	if m == nil || err == nil {
+		return
+	}
+	m.ReportDownstreamError(r.Name(), err.Error())
+}
+
+// Shutdown stops replaying, shuts down the wrapped exporter and closes the
+// fallback, leaving undelivered batches on disk for the next start.
+func (r *Resilient) Shutdown(ctx context.Context) error {
+	r.once.Do(func() { close(r.quit) })
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	err := r.next.Shutdown(ctx)
+	if r.fallback != nil {
+		err = errors.Join(err, r.fallback.Close())
+	}
+	if m := r.rt.Monitor(); m != nil {
+		m.ForgetDownstream(r.Name())
+	}
+	return err
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
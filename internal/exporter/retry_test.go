@@ -0,0 +1,187 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	"github.com/sungp/gophership/internal/vault"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// flakyExporter fails the first failN exports.
+type flakyExporter struct {
+	mu        sync.Mutex
+	calls     int
+	failN     int
+	delivered int // Records accepted
+}
+
+func (f *flakyExporter) Name() string { return "flaky" }
+
+func (f *flakyExporter) Export(_ context.Context, logs []*logsv1.ResourceLogs) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.calls <= f.failN {
+		return errors.New("downstream unavailable")
+	}
+	f.delivered += countRecords(logs)
+	return nil
+}
+
+func (f *flakyExporter) deliveredRecords() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.delivered
+}
+
+func (f *flakyExporter) Shutdown(context.Context) error { return nil }
+
+func noSleep(context.Context, time.Duration) error { return nil }
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := NewRetryPolicy(config.RetryConfig{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	})
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 50 * time.Millisecond, 100 * time.Millisecond},
+		{2, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 200 * time.Millisecond, 400 * time.Millisecond},
+		{10, 500 * time.Millisecond, time.Second}, // Capped at MaxBackoff
+	}
+	for _, tt := range tests {
+		for i := 0; i < 50; i++ {
+			if d := p.Backoff(tt.attempt); d < tt.min || d > tt.max {
+				t.Fatalf("Backoff(%d) = %v; want within [%v, %v]", tt.attempt, d, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestResilient_RetriesThenSucceeds(t *testing.T) {
+	flaky := &flakyExporter{failN: 2}
+	r := NewResilient(stochastic.Default, flaky, config.RetryConfig{MaxAttempts: 3}, nil)
+	r.sleep = noSleep
+
+	if err := r.Export(context.Background(), []*logsv1.ResourceLogs{testResourceLogs("svc", "a")}); err != nil {
+		t.Fatalf("expected success on third attempt, got %v", err)
+	}
+	if flaky.calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", flaky.calls)
+	}
+	if r.Breaker().State() != BreakerClosed {
+		t.Errorf("breaker should remain closed, got %s", r.Breaker().State())
+	}
+}
+
+func TestResilient_OpensAndDivertsToVault(t *testing.T) {
+	// A runtime of its own: health must reach its monitor, not the default one.
+	rt := stochastic.NewRuntime()
+	monitor := stochastic.NewSensingMonitor(1024, 1<<40, 0.8, 0.95, 0, 0)
+	rt.SetMonitor(monitor)
+
+	dir := t.TempDir()
+	q, err := vault.OpenQueueWithRuntime(rt, dir, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flaky := &flakyExporter{failN: 1000}
+	r := NewResilient(rt, flaky, config.RetryConfig{MaxAttempts: 2, FailureThreshold: 2, OpenTimeout: time.Hour}, q)
+	r.sleep = noSleep
+
+	batch := []*logsv1.ResourceLogs{testResourceLogs("svc", "keep-me")}
+	for i := 0; i < 3; i++ {
+		if err := r.Export(context.Background(), batch); !errors.Is(err, ErrDiverted) {
+			t.Fatalf("export %d: expected ErrDiverted, got %v", i, err)
+		}
+	}
+
+	if r.Breaker().State() != BreakerOpen {
+		t.Fatalf("expected breaker OPEN after consecutive failures, got %s", r.Breaker().State())
+	}
+	// Third export was rejected by the open breaker without touching the downstream.
+	if flaky.calls != 4 {
+		t.Errorf("expected 4 downstream calls (2 exports x 2 attempts), got %d", flaky.calls)
+	}
+
+	ds := monitor.Downstreams()
+	if len(ds) != 1 || ds[0].State != stochastic.DownstreamDegraded || ds[0].LastError == "" {
+		t.Errorf("expected downstream reported DOWN with last error, got %+v", ds)
+	}
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(monitor.Downstreams()) != 0 {
+		t.Error("expected downstream to be forgotten after shutdown")
+	}
+
+	// All three batches must survive in the vault for the next start.
+	q2, err := vault.OpenQueue(dir, 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q2.Close()
+	if n := q2.Len(); n != 3 {
+		t.Errorf("expected 3 vaulted batches, got %d", n)
+	}
+}
+
+func TestResilient_ReplaysFallbackOnceClosed(t *testing.T) {
+	q, err := vault.OpenQueueWithRuntime(stochastic.NewRuntime(), t.TempDir(), 1024*1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flaky := &flakyExporter{failN: 1}
+	r := NewResilient(stochastic.NewRuntime(), flaky, config.RetryConfig{MaxAttempts: 1, FailureThreshold: 1, OpenTimeout: time.Millisecond}, q)
+	r.sleep = noSleep
+	defer r.Shutdown(context.Background())
+
+	// The first export fails and is diverted; once the open timeout has passed
+	// the second one closes the breaker, which replays the first.
+	if err := r.Export(context.Background(), []*logsv1.ResourceLogs{testResourceLogs("svc", "diverted")}); !errors.Is(err, ErrDiverted) {
+		t.Fatalf("expected ErrDiverted, got %v", err)
+	}
+	if q.Len() != 1 {
+		t.Fatalf("fallback holds %d batches, want 1", q.Len())
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := r.Export(context.Background(), []*logsv1.ResourceLogs{testResourceLogs("svc", "live")}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for q.Len() > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if q.Len() != 0 {
+		t.Fatalf("fallback still holds %d batches after the breaker closed", q.Len())
+	}
+	if got := flaky.deliveredRecords(); got != 2 {
+		t.Errorf("delivered %d records, want the live and the replayed one", got)
+	}
+}
+
+func TestResilient_OpenWithoutFallback(t *testing.T) {
+	flaky := &flakyExporter{failN: 1000}
+	r := NewResilient(stochastic.Default, flaky, config.RetryConfig{MaxAttempts: 1, FailureThreshold: 1, OpenTimeout: time.Hour}, nil)
+	r.sleep = noSleep
+
+	batch := []*logsv1.ResourceLogs{testResourceLogs("svc", "a")}
+	_ = r.Export(context.Background(), batch)
+	if err := r.Export(context.Background(), batch); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+}
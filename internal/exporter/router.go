@@ -0,0 +1,271 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	"github.com/sungp/gophership/pkg/otel"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// matcher is a compiled MatchConfig.
+type matcher struct {
+	resource     map[string]string
+	attributes   map[string]string
+	minSeverity  logsv1.SeverityNumber
+	bodyContains []string
+	bodyRegex    *regexp.Regexp
+}
+
+func compileMatcher(c config.MatchConfig) (*matcher, error) {
+	m := &matcher{
+		resource:     c.Resource,
+		attributes:   c.Attributes,
+		bodyContains: c.BodyContains,
+	}
+	if c.MinSeverity != "" {
+		sev, err := otel.ParseSeverity(c.MinSeverity)
+		if err != nil {
+			return nil, err
+		}
+		m.minSeverity = sev
+	}
+	if c.BodyRegex != "" {
+		re, err := regexp.Compile(c.BodyRegex)
+		if err != nil {
+			return nil, fmt.Errorf("body_regex: %w", err)
+		}
+		m.bodyRegex = re
+	}
+	return m, nil
+}
+
+// matchResource evaluates the resource-level conditions once per ResourceLogs.
+func (m *matcher) matchResource(rl *logsv1.ResourceLogs) bool {
+	return attributesMatch(rl.GetResource().GetAttributes(), m.resource)
+}
+
+// matchRecord evaluates the record-level conditions.
+func (m *matcher) matchRecord(lr *logsv1.LogRecord) bool {
+	if m.minSeverity != logsv1.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED && otel.RecordSeverity(lr) < m.minSeverity {
+		return false
+	}
+	if !attributesMatch(lr.GetAttributes(), m.attributes) {
+		return false
+	}
+	if len(m.bodyContains) == 0 && m.bodyRegex == nil {
+		return true
+	}
+
+	body := lr.GetBody().GetStringValue()
+	if len(m.bodyContains) > 0 {
+		found := false
+		for _, s := range m.bodyContains {
+			if strings.Contains(body, s) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return m.bodyRegex == nil || m.bodyRegex.MatchString(body)
+}
+
+// attributesMatch reports whether every wanted key is present with the wanted string value.
+func attributesMatch(attrs []*commonv1.KeyValue, want map[string]string) bool {
+	for k, v := range want {
+		found := false
+		for _, kv := range attrs {
+			if kv.GetKey() == k {
+				found = kv.GetValue().GetStringValue() == v
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+type route struct {
+	name      string
+	match     *matcher
+	exporters []int // Indexes into Router.exporters
+	maxZone   stochastic.AmbientStatus
+}
+
+// Router fans records out to exporters based on content. Every matching route
+// receives a record; records matching no route go to the default exporters.
+// A route whose max zone is below the current somatic zone is paused: its
+// matching records are skipped (and counted) rather than delivered.
+type Router struct {
+	rt        *stochastic.Runtime
+	metrics   *metrics
+	exporters []Exporter
+	routes    []route
+	defaults  []int
+}
+
+// NewRouter compiles the routing table against the named exporters, pausing
+// routes by the zone of rt. With no routes and no default, every exporter
+// becomes a default target.
+func NewRouter(rt *stochastic.Runtime, cfg config.RoutingConfig, exporters []Exporter) (*Router, error) {
+	r := &Router{rt: rt, metrics: metricsFor(rt), exporters: exporters}
+	index := make(map[string]int, len(exporters))
+	for i, e := range exporters {
+		if _, dup := index[e.Name()]; dup {
+			return nil, fmt.Errorf("router: duplicate exporter name %q", e.Name())
+		}
+		index[e.Name()] = i
+	}
+
+	resolve := func(names []string) ([]int, error) {
+		out := make([]int, 0, len(names))
+		for _, n := range names {
+			i, ok := index[n]
+			if !ok {
+				return nil, fmt.Errorf("unknown exporter %q", n)
+			}
+			out = append(out, i)
+		}
+		return out, nil
+	}
+
+	for _, rc := range cfg.Routes {
+		m, err := compileMatcher(rc.Match)
+		if err != nil {
+			return nil, fmt.Errorf("router: route %s: %w", rc.Name, err)
+		}
+		targets, err := resolve(rc.Exporters)
+		if err != nil {
+			return nil, fmt.Errorf("router: route %s: %w", rc.Name, err)
+		}
+		maxZone := stochastic.StatusRed
+		if rc.MaxZone != "" {
+			if maxZone, err = stochastic.ParseStatus(rc.MaxZone); err != nil {
+				return nil, fmt.Errorf("router: route %s: %w", rc.Name, err)
+			}
+		}
+		r.routes = append(r.routes, route{name: rc.Name, match: m, exporters: targets, maxZone: maxZone})
+	}
+
+	if len(cfg.Default) > 0 {
+		d, err := resolve(cfg.Default)
+		if err != nil {
+			return nil, fmt.Errorf("router: default: %w", err)
+		}
+		r.defaults = d
+	} else if len(cfg.Routes) == 0 {
+		for i := range exporters {
+			r.defaults = append(r.defaults, i)
+		}
+	}
+	return r, nil
+}
+
+func (r *Router) Name() string { return "router" }
+
+// subBatch accumulates the records destined for one exporter, cloning the
+// ResourceLogs/ScopeLogs containers lazily so shared records are not copied.
+type subBatch struct {
+	logs   []*logsv1.ResourceLogs
+	rlIdx  int
+	slIdx  int
+	currRL *logsv1.ResourceLogs
+	currSL *logsv1.ScopeLogs
+}
+
+func (b *subBatch) add(rlIdx, slIdx int, rl *logsv1.ResourceLogs, sl *logsv1.ScopeLogs, lr *logsv1.LogRecord) {
+	if b.currRL == nil || b.rlIdx != rlIdx {
+		b.currRL = &logsv1.ResourceLogs{Resource: rl.Resource, SchemaUrl: rl.SchemaUrl}
+		b.logs = append(b.logs, b.currRL)
+		b.rlIdx = rlIdx
+		b.currSL = nil
+	}
+	if b.currSL == nil || b.slIdx != slIdx {
+		b.currSL = &logsv1.ScopeLogs{Scope: sl.Scope, SchemaUrl: sl.SchemaUrl}
+		b.currRL.ScopeLogs = append(b.currRL.ScopeLogs, b.currSL)
+		b.slIdx = slIdx
+	}
+	b.currSL.LogRecords = append(b.currSL.LogRecords, lr)
+}
+
+// Export splits the batch per exporter and delivers each sub-batch.
+func (r *Router) Export(ctx context.Context, logs []*logsv1.ResourceLogs) error {
+	zone := r.rt.Status()
+	batches := make([]*subBatch, len(r.exporters))
+	seen := make([]bool, len(r.exporters))
+	resourceOK := make([]bool, len(r.routes))
+
+	for i, rl := range logs {
+		for ri := range r.routes {
+			resourceOK[ri] = r.routes[ri].match.matchResource(rl)
+		}
+		for j, sl := range rl.GetScopeLogs() {
+			for _, lr := range sl.GetLogRecords() {
+				clear(seen)
+				matched := false
+				for ri := range r.routes {
+					rt := &r.routes[ri]
+					if !resourceOK[ri] || !rt.match.matchRecord(lr) {
+						continue
+					}
+					matched = true
+					if zone > rt.maxZone {
+						r.metrics.routePausedRecordsTotal.WithLabelValues(rt.name).Inc()
+						continue
+					}
+					for _, ei := range rt.exporters {
+						seen[ei] = true
+					}
+				}
+				if !matched {
+					for _, ei := range r.defaults {
+						seen[ei] = true
+					}
+				}
+				for ei, ok := range seen {
+					if !ok {
+						continue
+					}
+					if batches[ei] == nil {
+						batches[ei] = &subBatch{}
+					}
+					batches[ei].add(i, j, rl, sl, lr)
+				}
+			}
+		}
+	}
+
+	var errs []error
+	for ei, b := range batches {
+		if b == nil {
+			continue
+		}
+		e := r.exporters[ei]
+		if err := e.Export(ctx, b.logs); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Shutdown shuts down every exporter known to the router.
+func (r *Router) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, e := range r.exporters {
+		if err := e.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
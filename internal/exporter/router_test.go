@@ -0,0 +1,185 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+type namedRecorder struct {
+	recordingExporter
+	name string
+}
+
+func (n *namedRecorder) Name() string { return n.name }
+
+func (n *namedRecorder) bodies() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var out []string
+	for _, b := range n.batches {
+		for _, rl := range b {
+			for _, sl := range rl.ScopeLogs {
+				for _, lr := range sl.LogRecords {
+					out = append(out, lr.Body.GetStringValue())
+				}
+			}
+		}
+	}
+	return out
+}
+
+func routedRecord(body string, sev logsv1.SeverityNumber) *logsv1.LogRecord {
+	return &logsv1.LogRecord{
+		SeverityNumber: sev,
+		Body:           &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: body}},
+	}
+}
+
+func TestRouter_ContentBasedFanout(t *testing.T) {
+	resetZone(t)
+
+	loki := &namedRecorder{name: "loki"}
+	splunk := &namedRecorder{name: "splunk"}
+	s3 := &namedRecorder{name: "s3"}
+
+	r, err := NewRouter(stochastic.Default, config.RoutingConfig{
+		Default: []string{"loki"},
+		Routes: []config.RouteConfig{
+			{Name: "archive-all", Exporters: []string{"s3"}},
+			{
+				Name:      "security",
+				Exporters: []string{"splunk"},
+				Match: config.MatchConfig{
+					Resource:     map[string]string{"service.name": "auth"},
+					MinSeverity:  "warn",
+					BodyContains: []string{"denied", "sudo"},
+				},
+			},
+			{
+				Name:      "payments-errors",
+				Exporters: []string{"splunk", "loki"},
+				Match:     config.MatchConfig{BodyRegex: `^payment \d+ failed$`},
+			},
+		},
+	}, []Exporter{loki, splunk, s3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth := testResourceLogs("auth")
+	auth.ScopeLogs[0].LogRecords = []*logsv1.LogRecord{
+		routedRecord("access denied for bob", logsv1.SeverityNumber_SEVERITY_NUMBER_WARN),
+		routedRecord("access denied for eve", logsv1.SeverityNumber_SEVERITY_NUMBER_DEBUG), // Below min severity
+		routedRecord("user logged in", logsv1.SeverityNumber_SEVERITY_NUMBER_ERROR),        // No keyword
+	}
+	billing := testResourceLogs("billing")
+	billing.ScopeLogs[0].LogRecords = []*logsv1.LogRecord{
+		routedRecord("payment 42 failed", logsv1.SeverityNumber_SEVERITY_NUMBER_ERROR),
+		routedRecord("sudo make me a sandwich", logsv1.SeverityNumber_SEVERITY_NUMBER_ERROR), // Wrong service
+	}
+
+	if err := r.Export(context.Background(), []*logsv1.ResourceLogs{auth, billing}); err != nil {
+		t.Fatal(err)
+	}
+
+	assertBodies(t, "s3", s3.bodies(), "access denied for bob", "access denied for eve", "user logged in", "payment 42 failed", "sudo make me a sandwich")
+	assertBodies(t, "splunk", splunk.bodies(), "access denied for bob", "payment 42 failed")
+	// archive-all matches everything, so the default route is never used here.
+	assertBodies(t, "loki", loki.bodies(), "payment 42 failed")
+
+	// Sub-batches must keep the original resource so downstreams see service.name.
+	if got := serviceName(splunk.batches[0][0]); got != "auth" {
+		t.Errorf("expected splunk sub-batch to keep auth resource, got %q", got)
+	}
+}
+
+func TestRouter_DefaultRouteAndZonePolicy(t *testing.T) {
+	rt := stochastic.NewRuntime()
+	archive := &namedRecorder{name: "archive"}
+	analytics := &namedRecorder{name: "analytics"}
+	fallback := &namedRecorder{name: "fallback"}
+
+	r, err := NewRouter(rt, config.RoutingConfig{
+		Default: []string{"fallback"},
+		Routes: []config.RouteConfig{
+			{Name: "archive", Exporters: []string{"archive"}, MaxZone: "yellow", Match: config.MatchConfig{MinSeverity: "info"}},
+			{Name: "analytics", Exporters: []string{"analytics"}, MaxZone: "green", Match: config.MatchConfig{MinSeverity: "info"}},
+		},
+	}, []Exporter{archive, analytics, fallback})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rl := testResourceLogs("svc")
+	rl.ScopeLogs[0].LogRecords = []*logsv1.LogRecord{
+		routedRecord("info", logsv1.SeverityNumber_SEVERITY_NUMBER_INFO),
+		routedRecord("debug", logsv1.SeverityNumber_SEVERITY_NUMBER_DEBUG),
+	}
+
+	rt.SetStatus(stochastic.StatusYellow)
+	if err := r.Export(context.Background(), []*logsv1.ResourceLogs{rl}); err != nil {
+		t.Fatal(err)
+	}
+	assertBodies(t, "archive", archive.bodies(), "info")
+	assertBodies(t, "analytics", analytics.bodies())
+	assertBodies(t, "fallback", fallback.bodies(), "debug")
+
+	// In Red the archive route pauses too, but the paused match must not leak to the default.
+	rt.SetStatus(stochastic.StatusRed)
+	if err := r.Export(context.Background(), []*logsv1.ResourceLogs{rl}); err != nil {
+		t.Fatal(err)
+	}
+	assertBodies(t, "archive", archive.bodies(), "info")
+	assertBodies(t, "fallback", fallback.bodies(), "debug", "debug")
+}
+
+func TestNewRouter_Validation(t *testing.T) {
+	exporters := []Exporter{&namedRecorder{name: "a"}}
+	tests := []struct {
+		name string
+		cfg  config.RoutingConfig
+	}{
+		{"UnknownExporter", config.RoutingConfig{Routes: []config.RouteConfig{{Name: "r", Exporters: []string{"b"}}}}},
+		{"UnknownDefault", config.RoutingConfig{Default: []string{"b"}}},
+		{"BadRegex", config.RoutingConfig{Routes: []config.RouteConfig{{Name: "r", Exporters: []string{"a"}, Match: config.MatchConfig{BodyRegex: "("}}}}},
+		{"BadSeverity", config.RoutingConfig{Routes: []config.RouteConfig{{Name: "r", Exporters: []string{"a"}, Match: config.MatchConfig{MinSeverity: "loud"}}}}},
+		{"BadZone", config.RoutingConfig{Routes: []config.RouteConfig{{Name: "r", Exporters: []string{"a"}, MaxZone: "blue"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRouter(stochastic.Default, tt.cfg, exporters); err == nil {
+				t.Error("expected configuration error")
+			}
+		})
+	}
+
+	if _, err := NewRouter(stochastic.Default, config.RoutingConfig{}, []Exporter{&namedRecorder{name: "a"}, &namedRecorder{name: "a"}}); err == nil {
+		t.Error("expected duplicate exporter names to be rejected")
+	}
+}
+
+func resetZone(t *testing.T) {
+	t.Helper()
+	prev := stochastic.GetAmbientStatus()
+	stochastic.MustSetAmbientStatus(stochastic.StatusGreen)
+	t.Cleanup(func() { stochastic.MustSetAmbientStatus(prev) })
+}
+
+func assertBodies(t *testing.T, name string, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s received %v; want %v", name, got, want)
+		return
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%s received %v; want %v", name, got, want)
+			return
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package exporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pierrec/lz4/v4"
+	"github.com/rs/zerolog/log"
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	logcol "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// DefaultS3PartSize is the multipart chunk size. S3 requires at least 5MiB
+	// for every part except the last.
+	DefaultS3PartSize = 8 * 1024 * 1024
+	// MinS3PartSize is the smallest part size accepted by S3.
+	MinS3PartSize = 5 * 1024 * 1024
+	// DefaultS3Timeout bounds each individual HTTP request.
+	DefaultS3Timeout = 30 * time.Second
+
+	// unknownService is the OTel default when service.name is missing.
+	unknownService = "unknown_service"
+)
+
+// S3Exporter archives batches as compressed OTLP protobuf objects in an
+// S3-compatible bucket. Objects are keyed by date and service:
+//
+//	<prefix>/dt=2026-02-25/service=checkout/153012-1740497412000000000-7.pb.gz
+type S3Exporter struct {
+	name     string
+	cfg      config.S3Config
+	endpoint *url.URL
+	client   *http.Client
+	signer   *sigV4Signer
+	seq      atomic.Uint64
+	metrics  *metrics
+
+	// compression starts as cfg.Compression and may be switched at runtime.
+	compression atomic.Pointer[string]
+
+	// now is overridable for deterministic keys in tests.
+	now func() time.Time
+}
+
+// NewS3Exporter validates cfg and creates an archive exporter. Credentials fall
+// back to the standard AWS_* environment variables when not configured. Written
+// bytes are counted on rt.
+func NewS3Exporter(rt *stochastic.Runtime, name string, cfg config.S3Config) (*S3Exporter, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3: bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	if cfg.AccessKeyID == "" {
+		cfg.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if cfg.SecretAccessKey == "" {
+		cfg.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+	if cfg.SessionToken == "" {
+		cfg.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("s3: access key id and secret access key are required")
+	}
+
+	if cfg.Compression == "" {
+		cfg.Compression = "gzip"
+	}
+	if err := validS3Compression(cfg.Compression); err != nil {
+		return nil, err
+	}
+
+	if cfg.PartSize == 0 {
+		cfg.PartSize = DefaultS3PartSize
+	}
+	if cfg.PartSize < MinS3PartSize {
+		return nil, fmt.Errorf("s3: part_size must be at least %d bytes", MinS3PartSize)
+	}
+	if cfg.MultipartThreshold == 0 {
+		cfg.MultipartThreshold = cfg.PartSize
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = DefaultS3Timeout
+	}
+
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("s3: invalid endpoint: %w", err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("s3: endpoint must include scheme and host: %q", cfg.Endpoint)
+	}
+
+	e := &S3Exporter{
+		name:     name,
+		cfg:      cfg,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		signer: &sigV4Signer{
+			accessKey:    cfg.AccessKeyID,
+			secretKey:    cfg.SecretAccessKey,
+			sessionToken: cfg.SessionToken,
+			region:       cfg.Region,
+			service:      "s3",
+		},
+		metrics: metricsFor(rt),
+		now:     time.Now,
+	}
+	e.compression.Store(&cfg.Compression)
+	return e, nil
+}
+
+func validS3Compression(c string) error {
+	switch c {
+	case "gzip", "lz4", "none":
+		return nil
+	default:
+		return fmt.Errorf("s3: unsupported compression %q", c)
+	}
+}
+
+// CheckCompression accepts the codecs SetCompression can switch to.
+func (e *S3Exporter) CheckCompression(compression string) error {
+	return validS3Compression(compression)
+}
+
+// SetCompression switches the codec used for new objects, e.g. to the cheaper
+// lz4 while the engine is under pressure.
+func (e *S3Exporter) SetCompression(compression string) error {
+	if err := validS3Compression(compression); err != nil {
+		return err
+	}
+	e.compression.Store(&compression)
+	return nil
+}
+
+func (e *S3Exporter) Name() string { return e.name }
+
+// Export writes one object per service.name contained in the batch.
+func (e *S3Exporter) Export(ctx context.Context, logs []*logsv1.ResourceLogs) error {
+	groups := make(map[string][]*logsv1.ResourceLogs)
+	for _, rl := range logs {
+		svc := serviceName(rl)
+		groups[svc] = append(groups[svc], rl)
+	}
+
+	services := make([]string, 0, len(groups))
+	for svc := range groups {
+		services = append(services, svc)
+	}
+	sort.Strings(services)
+
+	now := e.now()
+	compression := *e.compression.Load()
+	for _, svc := range services {
+		payload, err := proto.Marshal(&logcol.ExportLogsServiceRequest{ResourceLogs: groups[svc]})
+		if err != nil {
+			return fmt.Errorf("s3: marshal batch: %w", err)
+		}
+		body, err := compress(payload, compression)
+		if err != nil {
+			return fmt.Errorf("s3: compress batch: %w", err)
+		}
+
+		key := e.objectKey(svc, now, compression)
+		if int64(len(body)) > e.cfg.MultipartThreshold {
+			err = e.putMultipart(ctx, key, body)
+		} else {
+			err = e.putObject(ctx, key, body)
+		}
+		if err != nil {
+			return err
+		}
+
+		e.metrics.exportedBytesTotal.WithLabelValues(e.name).Add(float64(len(body)))
+		log.Debug().Str("exporter", e.name).Str("key", key).Int("bytes", len(body)).Msg("Archived batch to S3")
+	}
+	return nil
+}
+
+func (e *S3Exporter) Shutdown(ctx context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// objectKey builds the date/service partitioned key for a new object.
+func (e *S3Exporter) objectKey(service string, t time.Time, compression string) string {
+	t = t.UTC()
+	var b strings.Builder
+	if p := strings.Trim(e.cfg.Prefix, "/"); p != "" {
+		b.WriteString(p)
+		b.WriteByte('/')
+	}
+	b.WriteString("dt=")
+	b.WriteString(t.Format("2006-01-02"))
+	b.WriteString("/service=")
+	b.WriteString(sanitizeKeySegment(service))
+	b.WriteByte('/')
+	b.WriteString(t.Format("150405"))
+	b.WriteByte('-')
+	b.WriteString(strconv.FormatInt(t.UnixNano(), 10))
+	b.WriteByte('-')
+	b.WriteString(strconv.FormatUint(e.seq.Add(1), 10))
+	b.WriteString(".pb")
+	switch compression {
+	case "gzip":
+		b.WriteString(".gz")
+	case "lz4":
+		b.WriteString(".lz4")
+	}
+	return b.String()
+}
+
+// sanitizeKeySegment restricts a partition value to a conservative charset so
+// keys never need escaping and cannot introduce extra path segments.
+func sanitizeKeySegment(s string) string {
+	if s == "" {
+		return unknownService
+	}
+	out := []byte(s)
+	for i, c := range out {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.':
+		default:
+			out[i] = '_'
+		}
+	}
+	return string(out)
+}
+
+func compress(payload []byte, compression string) ([]byte, error) {
+	var buf bytes.Buffer
+	switch compression {
+	case "gzip":
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+	case "lz4":
+		zw := lz4.NewWriter(&buf)
+		if _, err := zw.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return payload, nil
+	}
+	return buf.Bytes(), nil
+}
+
+// objectURL returns the URL for key using path-style or virtual-hosted addressing.
+func (e *S3Exporter) objectURL(key string, query url.Values) *url.URL {
+	u := *e.endpoint
+	base := strings.TrimSuffix(u.Path, "/")
+	if e.cfg.ForcePathStyle {
+		u.Path = base + "/" + e.cfg.Bucket + "/" + key
+	} else {
+		u.Host = e.cfg.Bucket + "." + u.Host
+		u.Path = base + "/" + key
+	}
+	u.RawPath = ""
+	u.RawQuery = query.Encode()
+	return &u
+}
+
+func (e *S3Exporter) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, e.objectURL(key, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+
+	payloadHash := emptyPayloadHash
+	if len(body) > 0 {
+		payloadHash = hashHex(body)
+	}
+	e.signer.sign(req, payloadHash, e.now())
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3: %s %s: %w", method, key, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("s3: %s %s: %s: %s", method, key, resp.Status, bytes.TrimSpace(msg))
+	}
+	return resp, nil
+}
+
+func (e *S3Exporter) putObject(ctx context.Context, key string, body []byte) error {
+	resp, err := e.do(ctx, http.MethodPut, key, nil, body)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+// putMultipart uploads body in PartSize chunks, aborting the upload on failure
+// so no orphaned parts accumulate in the bucket.
+func (e *S3Exporter) putMultipart(ctx context.Context, key string, body []byte) error {
+	resp, err := e.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return err
+	}
+	var initiated initiateMultipartUploadResult
+	err = xml.NewDecoder(resp.Body).Decode(&initiated)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("s3: decode multipart initiation: %w", err)
+	}
+	if initiated.UploadID == "" {
+		return fmt.Errorf("s3: multipart initiation returned no upload id")
+	}
+
+	if err := e.uploadParts(ctx, key, initiated.UploadID, body); err != nil {
+		abortCtx, cancel := context.WithTimeout(context.Background(), e.cfg.Timeout)
+		defer cancel()
+		if resp, abortErr := e.do(abortCtx, http.MethodDelete, key, url.Values{"uploadId": {initiated.UploadID}}, nil); abortErr != nil {
+			log.Warn().Err(abortErr).Str("key", key).Msg("Failed to abort S3 multipart upload")
+		} else {
+			resp.Body.Close()
+		}
+		return err
+	}
+	return nil
+}
+
+func (e *S3Exporter) uploadParts(ctx context.Context, key, uploadID string, body []byte) error {
+	var complete completeMultipartUpload
+	for part, off := 1, int64(0); off < int64(len(body)); part++ {
+		end := off + e.cfg.PartSize
+		if end > int64(len(body)) {
+			end = int64(len(body))
+		}
+
+		q := url.Values{
+			"partNumber": {strconv.Itoa(part)},
+			"uploadId":   {uploadID},
+		}
+		resp, err := e.do(ctx, http.MethodPut, key, q, body[off:end])
+		if err != nil {
+			return err
+		}
+		etag := resp.Header.Get("ETag")
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if etag == "" {
+			return fmt.Errorf("s3: part %d of %s returned no ETag", part, key)
+		}
+
+		complete.Parts = append(complete.Parts, completedPart{PartNumber: part, ETag: etag})
+		off = end
+	}
+
+	payload, err := xml.Marshal(complete)
+	if err != nil {
+		return err
+	}
+	resp, err := e.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// S3 may return 200 with an <Error> document when completion fails late.
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if bytes.Contains(msg, []byte("<Error>")) {
+		return fmt.Errorf("s3: complete multipart %s: %s", key, bytes.TrimSpace(msg))
+	}
+	return nil
+}
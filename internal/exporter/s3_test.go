@@ -0,0 +1,295 @@
+package exporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	logcol "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeS3 is a minimal in-process S3 server supporting PutObject and the
+// multipart upload lifecycle.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	uploads map[string]map[int][]byte
+	aborted int
+	failPut bool
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string][]byte{}, uploads: map[string]map[int][]byte{}}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	sum := sha256.Sum256(body)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		http.Error(w, "XAmzContentSHA256Mismatch", http.StatusBadRequest)
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := r.URL.Path
+	q := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		id := fmt.Sprintf("upload-%d", len(f.uploads)+1)
+		f.uploads[id] = map[int][]byte{}
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+	case r.Method == http.MethodPut && q.Has("partNumber"):
+		if f.failPut {
+			http.Error(w, "InternalError", http.StatusInternalServerError)
+			return
+		}
+		var n int
+		fmt.Sscanf(q.Get("partNumber"), "%d", &n)
+		f.uploads[q.Get("uploadId")][n] = body
+		w.Header().Set("ETag", fmt.Sprintf("\"etag-%d\"", n))
+	case r.Method == http.MethodPost && q.Has("uploadId"):
+		parts := f.uploads[q.Get("uploadId")]
+		nums := make([]int, 0, len(parts))
+		for n := range parts {
+			nums = append(nums, n)
+		}
+		sort.Ints(nums)
+		var obj []byte
+		for _, n := range nums {
+			obj = append(obj, parts[n]...)
+		}
+		f.objects[key] = obj
+		delete(f.uploads, q.Get("uploadId"))
+		fmt.Fprint(w, "<CompleteMultipartUploadResult></CompleteMultipartUploadResult>")
+	case r.Method == http.MethodDelete && q.Has("uploadId"):
+		delete(f.uploads, q.Get("uploadId"))
+		f.aborted++
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		if f.failPut {
+			http.Error(w, "InternalError", http.StatusInternalServerError)
+			return
+		}
+		f.objects[key] = body
+	default:
+		http.Error(w, "NotImplemented", http.StatusNotImplemented)
+	}
+}
+
+func (f *fakeS3) keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var keys []string
+	for k := range f.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func testResourceLogs(service string, bodies ...string) *logsv1.ResourceLogs {
+	rl := &logsv1.ResourceLogs{
+		Resource:  &resourcev1.Resource{},
+		ScopeLogs: []*logsv1.ScopeLogs{{}},
+	}
+	if service != "" {
+		rl.Resource.Attributes = []*commonv1.KeyValue{{
+			Key:   "service.name",
+			Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: service}},
+		}}
+	}
+	for _, b := range bodies {
+		rl.ScopeLogs[0].LogRecords = append(rl.ScopeLogs[0].LogRecords, &logsv1.LogRecord{
+			Body: &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: b}},
+		})
+	}
+	return rl
+}
+
+func newTestS3Exporter(t *testing.T, srv *httptest.Server, compression string) *S3Exporter {
+	t.Helper()
+	e, err := NewS3Exporter(stochastic.Default, "archive", config.S3Config{
+		Endpoint:        srv.URL,
+		Bucket:          "logs",
+		Prefix:          "/gophership/",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "SECRET",
+		ForcePathStyle:  true,
+		Compression:     compression,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.now = func() time.Time { return time.Date(2026, 2, 25, 15, 30, 12, 0, time.UTC) }
+	return e
+}
+
+func TestS3Exporter_PartitionsByDateAndService(t *testing.T) {
+	fake := newFakeS3()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	e := newTestS3Exporter(t, srv, "gzip")
+	batch := []*logsv1.ResourceLogs{
+		testResourceLogs("checkout", "a", "b"),
+		testResourceLogs("payments/eu", "c"),
+		testResourceLogs("", "d"),
+		testResourceLogs("checkout", "e"),
+	}
+	if err := e.Export(context.Background(), batch); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	keys := fake.keys()
+	if len(keys) != 3 {
+		t.Fatalf("expected 3 objects (one per service), got %d: %v", len(keys), keys)
+	}
+	wantPrefixes := []string{
+		"/logs/gophership/dt=2026-02-25/service=checkout/153012-",
+		"/logs/gophership/dt=2026-02-25/service=payments_eu/153012-",
+		"/logs/gophership/dt=2026-02-25/service=unknown_service/153012-",
+	}
+	for i, p := range wantPrefixes {
+		if !strings.HasPrefix(keys[i], p) || !strings.HasSuffix(keys[i], ".pb.gz") {
+			t.Errorf("key %d = %s; want prefix %s and suffix .pb.gz", i, keys[i], p)
+		}
+	}
+
+	// The checkout object must decode to both checkout ResourceLogs.
+	zr, err := gzip.NewReader(bytes.NewReader(fake.objects[keys[0]]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := io.ReadAll(zr)
+	var req logcol.ExportLogsServiceRequest
+	if err := proto.Unmarshal(raw, &req); err != nil {
+		t.Fatalf("archived object is not an OTLP request: %v", err)
+	}
+	if n := countRecords(req.ResourceLogs); n != 3 {
+		t.Errorf("expected 3 checkout records in archive, got %d", n)
+	}
+}
+
+func TestS3Exporter_Multipart(t *testing.T) {
+	fake := newFakeS3()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	e := newTestS3Exporter(t, srv, "none")
+	e.cfg.PartSize = 64
+	e.cfg.MultipartThreshold = 64
+
+	rl := testResourceLogs("bulk", strings.Repeat("x", 100), strings.Repeat("y", 100))
+	if err := e.Export(context.Background(), []*logsv1.ResourceLogs{rl}); err != nil {
+		t.Fatalf("multipart Export failed: %v", err)
+	}
+
+	keys := fake.keys()
+	if len(keys) != 1 {
+		t.Fatalf("expected 1 assembled object, got %v", keys)
+	}
+	want, _ := proto.Marshal(&logcol.ExportLogsServiceRequest{ResourceLogs: []*logsv1.ResourceLogs{rl}})
+	if !bytes.Equal(fake.objects[keys[0]], want) {
+		t.Errorf("assembled multipart object does not match marshaled batch")
+	}
+	if len(fake.uploads) != 0 {
+		t.Errorf("expected no in-flight uploads, got %d", len(fake.uploads))
+	}
+}
+
+func TestS3Exporter_MultipartAbortOnFailure(t *testing.T) {
+	fake := newFakeS3()
+	fake.failPut = true
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	e := newTestS3Exporter(t, srv, "none")
+	e.cfg.PartSize = 64
+	e.cfg.MultipartThreshold = 64
+
+	rl := testResourceLogs("bulk", strings.Repeat("x", 200))
+	if err := e.Export(context.Background(), []*logsv1.ResourceLogs{rl}); err == nil {
+		t.Fatal("expected error when part upload fails")
+	}
+	if fake.aborted != 1 {
+		t.Errorf("expected multipart upload to be aborted once, got %d", fake.aborted)
+	}
+}
+
+func TestNewS3Exporter_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.S3Config
+	}{
+		{"MissingBucket", config.S3Config{AccessKeyID: "a", SecretAccessKey: "b"}},
+		{"BadCompression", config.S3Config{Bucket: "b", AccessKeyID: "a", SecretAccessKey: "b", Compression: "brotli"}},
+		{"SmallPartSize", config.S3Config{Bucket: "b", AccessKeyID: "a", SecretAccessKey: "b", PartSize: 1024}},
+		{"RelativeEndpoint", config.S3Config{Bucket: "b", AccessKeyID: "a", SecretAccessKey: "b", Endpoint: "minio:9000"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewS3Exporter(stochastic.Default, "x", tt.cfg); err == nil {
+				t.Errorf("expected validation error")
+			}
+		})
+	}
+}
+
+func TestSetCompression_ThroughWrappers(t *testing.T) {
+	fake := newFakeS3()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	s3 := newTestS3Exporter(t, srv, "gzip")
+	wrapped := NewBatcher(stochastic.Default, NewResilient(stochastic.Default, s3, config.RetryConfig{}, nil), 0, time.Hour)
+	defer wrapped.Shutdown(context.Background())
+
+	if err := CheckCompression(wrapped, "brotli"); err == nil {
+		t.Fatal("expected CheckCompression to reject an unsupported codec")
+	}
+	if err := CheckCompression(wrapped, "lz4"); err != nil {
+		t.Fatalf("CheckCompression failed: %v", err)
+	}
+	if err := SetCompression(wrapped, "brotli"); err == nil {
+		t.Fatal("expected unsupported codec to be rejected")
+	}
+	if err := SetCompression(wrapped, "none"); err != nil {
+		t.Fatalf("SetCompression failed: %v", err)
+	}
+	if err := s3.Export(context.Background(), []*logsv1.ResourceLogs{testResourceLogs("checkout", "a")}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if keys := fake.keys(); len(keys) != 1 || !strings.HasSuffix(keys[0], ".pb") {
+		t.Errorf("keys = %v; want one uncompressed .pb object", keys)
+	}
+
+	if err := SetCompression(&flakyExporter{}, "lz4"); err == nil {
+		t.Error("expected an error for an exporter without compression")
+	}
+	if err := CheckCompression(&flakyExporter{}, "lz4"); err == nil {
+		t.Error("expected CheckCompression to fail for an exporter without compression")
+	}
+}
@@ -0,0 +1,238 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/exporter"
+	"github.com/sungp/gophership/internal/stochastic"
+)
+
+// maxOutput caps the command output or response body quoted in errors.
+const maxOutput = 512
+
+// Build constructs the dispatcher for the configured rules and policies,
+// following the zone of rt. Compression hooks refer to exporters by name.
+func Build(rt *stochastic.Runtime, cfg config.HooksConfig, exporters []exporter.Exporter) (*Dispatcher, error) {
+	byName := make(map[string]exporter.Exporter, len(exporters))
+	for _, e := range exporters {
+		byName[e.Name()] = e
+	}
+
+	var rules Rules
+	seen := make(map[string]bool, len(cfg.Rules))
+	for _, hc := range cfg.Rules {
+		if hc.Name == "" {
+			return nil, fmt.Errorf("hook of action %q has no name", hc.Action)
+		}
+		if seen[hc.Name] {
+			return nil, fmt.Errorf("duplicate hook name %q", hc.Name)
+		}
+		seen[hc.Name] = true
+
+		rule, err := buildRule(hc, byName)
+		if err != nil {
+			return nil, fmt.Errorf("hook %s: %w", hc.Name, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	var ps []ZonePolicy
+	if len(rules) > 0 {
+		ps = append(ps, rules)
+	}
+	for _, name := range cfg.Policies {
+		p, ok := lookupPolicy(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown zone policy %q (registered: %s)", name, strings.Join(Policies(), ", "))
+		}
+		ps = append(ps, p)
+	}
+	return NewDispatcher(rt, ps...), nil
+}
+
+func buildRule(hc config.HookConfig, exporters map[string]exporter.Exporter) (Rule, error) {
+	var r Rule
+	if len(hc.On) == 0 {
+		return r, fmt.Errorf("on must list at least one zone")
+	}
+	var err error
+	if r.On, err = parseStatuses(hc.On); err != nil {
+		return r, err
+	}
+	if r.From, err = parseStatuses(hc.From); err != nil {
+		return r, err
+	}
+
+	timeout := hc.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	switch hc.Action {
+	case "webhook":
+		r.Action, err = NewWebhook(hc.Name, hc.URL, timeout)
+	case "exec":
+		r.Action, err = NewExec(hc.Name, hc.Command, timeout)
+	case "compression":
+		e, ok := exporters[hc.Exporter]
+		if !ok {
+			return r, fmt.Errorf("unknown exporter %q", hc.Exporter)
+		}
+		if hc.Compression == "" {
+			return r, fmt.Errorf("compression hook needs a compression")
+		}
+		// Reject an exporter or codec that cannot switch now rather than
+		// failing on every zone change.
+		if err := exporter.CheckCompression(e, hc.Compression); err != nil {
+			return r, err
+		}
+		r.Action = &compressionAction{name: hc.Name, exporter: e, compression: hc.Compression}
+	case "log":
+		r.Action = logAction(hc.Name)
+	default:
+		return r, fmt.Errorf("unknown action %q", hc.Action)
+	}
+	return r, err
+}
+
+func parseStatuses(names []string) ([]stochastic.AmbientStatus, error) {
+	out := make([]stochastic.AmbientStatus, 0, len(names))
+	for _, n := range names {
+		s, err := stochastic.ParseStatus(n)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// Webhook POSTs each zone change as JSON, typically to a local agent.
+type Webhook struct {
+	name    string
+	url     string
+	timeout time.Duration
+	client  *http.Client
+}
+
+// NewWebhook creates a webhook action posting to rawURL.
+func NewWebhook(name, rawURL string, timeout time.Duration) (*Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("webhook url must be an absolute http(s) URL, got %q", rawURL)
+	}
+	return &Webhook{name: name, url: rawURL, timeout: timeout, client: &http.Client{}}, nil
+}
+
+func (w *Webhook) Name() string { return w.name }
+
+// Timeout bounds each delivery.
+func (w *Webhook) Timeout() time.Duration { return w.timeout }
+
+// Run posts ev and fails on a non-2xx response.
+func (w *Webhook) Run(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxOutput))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// Exec runs a command on each zone change, e.g. a script that pauses a file
+// input. The change is passed in GS_ZONE, GS_ZONE_FROM, GS_ZONE_SENSOR and
+// GS_ZONE_REASON.
+type Exec struct {
+	name    string
+	argv    []string
+	timeout time.Duration
+}
+
+// NewExec creates an exec action running argv.
+func NewExec(name string, argv []string, timeout time.Duration) (*Exec, error) {
+	if len(argv) == 0 || argv[0] == "" {
+		return nil, fmt.Errorf("exec needs a command")
+	}
+	return &Exec{name: name, argv: argv, timeout: timeout}, nil
+}
+
+func (e *Exec) Name() string { return e.name }
+
+// Timeout bounds each run; the process is killed when it expires.
+func (e *Exec) Timeout() time.Duration { return e.timeout }
+
+// Run executes the command and fails on a non-zero exit.
+func (e *Exec) Run(ctx context.Context, ev Event) error {
+	cmd := exec.CommandContext(ctx, e.argv[0], e.argv[1:]...)
+	cmd.Env = append(os.Environ(),
+		"GS_ZONE="+ev.To.String(),
+		"GS_ZONE_FROM="+ev.From.String(),
+		"GS_ZONE_SENSOR="+ev.Sensor,
+		"GS_ZONE_REASON="+ev.Reason,
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if len(out) > maxOutput {
+			out = out[:maxOutput]
+		}
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// compressionAction switches an exporter's codec, e.g. to lz4 in Red.
+type compressionAction struct {
+	name        string
+	exporter    exporter.Exporter
+	compression string
+}
+
+func (c *compressionAction) Name() string { return c.name }
+
+func (c *compressionAction) Run(ctx context.Context, ev Event) error {
+	if err := exporter.SetCompression(c.exporter, c.compression); err != nil {
+		return err
+	}
+	log.Info().Str("exporter", c.exporter.Name()).Str("compression", c.compression).Str("zone", ev.To.String()).Msg("Exporter compression switched")
+	return nil
+}
+
+// logAction records zone changes in the engine log.
+type logAction string
+
+func (l logAction) Name() string { return string(l) }
+
+func (l logAction) Run(ctx context.Context, ev Event) error {
+	log.Info().
+		Str("hook", string(l)).
+		Str("from", ev.From.String()).
+		Str("to", ev.To.String()).
+		Str("sensor", ev.Sensor).
+		Str("reason", ev.Reason).
+		Msg("Somatic zone changed")
+	return nil
+}
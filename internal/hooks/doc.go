@@ -0,0 +1,4 @@
+// Package hooks runs custom reactions to somatic zone changes: built-in
+// actions (webhook, exec, exporter compression, log) selected by configured
+// rules, and ZonePolicy implementations compiled into the binary.
+package hooks
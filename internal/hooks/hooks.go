@@ -0,0 +1,260 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sungp/gophership/internal/stochastic"
+)
+
+// DefaultTimeout bounds one run of an action that does not set its own.
+const DefaultTimeout = 10 * time.Second
+
+// queueSize is the number of runs that may wait per action; further zone
+// changes are dropped for that action until it catches up.
+const queueSize = 16
+
+// Event is a somatic zone change.
+type Event struct {
+	From stochastic.AmbientStatus `json:"from"`
+	To   stochastic.AmbientStatus `json:"to"`
+	At   time.Time                `json:"at"`
+	// Sensor and Reason describe the vote that drove the change, if known.
+	Sensor string `json:"sensor,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Action reacts to a zone change. Runs of the same action are sequential,
+// in the order of the changes; different actions run concurrently.
+type Action interface {
+	// Name identifies the action in logs and metrics. Actions with the same
+	// name share a queue.
+	Name() string
+	Run(ctx context.Context, ev Event) error
+}
+
+// ZonePolicy decides which actions react to a zone change. Decide runs on
+// the dispatcher goroutine, never on the path that changes the zone, and
+// should return quickly; slow work belongs in an Action.
+type ZonePolicy interface {
+	Decide(ev Event) []Action
+}
+
+// PolicyFunc adapts a function to ZonePolicy.
+type PolicyFunc func(ev Event) []Action
+
+// Decide calls f.
+func (f PolicyFunc) Decide(ev Event) []Action { return f(ev) }
+
+var (
+	policiesMu sync.Mutex
+	policies   = make(map[string]ZonePolicy)
+)
+
+// RegisterPolicy makes a policy available to monitoring.hooks.policies under
+// name. It is meant to be called from an init function of a package linked
+// into a custom build, and panics if name is already registered.
+func RegisterPolicy(name string, p ZonePolicy) {
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	if _, ok := policies[name]; ok {
+		panic(fmt.Sprintf("hooks: policy %q registered twice", name))
+	}
+	policies[name] = p
+}
+
+// Policies returns the names of registered policies, sorted.
+func Policies() []string {
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupPolicy(name string) (ZonePolicy, bool) {
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	p, ok := policies[name]
+	return p, ok
+}
+
+// Rule runs Action when the zone changes into one of On, and, if From is
+// set, out of one of From.
+type Rule struct {
+	On     []stochastic.AmbientStatus
+	From   []stochastic.AmbientStatus
+	Action Action
+}
+
+func (r Rule) matches(ev Event) bool {
+	return containsStatus(r.On, ev.To) && (len(r.From) == 0 || containsStatus(r.From, ev.From))
+}
+
+func containsStatus(list []stochastic.AmbientStatus, s stochastic.AmbientStatus) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules is the ZonePolicy built from configured hooks.
+type Rules []Rule
+
+// Decide returns the actions of every matching rule, in order.
+func (rs Rules) Decide(ev Event) []Action {
+	var out []Action
+	for _, r := range rs {
+		if r.matches(ev) {
+			out = append(out, r.Action)
+		}
+	}
+	return out
+}
+
+// Dispatcher runs the actions its policies choose on every zone change. It
+// learns of changes through its runtime's Subscribe, whose notifications
+// never block SetStatus, and gives each action its own queue and
+// worker so that a slow action delays neither the others nor the dispatcher.
+type Dispatcher struct {
+	rt       *stochastic.Runtime
+	policies []ZonePolicy
+	metrics  *metrics
+
+	mu      sync.Mutex
+	workers map[string]chan Event
+	ctx     context.Context
+}
+
+// NewDispatcher creates a dispatcher following the zone of rt and consulting
+// policies in order.
+func NewDispatcher(rt *stochastic.Runtime, policies ...ZonePolicy) *Dispatcher {
+	return &Dispatcher{
+		rt:       rt,
+		policies: policies,
+		metrics:  metricsFor(rt),
+		workers:  make(map[string]chan Event),
+		ctx:      context.Background(),
+	}
+}
+
+// Start dispatches zone changes until ctx is done.
+func (d *Dispatcher) Start(ctx context.Context) {
+	if len(d.policies) == 0 {
+		return
+	}
+	d.mu.Lock()
+	d.ctx = ctx
+	d.mu.Unlock()
+
+	ch, cleanup := d.rt.Subscribe()
+	prev := d.rt.Status()
+	go func() {
+		defer cleanup()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case status, ok := <-ch:
+				if !ok {
+					return
+				}
+				if status == prev {
+					continue
+				}
+				ev := Event{From: prev, To: status, At: time.Now()}
+				if dec := d.rt.Zones().Decision(); dec.Status == status {
+					ev.Sensor, ev.Reason = dec.Sensor, dec.Reason
+				}
+				prev = status
+				d.Dispatch(ev)
+			}
+		}
+	}()
+}
+
+// Dispatch queues the actions chosen for ev. It never waits for an action:
+// when an action's queue is full the run is dropped and counted.
+func (d *Dispatcher) Dispatch(ev Event) {
+	for _, p := range d.policies {
+		for _, a := range decide(p, ev) {
+			q := d.queue(a)
+			select {
+			case q <- ev:
+			default:
+				d.metrics.hookRunsTotal.WithLabelValues(a.Name(), "dropped").Inc()
+				log.Warn().Str("hook", a.Name()).Str("zone", ev.To.String()).Msg("Zone hook queue full; dropping run")
+			}
+		}
+	}
+}
+
+// decide calls p, containing panics from policy code.
+func decide(p ZonePolicy, ev Event) (actions []Action) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Interface("panic", r).Msg("Zone policy panicked")
+			actions = nil
+		}
+	}()
+	return p.Decide(ev)
+}
+
+// queue returns the action's queue, starting its worker on first use.
+func (d *Dispatcher) queue(a Action) chan Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	q, ok := d.workers[a.Name()]
+	if !ok {
+		q = make(chan Event, queueSize)
+		d.workers[a.Name()] = q
+		go d.work(d.ctx, a, q)
+	}
+	return q
+}
+
+func (d *Dispatcher) work(ctx context.Context, a Action, q chan Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-q:
+			d.run(ctx, a, ev)
+		}
+	}
+}
+
+// run executes one action within its timeout, containing panics.
+func (d *Dispatcher) run(ctx context.Context, a Action, ev Event) {
+	timeout := DefaultTimeout
+	if t, ok := a.(interface{ Timeout() time.Duration }); ok && t.Timeout() > 0 {
+		timeout = t.Timeout()
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return a.Run(ctx, ev)
+	}()
+	if err != nil {
+		d.metrics.hookRunsTotal.WithLabelValues(a.Name(), "error").Inc()
+		log.Warn().Err(err).Str("hook", a.Name()).Str("from", ev.From.String()).Str("to", ev.To.String()).Msg("Zone hook failed")
+		return
+	}
+	d.metrics.hookRunsTotal.WithLabelValues(a.Name(), "ok").Inc()
+	log.Debug().Str("hook", a.Name()).Str("to", ev.To.String()).Msg("Zone hook ran")
+}
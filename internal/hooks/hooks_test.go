@@ -0,0 +1,285 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/exporter"
+	"github.com/sungp/gophership/internal/stochastic"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// recordAction records the zone changes it runs for, optionally blocking
+// until release is closed.
+type recordAction struct {
+	name    string
+	release chan struct{}
+
+	mu     sync.Mutex
+	events []Event
+	ran    chan struct{}
+}
+
+func newRecordAction(name string) *recordAction {
+	return &recordAction{name: name, ran: make(chan struct{}, 64)}
+}
+
+func (a *recordAction) Name() string { return a.name }
+
+func (a *recordAction) Run(ctx context.Context, ev Event) error {
+	if a.release != nil {
+		<-a.release
+	}
+	a.mu.Lock()
+	a.events = append(a.events, ev)
+	a.mu.Unlock()
+	a.ran <- struct{}{}
+	return nil
+}
+
+func (a *recordAction) wait(t *testing.T, n int) []Event {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-a.ran:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("action %s ran %d times, want %d", a.name, i, n)
+		}
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]Event(nil), a.events...)
+}
+
+func TestRules_Decide(t *testing.T) {
+	red := newRecordAction("red")
+	recovered := newRecordAction("recovered")
+	rules := Rules{
+		{On: []stochastic.AmbientStatus{stochastic.StatusRed}, Action: red},
+		{On: []stochastic.AmbientStatus{stochastic.StatusGreen}, From: []stochastic.AmbientStatus{stochastic.StatusRed}, Action: recovered},
+	}
+
+	tests := []struct {
+		name     string
+		from, to stochastic.AmbientStatus
+		want     []string
+	}{
+		{"IntoRed", stochastic.StatusYellow, stochastic.StatusRed, []string{"red"}},
+		{"RedToGreen", stochastic.StatusRed, stochastic.StatusGreen, []string{"recovered"}},
+		{"YellowToGreen", stochastic.StatusYellow, stochastic.StatusGreen, nil},
+		{"IntoYellow", stochastic.StatusGreen, stochastic.StatusYellow, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, a := range rules.Decide(Event{From: tt.from, To: tt.to}) {
+				got = append(got, a.Name())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Decide = %v; want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Decide = %v; want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDispatcher_SlowActionDoesNotBlock(t *testing.T) {
+	slow := newRecordAction("slow")
+	slow.release = make(chan struct{})
+	fast := newRecordAction("fast")
+	on := []stochastic.AmbientStatus{stochastic.StatusRed}
+	rt := stochastic.NewRuntime()
+	d := NewDispatcher(rt, Rules{{On: on, Action: slow}, {On: on, Action: fast}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.Start(ctx)
+
+	// One run in progress, queueSize waiting, and two beyond that dropped.
+	total := queueSize + 3
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < total; i++ {
+			d.Dispatch(Event{From: stochastic.StatusGreen, To: stochastic.StatusRed})
+			// Give the workers a chance to pick up runs: the slow one takes
+			// the first and then stalls, the fast one keeps draining.
+			time.Sleep(5 * time.Millisecond)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Dispatch blocked on a slow action")
+	}
+
+	if got := len(fast.wait(t, total)); got != total {
+		t.Errorf("fast action ran %d times, want %d", got, total)
+	}
+	// Counted on the dispatcher's runtime only.
+	if got := testutil.ToFloat64(metricsFor(rt).hookRunsTotal.WithLabelValues("slow", "dropped")); got != 2 {
+		t.Errorf("dropped %v slow runs, want 2", got)
+	}
+	if got := testutil.ToFloat64(HookRunsTotal.WithLabelValues("slow", "dropped")); got != 0 {
+		t.Errorf("default runtime counted %v dropped runs, want 0", got)
+	}
+	if got, err := testutil.GatherAndCount(rt.Registry(), "gophership_hook_runs_total"); err != nil || got == 0 {
+		t.Errorf("runtime registry exports %d hook run series (err %v)", got, err)
+	}
+	close(slow.release)
+	slow.wait(t, queueSize+1)
+}
+
+func TestDispatcher_FollowsItsRuntime(t *testing.T) {
+	rt := stochastic.NewRuntime()
+	rec := newRecordAction("rec")
+	all := []stochastic.AmbientStatus{stochastic.StatusGreen, stochastic.StatusYellow, stochastic.StatusRed}
+	d := NewDispatcher(rt, Rules{{On: all, Action: rec}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.Start(ctx)
+
+	// Changes to the default runtime are not this dispatcher's.
+	stochastic.MustSetAmbientStatus(stochastic.StatusYellow)
+	t.Cleanup(func() { stochastic.MustSetAmbientStatus(stochastic.StatusGreen) })
+	rt.SetStatus(stochastic.StatusRed)
+	evs := rec.wait(t, 1)
+	if evs[0].From != stochastic.StatusGreen || evs[0].To != stochastic.StatusRed {
+		t.Errorf("event = %s -> %s; want GREEN -> RED", evs[0].From, evs[0].To)
+	}
+	rt.SetStatus(stochastic.StatusGreen)
+	evs = rec.wait(t, 1)
+	if last := evs[len(evs)-1]; last.From != stochastic.StatusRed || last.To != stochastic.StatusGreen {
+		t.Errorf("event = %s -> %s; want RED -> GREEN", last.From, last.To)
+	}
+}
+
+func TestWebhook_Run(t *testing.T) {
+	var got Event
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode webhook body: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhook("notify", srv.URL, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := Event{From: stochastic.StatusYellow, To: stochastic.StatusRed, Sensor: "memory", Reason: "Memory usage high"}
+	if err := w.Run(context.Background(), ev); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if got.From != ev.From || got.To != ev.To || got.Sensor != ev.Sensor || got.Reason != ev.Reason {
+		t.Errorf("webhook received %+v; want %+v", got, ev)
+	}
+
+	status = http.StatusInternalServerError
+	if err := w.Run(context.Background(), ev); err == nil {
+		t.Error("expected an error for a 500 response")
+	}
+}
+
+func TestExec_Run(t *testing.T) {
+	e, err := NewExec("check", []string{"sh", "-c", `test "$GS_ZONE" = RED && test "$GS_ZONE_FROM" = YELLOW`}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Run(context.Background(), Event{From: stochastic.StatusYellow, To: stochastic.StatusRed}); err != nil {
+		t.Errorf("Run failed: %v", err)
+	}
+	if err := e.Run(context.Background(), Event{From: stochastic.StatusRed, To: stochastic.StatusGreen}); err == nil {
+		t.Error("expected a non-zero exit to fail")
+	}
+}
+
+func init() {
+	// Policies register once per process, as a linked-in package would.
+	RegisterPolicy("test-policy", PolicyFunc(func(Event) []Action { return nil }))
+}
+
+// fakeExporter drops everything; with codecs set it also switches among
+// them as an exporter.Compressor would.
+type fakeExporter struct {
+	name   string
+	codecs []string
+}
+
+func (f *fakeExporter) Name() string { return f.name }
+
+func (f *fakeExporter) Export(context.Context, []*logsv1.ResourceLogs) error { return nil }
+
+func (f *fakeExporter) Shutdown(context.Context) error { return nil }
+
+type fakeCompressor struct{ fakeExporter }
+
+func (f *fakeCompressor) CheckCompression(c string) error {
+	for _, codec := range f.codecs {
+		if c == codec {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported compression %q", c)
+}
+
+func (f *fakeCompressor) SetCompression(c string) error { return f.CheckCompression(c) }
+
+func TestBuild(t *testing.T) {
+	exporters := []exporter.Exporter{
+		&fakeCompressor{fakeExporter{name: "archive", codecs: []string{"gzip", "lz4"}}},
+		&fakeExporter{name: "stream"},
+	}
+	wrapped := exporter.NewBatcher(stochastic.Default, &fakeExporter{name: "wrapped"}, 0, time.Hour)
+	defer wrapped.Shutdown(context.Background())
+	exporters = append(exporters, wrapped)
+	compression := func(exp, codec string) config.HooksConfig {
+		return config.HooksConfig{Rules: []config.HookConfig{{Name: "x", On: []string{"red"}, Action: "compression", Exporter: exp, Compression: codec}}}
+	}
+	tests := []struct {
+		name    string
+		cfg     config.HooksConfig
+		wantErr bool
+	}{
+		{"Empty", config.HooksConfig{}, false},
+		{"Log", config.HooksConfig{Rules: []config.HookConfig{{Name: "audit", On: []string{"red"}, Action: "log"}}}, false},
+		{"RegisteredPolicy", config.HooksConfig{Policies: []string{"test-policy"}}, false},
+		{"UnknownPolicy", config.HooksConfig{Policies: []string{"missing"}}, true},
+		{"UnknownAction", config.HooksConfig{Rules: []config.HookConfig{{Name: "x", On: []string{"red"}, Action: "page"}}}, true},
+		{"UnknownZone", config.HooksConfig{Rules: []config.HookConfig{{Name: "x", On: []string{"blue"}, Action: "log"}}}, true},
+		{"NoZones", config.HooksConfig{Rules: []config.HookConfig{{Name: "x", Action: "log"}}}, true},
+		{"NoName", config.HooksConfig{Rules: []config.HookConfig{{On: []string{"red"}, Action: "log"}}}, true},
+		{"DuplicateName", config.HooksConfig{Rules: []config.HookConfig{
+			{Name: "x", On: []string{"red"}, Action: "log"},
+			{Name: "x", On: []string{"green"}, Action: "log"},
+		}}, true},
+		{"RelativeWebhook", config.HooksConfig{Rules: []config.HookConfig{{Name: "x", On: []string{"red"}, Action: "webhook", URL: "/hook"}}}, true},
+		{"EmptyExec", config.HooksConfig{Rules: []config.HookConfig{{Name: "x", On: []string{"red"}, Action: "exec"}}}, true},
+		{"Compression", compression("archive", "lz4"), false},
+		{"UnknownExporter", compression("missing", "lz4"), true},
+		{"UnsupportedCodec", compression("archive", "zstd"), true},
+		{"NoCompressor", compression("stream", "lz4"), true},
+		{"NoCompressorWrapped", compression("wrapped", "lz4"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Build(stochastic.NewRuntime(), tt.cfg, exporters)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Build error = %v; wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package hooks
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sungp/gophership/internal/stochastic"
+)
+
+// metrics holds the collectors of the hook dispatchers built on one runtime.
+type metrics struct {
+	hookRunsTotal *prometheus.CounterVec
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		hookRunsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gophership_hook_runs_total",
+			Help: "Total number of zone hook runs by result (ok, error, dropped).",
+		}, []string{"hook", "result"}),
+	}
+}
+
+func (m *metrics) mustRegister(reg *prometheus.Registry) {
+	reg.MustRegister(m.hookRunsTotal)
+}
+
+type metricsKey struct{}
+
+// metricsFor returns the collectors of the hook dispatchers built on rt, registered on
+// its registry.
+func metricsFor(rt *stochastic.Runtime) *metrics {
+	if rt == stochastic.Default {
+		return defaultMetrics
+	}
+	return rt.Collectors(metricsKey{}, func(reg *prometheus.Registry) any {
+		m := newMetrics()
+		m.mustRegister(reg)
+		return m
+	}).(*metrics)
+}
+
+var defaultMetrics = newMetrics()
+
+// The collectors of the hook dispatchers built on stochastic.Default, registered on its
+// registry at init.
+var (
+	// HookRunsTotal counts zone hook runs per hook and result (ok, error, dropped).
+	HookRunsTotal = defaultMetrics.hookRunsTotal
+)
+
+func init() {
+	defaultMetrics.mustRegister(stochastic.Registry)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/rs/zerolog/log"
 	"github.com/sungp/gophership/internal/buffer"
+	"github.com/sungp/gophership/internal/exporter"
 	"github.com/sungp/gophership/internal/somatic"
 	"github.com/sungp/gophership/internal/stochastic"
 	"github.com/sungp/gophership/internal/vault"
@@ -24,11 +25,20 @@ type Ingester struct {
 	processedCount uint64
 	fallbackCount  uint64 // Total count of dropped packets (Somatic Pivots)
 	quit           chan struct{}
+	rt             *stochastic.Runtime
 	somatic        *somatic.Controller
 	healthServer   *health.Server
+	exporter       exporter.Exporter
 }
 
+// NewIngester creates an ingester in the default runtime.
 func NewIngester(bufferSize int) *Ingester {
+	return NewIngesterWithRuntime(stochastic.Default, bufferSize)
+}
+
+// NewIngesterWithRuntime creates an ingester that senses, votes and reports
+// usage in rt.
+func NewIngesterWithRuntime(rt *stochastic.Runtime, bufferSize int) *Ingester {
 	if bufferSize <= 0 {
 		bufferSize = 1024 // Default to power-of-two alignment
 	}
@@ -41,9 +51,10 @@ func NewIngester(bufferSize int) *Ingester {
 	i := &Ingester{
 		buffer:       make(chan *[]byte, bufferSize),
 		quit:         make(chan struct{}),
+		rt:           rt,
 		healthServer: health.NewServer(),
 	}
-	i.somatic = somatic.NewController(i)
+	i.somatic = somatic.NewControllerWithRuntime(rt, i)
 
 	// Initialize health status
 	i.healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
@@ -71,16 +82,23 @@ func (i *Ingester) Somatic() *somatic.Controller {
 	return i.somatic
 }
 
+// SetExporter attaches the downstream sink fed by the worker loop.
+// It must be called before StartWorkerLoop.
+func (i *Ingester) SetExporter(e exporter.Exporter) {
+	i.exporter = e
+}
+
 // IngestData demonstrates the "Local Reflex" and "Stochastic Awareness".
 func (i *Ingester) IngestData(ctx context.Context, data *[]byte) {
 	// 1. Stochastic Check (Every 1024 operations, reassess pressure)
-	if stochastic.Monitor != nil && stochastic.Monitor.ShouldCheck() {
+	if m := i.rt.Monitor(); m != nil && m.ShouldCheck() {
 		// [AC2] Optimization: Trigger host and component sensing
-		stochastic.Monitor.MustSense()
+		m.MustSense()
 
-		// Ingester also does its own somatic reassessment (hysteresis)
-		status := i.somatic.Reassess()
-		i.updateHealthStatus(status)
+		// Ingester also votes on buffer occupancy (hysteresis); health
+		// follows the arbitrated zone.
+		i.somatic.Reassess()
+		i.updateHealthStatus(i.rt.Status())
 	}
 
 	// 2. Local Reflex (Select-Default for zero-latency buffer sensing)
@@ -104,12 +122,12 @@ func (i *Ingester) somaticFallback(ctx context.Context, data *[]byte) {
 	buffer.MustRelease(data)
 
 	// Report usage reduction
-	if stochastic.Monitor != nil {
-		stochastic.Monitor.ReportIngesterUsage(-int64(size))
+	if m := i.rt.Monitor(); m != nil {
+		m.ReportIngesterUsage(-int64(size))
 	}
 
 	// Increment Prometheus counter (Thread-safe, high efficiency)
-	stochastic.SomaticPivotsTotal.Inc()
+	i.rt.SomaticPivots().Inc()
 
 	dropped := atomic.AddUint64(&i.fallbackCount, 1)
 	if dropped%1024 == 0 {
@@ -134,12 +152,14 @@ func (i *Ingester) StartWorkerLoop(ctx context.Context) {
 				// In high-load, this is where we'd check GetAmbientStatus to decide
 				// if we should full-parse or just forward raw blobs.
 				size := len(*data)
-				_ = data
+				if i.exporter != nil {
+					i.export(ctx, *data)
+				}
 				buffer.MustRelease(data)
 
 				// Report usage reduction
-				if stochastic.Monitor != nil {
-					stochastic.Monitor.ReportIngesterUsage(-int64(size))
+				if m := i.rt.Monitor(); m != nil {
+					m.ReportIngesterUsage(-int64(size))
 				}
 				atomic.AddUint64(&i.processedCount, 1)
 			case <-i.quit:
@@ -153,6 +173,22 @@ func (i *Ingester) StartWorkerLoop(ctx context.Context) {
 	}()
 }
 
+// export decodes a buffered OTLP request and hands it to the configured exporter.
+// This runs on the deferred path, so decoding allocations are acceptable here.
+func (i *Ingester) export(ctx context.Context, data []byte) {
+	req := &logcol.ExportLogsServiceRequest{}
+	if err := proto.Unmarshal(data, req); err != nil {
+		log.Debug().Err(err).Int("size_bytes", len(data)).Msg("Skipping undecodable buffer on export path")
+		return
+	}
+	if len(req.ResourceLogs) == 0 {
+		return
+	}
+	if err := i.exporter.Export(ctx, req.ResourceLogs); err != nil {
+		log.Error().Err(err).Str("exporter", i.exporter.Name()).Msg("Export failed")
+	}
+}
+
 // ReplayRawVault streams data from the Raw Vault back into the ingestion buffer.
 func (i *Ingester) ReplayRawVault(ctx context.Context, w *vault.WAL, itemsPerSecond int) error {
 	replayer := vault.NewReplayer(w, itemsPerSecond)
@@ -160,7 +196,7 @@ func (i *Ingester) ReplayRawVault(ctx context.Context, w *vault.WAL, itemsPerSec
 	return replayer.StreamTo(ctx, func(data []byte) error {
 		// [NFR.P1] Zero-allocation copy to pooled buffer
 		bufPtr := buffer.MustAcquire(len(data))
-		copy(*bufPtr, data)
+		*bufPtr = append((*bufPtr)[:0], data...)
 
 		// [AC3] Throttling via blocking channel send
 		select {
@@ -190,8 +226,8 @@ func (i *Ingester) Export(ctx context.Context, req *logcol.ExportLogsServiceRequ
 	*bufPtr = out
 
 	// Report usage increase
-	if stochastic.Monitor != nil {
-		stochastic.Monitor.ReportIngesterUsage(int64(size))
+	if m := i.rt.Monitor(); m != nil {
+		m.ReportIngesterUsage(int64(size))
 	}
 
 	i.IngestData(ctx, bufPtr)
@@ -82,6 +82,30 @@ func TestIngester_SomaticPivotsCounter(t *testing.T) {
 	}
 }
 
+func TestIngester_RuntimeIsolation(t *testing.T) {
+	t.Parallel()
+
+	rt := stochastic.NewRuntime()
+	ing := NewIngesterWithRuntime(rt, 1)
+	if ing.Somatic().Runtime() != rt {
+		t.Fatal("somatic controller is not bound to the ingester's runtime")
+	}
+
+	ctx := context.Background()
+	ing.IngestData(ctx, buffer.MustAcquire(10))
+	ing.IngestData(ctx, buffer.MustAcquire(10)) // Buffer full: pivot
+
+	if got := testutil.ToFloat64(rt.SomaticPivots()); got != 1 {
+		t.Errorf("runtime pivots = %v; want 1", got)
+	}
+
+	// A full buffer drives this runtime's zone.
+	ing.Somatic().Reassess()
+	if got := rt.Status(); got != stochastic.StatusRed {
+		t.Errorf("runtime status = %s; want RED", got)
+	}
+}
+
 // TestIngester_TLSVersionEnforcement verifies AC1 (Reject TLS < 1.3)
 func TestIngester_TLSVersionEnforcement(t *testing.T) {
 	// 1. Generate self-signed cert for testing
@@ -0,0 +1,214 @@
+package processor
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/pkg/otel"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+type attrOp uint8
+
+const (
+	opInsert attrOp = iota
+	opUpdate
+	opUpsert
+	opRename
+	opDelete
+	opHash
+	opTruncate
+)
+
+var attrOps = map[string]attrOp{
+	"insert":   opInsert,
+	"update":   opUpdate,
+	"upsert":   opUpsert,
+	"rename":   opRename,
+	"delete":   opDelete,
+	"hash":     opHash,
+	"truncate": opTruncate,
+}
+
+type attrScope uint8
+
+const (
+	scopeLog attrScope = iota
+	scopeScope
+	scopeResource
+)
+
+type attrAction struct {
+	op      attrOp
+	scope   attrScope
+	key     string
+	value   string
+	kind    valueKind
+	newKey  string
+	maxLen  int
+	hashKey []byte
+}
+
+// AttributeProcessor applies declarative insert/update/upsert/rename/delete/
+// hash/truncate actions to resource, scope or log record attributes.
+// Inserted values and removed attributes go through the pkg/otel pools.
+type AttributeProcessor struct {
+	name    string
+	actions []attrAction
+}
+
+// NewAttributeProcessor validates the configured actions.
+func NewAttributeProcessor(name string, cfgs []config.AttributeAction) (*AttributeProcessor, error) {
+	if len(cfgs) == 0 {
+		return nil, fmt.Errorf("no attribute actions configured")
+	}
+	p := &AttributeProcessor{name: name, actions: make([]attrAction, 0, len(cfgs))}
+	for i, c := range cfgs {
+		op, ok := attrOps[strings.ToLower(c.Action)]
+		if !ok {
+			return nil, fmt.Errorf("action %d: unknown action %q", i, c.Action)
+		}
+		if c.Key == "" {
+			return nil, fmt.Errorf("action %d (%s): key is required", i, c.Action)
+		}
+		a := attrAction{op: op, key: c.Key, value: c.Value, newKey: c.NewKey, maxLen: c.MaxLength}
+
+		switch strings.ToLower(c.Scope) {
+		case "", "log":
+			a.scope = scopeLog
+		case "scope":
+			a.scope = scopeScope
+		case "resource":
+			a.scope = scopeResource
+		default:
+			return nil, fmt.Errorf("action %d (%s): unknown scope %q", i, c.Key, c.Scope)
+		}
+
+		switch op {
+		case opInsert, opUpdate, opUpsert:
+			kinds, err := compileTypes(map[string]string{c.Key: c.Type})
+			if err != nil {
+				return nil, fmt.Errorf("action %d: %w", i, err)
+			}
+			a.kind = kinds[c.Key]
+		case opRename:
+			if c.NewKey == "" {
+				return nil, fmt.Errorf("action %d (%s): rename requires new_key", i, c.Key)
+			}
+		case opTruncate:
+			if c.MaxLength <= 0 {
+				return nil, fmt.Errorf("action %d (%s): truncate requires a positive max_length", i, c.Key)
+			}
+		case opHash:
+			if c.HashKey != "" {
+				a.hashKey = []byte(c.HashKey)
+			}
+		}
+		p.actions = append(p.actions, a)
+	}
+	return p, nil
+}
+
+func (p *AttributeProcessor) Name() string { return p.name }
+
+// Process applies every action in order. Resource and scope actions run once
+// per container rather than once per record.
+func (p *AttributeProcessor) Process(_ context.Context, logs []*logsv1.ResourceLogs) ([]*logsv1.ResourceLogs, error) {
+	for _, rl := range logs {
+		for i := range p.actions {
+			if a := &p.actions[i]; a.scope == scopeResource {
+				if rl.Resource == nil {
+					if a.op != opInsert && a.op != opUpsert {
+						continue
+					}
+					rl.Resource = &resourcev1.Resource{}
+				}
+				rl.Resource.Attributes = a.apply(rl.Resource.Attributes)
+			}
+		}
+		for _, sl := range rl.GetScopeLogs() {
+			for i := range p.actions {
+				if a := &p.actions[i]; a.scope == scopeScope {
+					if sl.Scope == nil {
+						if a.op != opInsert && a.op != opUpsert {
+							continue
+						}
+						sl.Scope = &commonv1.InstrumentationScope{}
+					}
+					sl.Scope.Attributes = a.apply(sl.Scope.Attributes)
+				}
+			}
+			for _, lr := range sl.GetLogRecords() {
+				for i := range p.actions {
+					if a := &p.actions[i]; a.scope == scopeLog {
+						lr.Attributes = a.apply(lr.Attributes)
+					}
+				}
+			}
+		}
+	}
+	return logs, nil
+}
+
+func (a *attrAction) apply(kvs []*commonv1.KeyValue) []*commonv1.KeyValue {
+	switch a.op {
+	case opInsert:
+		if otel.FindAttribute(kvs, a.key) == nil {
+			kvs = append(kvs, otel.NewKeyValue(a.key, coerce(a.kind, a.value)))
+		}
+	case opUpdate:
+		if otel.FindAttribute(kvs, a.key) != nil {
+			kvs = otel.SetAttribute(kvs, a.key, coerce(a.kind, a.value))
+		}
+	case opUpsert:
+		kvs = otel.SetAttribute(kvs, a.key, coerce(a.kind, a.value))
+	case opRename:
+		kvs, _ = otel.RenameAttribute(kvs, a.key, a.newKey)
+	case opDelete:
+		kvs = otel.DeleteAttribute(kvs, a.key)
+	case opHash:
+		if kv := otel.FindAttribute(kvs, a.key); kv != nil {
+			if s, ok := scalarString(kv.Value); ok {
+				kvs = otel.SetAttribute(kvs, a.key, otel.NewStringValue(a.hash(s)))
+			}
+		}
+	case opTruncate:
+		if kv := otel.FindAttribute(kvs, a.key); kv != nil {
+			if sv, ok := kv.Value.GetValue().(*commonv1.AnyValue_StringValue); ok {
+				sv.StringValue = truncateUTF8(sv.StringValue, a.maxLen)
+			}
+		}
+	}
+	return kvs
+}
+
+func (a *attrAction) hash(s string) string {
+	var h hash.Hash
+	if a.hashKey != nil {
+		h = hmac.New(sha256.New, a.hashKey)
+	} else {
+		h = sha256.New()
+	}
+	h.Write([]byte(s))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,93 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sungp/gophership/internal/config"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestAttributeProcessor(t *testing.T) {
+	p, err := NewAttributeProcessor("normalize", []config.AttributeAction{
+		{Key: "svc", Action: "rename", NewKey: "service.name", Scope: "resource"},
+		{Key: "deployment.environment", Action: "insert", Value: "prod", Scope: "resource"},
+		{Key: "team", Action: "upsert", Value: "payments", Scope: "scope"},
+		{Key: "http.status", Action: "update", Value: "200", Type: "int"},
+		{Key: "retries", Action: "insert", Value: "3", Type: "int"},
+		{Key: "internal.debug", Action: "delete"},
+		{Key: "user.id", Action: "hash"},
+		{Key: "query", Action: "truncate", MaxLength: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lr := stringRecord("req")
+	lr.Attributes = []*commonv1.KeyValue{
+		{Key: "http.status", Value: stringValue("ok")},
+		{Key: "retries", Value: stringValue("keep")},
+		{Key: "internal.debug", Value: stringValue("x")},
+		{Key: "user.id", Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: 42}}},
+		{Key: "query", Value: stringValue("héllo world")},
+	}
+	logs := testLogs(lr)
+	logs[0].Resource.Attributes = []*commonv1.KeyValue{
+		{Key: "svc", Value: stringValue("checkout")},
+		{Key: "deployment.environment", Value: stringValue("staging")},
+	}
+
+	if _, err := p.Process(context.Background(), logs); err != nil {
+		t.Fatal(err)
+	}
+
+	res := &logsv1.LogRecord{Attributes: logs[0].Resource.Attributes}
+	if got := attr(res, "service.name").GetStringValue(); got != "checkout" || attr(res, "svc") != nil {
+		t.Errorf("resource rename: service.name=%q, svc=%v", got, attr(res, "svc"))
+	}
+	if got := attr(res, "deployment.environment").GetStringValue(); got != "staging" {
+		t.Errorf("insert overwrote existing value: %q", got)
+	}
+	scope := &logsv1.LogRecord{Attributes: logs[0].ScopeLogs[0].GetScope().GetAttributes()}
+	if got := attr(scope, "team").GetStringValue(); got != "payments" {
+		t.Errorf("scope upsert: team=%q", got)
+	}
+
+	if got := attr(lr, "http.status").GetIntValue(); got != 200 {
+		t.Errorf("update: http.status=%v", attr(lr, "http.status"))
+	}
+	if got := attr(lr, "retries").GetStringValue(); got != "keep" {
+		t.Errorf("insert overwrote existing value: %q", got)
+	}
+	if attr(lr, "internal.debug") != nil {
+		t.Error("delete kept attribute")
+	}
+	// sha256("42")
+	if got := attr(lr, "user.id").GetStringValue(); got != "73475cb40a568e8da8a045ced110137e159f890ac4da883b6b17dc651b3a8049" {
+		t.Errorf("hash: user.id=%q", got)
+	}
+	if got := attr(lr, "query").GetStringValue(); got != "h" {
+		t.Errorf("truncate split a rune or kept too much: %q", got)
+	}
+	if len(lr.Attributes) != 4 {
+		t.Errorf("got %d record attributes, want 4", len(lr.Attributes))
+	}
+}
+
+func TestNewAttributeProcessor_Validation(t *testing.T) {
+	tests := [][]config.AttributeAction{
+		nil,
+		{{Key: "a", Action: "explode"}},
+		{{Action: "delete"}},
+		{{Key: "a", Action: "rename"}},
+		{{Key: "a", Action: "truncate"}},
+		{{Key: "a", Action: "upsert", Type: "uuid"}},
+		{{Key: "a", Action: "delete", Scope: "span"}},
+	}
+	for _, cfgs := range tests {
+		if _, err := NewAttributeProcessor("a", cfgs); err == nil {
+			t.Errorf("NewAttributeProcessor(%+v) succeeded, want error", cfgs)
+		}
+	}
+}
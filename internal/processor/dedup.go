@@ -0,0 +1,246 @@
+package processor
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	"github.com/sungp/gophership/pkg/otel"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	DefaultDedupWindow     = 10 * time.Second
+	DefaultDedupMaxEntries = 10000
+
+	// dedupEntryOverhead approximates the per-entry bookkeeping (list element,
+	// map slot, entry struct) on top of the cloned protobufs.
+	dedupEntryOverhead = 160
+)
+
+// Attributes set on collapsed records.
+const (
+	RepeatCountAttr    = "repeat_count"
+	FirstTimestampAttr = "first_timestamp"
+	LastTimestampAttr  = "last_timestamp"
+)
+
+// dedupEntry tracks one distinct record within its window.
+type dedupEntry struct {
+	key      uint64
+	started  time.Time // Window start (processing time)
+	repeats  int64     // Occurrences suppressed since the first one
+	first    uint64    // Record timestamps of the suppressed occurrences
+	last     uint64
+	resource *resourcev1.Resource
+	scope    *commonv1.InstrumentationScope
+	record   *logsv1.LogRecord
+	size     int64
+}
+
+// Dedup collapses identical records. The first occurrence passes through
+// immediately; repeats within the window are suppressed and later emitted as
+// one record carrying repeat_count and first/last timestamps. Tracked records
+// live in an LRU bounded by MaxEntries, and its memory is reported to the
+// SensingMonitor processor budget.
+type Dedup struct {
+	name       string
+	keys       []string
+	window     time.Duration
+	maxEntries int
+
+	lru     *list.List // Front is least recently seen
+	entries map[uint64]*list.Element
+	usage   int64
+	rt      *stochastic.Runtime // Whose monitor is told of usage
+	metrics *metrics
+	now     func() time.Time
+}
+
+// NewDedup creates a repeat-collapsing processor reporting its memory to rt's
+// monitor.
+func NewDedup(rt *stochastic.Runtime, name string, cfg config.DedupConfig) (*Dedup, error) {
+	if cfg.Window < 0 || cfg.MaxEntries < 0 {
+		return nil, fmt.Errorf("window and max_entries must not be negative")
+	}
+	d := &Dedup{
+		name:       name,
+		keys:       cfg.Keys,
+		window:     cfg.Window,
+		maxEntries: cfg.MaxEntries,
+		lru:        list.New(),
+		entries:    make(map[uint64]*list.Element),
+		rt:         rt,
+		metrics:    metricsFor(rt),
+		now:        time.Now,
+	}
+	if d.window == 0 {
+		d.window = DefaultDedupWindow
+	}
+	if d.maxEntries == 0 {
+		d.maxEntries = DefaultDedupMaxEntries
+	}
+	return d, nil
+}
+
+func (d *Dedup) Name() string { return d.name }
+
+// Process drops repeats and appends collapsed records for windows that closed.
+func (d *Dedup) Process(_ context.Context, logs []*logsv1.ResourceLogs) ([]*logsv1.ResourceLogs, error) {
+	now := d.now()
+	var summaries []*logsv1.ResourceLogs
+
+	keptRL := logs[:0]
+	for _, rl := range logs {
+		keptSL := rl.ScopeLogs[:0]
+		for _, sl := range rl.ScopeLogs {
+			kept := sl.LogRecords[:0]
+			for _, lr := range sl.LogRecords {
+				key := d.identity(rl.GetResource(), lr)
+				if el, ok := d.entries[key]; ok {
+					e := el.Value.(*dedupEntry)
+					if now.Sub(e.started) < d.window {
+						ts := recordTime(lr, now)
+						if e.repeats == 0 {
+							e.first = ts
+						}
+						e.last = ts
+						e.repeats++
+						d.lru.MoveToBack(el)
+						d.metrics.dedupSuppressedTotal.WithLabelValues(d.name).Inc()
+						continue
+					}
+					// Window closed: report it and start a new one with this record.
+					summaries = d.appendSummary(summaries, e)
+					d.remove(el)
+				}
+				d.track(key, now, rl.GetResource(), sl.GetScope(), lr)
+				kept = append(kept, lr)
+			}
+			clear(sl.LogRecords[len(kept):])
+			sl.LogRecords = kept
+			if len(kept) > 0 {
+				keptSL = append(keptSL, sl)
+			}
+		}
+		clear(rl.ScopeLogs[len(keptSL):])
+		rl.ScopeLogs = keptSL
+		if len(keptSL) > 0 {
+			keptRL = append(keptRL, rl)
+		}
+	}
+	clear(logs[len(keptRL):])
+
+	for d.lru.Len() > d.maxEntries {
+		front := d.lru.Front()
+		summaries = d.appendSummary(summaries, front.Value.(*dedupEntry))
+		d.remove(front)
+	}
+	return append(keptRL, summaries...), nil
+}
+
+// Flush emits collapsed records for expired windows, or for every tracked
+// record when final is set.
+func (d *Dedup) Flush(_ context.Context, final bool) []*logsv1.ResourceLogs {
+	now := d.now()
+	var out []*logsv1.ResourceLogs
+	for el := d.lru.Front(); el != nil; {
+		next := el.Next()
+		if e := el.Value.(*dedupEntry); final || now.Sub(e.started) >= d.window {
+			out = d.appendSummary(out, e)
+			d.remove(el)
+		}
+		el = next
+	}
+	return out
+}
+
+func (d *Dedup) track(key uint64, now time.Time, res *resourcev1.Resource, scope *commonv1.InstrumentationScope, lr *logsv1.LogRecord) {
+	e := &dedupEntry{key: key, started: now, record: proto.Clone(lr).(*logsv1.LogRecord)}
+	if res != nil {
+		e.resource = proto.Clone(res).(*resourcev1.Resource)
+	}
+	if scope != nil {
+		e.scope = proto.Clone(scope).(*commonv1.InstrumentationScope)
+	}
+	e.size = int64(proto.Size(e.record)+proto.Size(e.resource)+proto.Size(e.scope)) + dedupEntryOverhead
+	d.entries[key] = d.lru.PushBack(e)
+	d.account(e.size)
+}
+
+func (d *Dedup) remove(el *list.Element) {
+	e := d.lru.Remove(el).(*dedupEntry)
+	delete(d.entries, e.key)
+	d.account(-e.size)
+}
+
+func (d *Dedup) account(delta int64) {
+	d.usage += delta
+	if m := d.rt.Monitor(); m != nil {
+		m.ReportProcessorUsage(delta)
+	}
+}
+
+// appendSummary adds the collapsed record for e, if anything was suppressed.
+func (d *Dedup) appendSummary(out []*logsv1.ResourceLogs, e *dedupEntry) []*logsv1.ResourceLogs {
+	if e.repeats == 0 {
+		return out
+	}
+	lr := e.record
+	lr.TimeUnixNano = e.last
+	lr.Attributes = otel.SetAttribute(lr.Attributes, RepeatCountAttr, otel.NewIntValue(e.repeats))
+	lr.Attributes = otel.SetAttribute(lr.Attributes, FirstTimestampAttr, otel.NewStringValue(formatNanos(e.first)))
+	lr.Attributes = otel.SetAttribute(lr.Attributes, LastTimestampAttr, otel.NewStringValue(formatNanos(e.last)))
+	return append(out, &logsv1.ResourceLogs{
+		Resource:  e.resource,
+		ScopeLogs: []*logsv1.ScopeLogs{{Scope: e.scope, LogRecords: []*logsv1.LogRecord{lr}}},
+	})
+}
+
+// identity hashes the body, severity and configured keys of a record.
+func (d *Dedup) identity(res *resourcev1.Resource, lr *logsv1.LogRecord) uint64 {
+	h := fnv.New64a()
+	sep := []byte{0}
+	writeValue := func(v *commonv1.AnyValue) {
+		if s, ok := scalarString(v); ok {
+			h.Write([]byte(s))
+		} else if v != nil {
+			b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(v)
+			h.Write(b)
+		}
+		h.Write(sep)
+	}
+
+	writeValue(lr.GetBody())
+	h.Write([]byte{byte(otel.RecordSeverity(lr)), 0})
+	for _, k := range d.keys {
+		kv := otel.FindAttribute(lr.GetAttributes(), k)
+		if kv == nil {
+			kv = otel.FindAttribute(res.GetAttributes(), k)
+		}
+		writeValue(kv.GetValue())
+	}
+	return h.Sum64()
+}
+
+// recordTime prefers the event time, then the observed time, then now.
+func recordTime(lr *logsv1.LogRecord, now time.Time) uint64 {
+	if ts := lr.GetTimeUnixNano(); ts != 0 {
+		return ts
+	}
+	if ts := lr.GetObservedTimeUnixNano(); ts != 0 {
+		return ts
+	}
+	return uint64(now.UnixNano())
+}
+
+func formatNanos(ns uint64) string {
+	return time.Unix(0, int64(ns)).UTC().Format(time.RFC3339Nano)
+}
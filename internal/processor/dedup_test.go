@@ -0,0 +1,135 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// records flattens a batch.
+func records(logs []*logsv1.ResourceLogs) []*logsv1.LogRecord {
+	var out []*logsv1.LogRecord
+	for _, rl := range logs {
+		for _, sl := range rl.ScopeLogs {
+			out = append(out, sl.LogRecords...)
+		}
+	}
+	return out
+}
+
+func newTestDedup(t *testing.T, cfg config.DedupConfig) (*Dedup, *time.Time) {
+	t.Helper()
+	d, err := NewDedup(stochastic.Default, "dedup", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	d.now = func() time.Time { return now }
+	return d, &now
+}
+
+func timedRecord(body string, sec int64) *logsv1.LogRecord {
+	lr := stringRecord(body)
+	lr.TimeUnixNano = uint64(time.Unix(sec, 0).UnixNano())
+	return lr
+}
+
+func TestDedup_CollapsesRepeatsWithinWindow(t *testing.T) {
+	d, now := newTestDedup(t, config.DedupConfig{Window: 10 * time.Second, Keys: []string{"service.name"}})
+	ctx := context.Background()
+
+	batch := testLogs(timedRecord("db timeout", 1), timedRecord("db timeout", 2), timedRecord("other", 2), timedRecord("db timeout", 3))
+	batch[0].Resource.Attributes = []*commonv1.KeyValue{{Key: "service.name", Value: stringValue("checkout")}}
+	out, _ := d.Process(ctx, batch)
+	if got := records(out); len(got) != 2 || got[0].Body.GetStringValue() != "db timeout" || got[1].Body.GetStringValue() != "other" {
+		t.Fatalf("first pass kept %v", got)
+	}
+
+	// A different key attribute is a different record.
+	other := testLogs(timedRecord("db timeout", 4))
+	other[0].Resource.Attributes = []*commonv1.KeyValue{{Key: "service.name", Value: stringValue("billing")}}
+	if out, _ := d.Process(ctx, other); len(records(out)) != 1 {
+		t.Error("record with different key attributes was collapsed")
+	}
+
+	if out := d.Flush(ctx, false); len(out) != 0 {
+		t.Fatalf("flushed %d batches before the window closed", len(out))
+	}
+	*now = now.Add(10 * time.Second)
+	out = d.Flush(ctx, false)
+	got := records(out)
+	if len(got) != 1 {
+		t.Fatalf("flushed %d records, want 1 summary", len(got))
+	}
+	s := got[0]
+	if attr(s, RepeatCountAttr).GetIntValue() != 2 {
+		t.Errorf("repeat_count = %v, want 2", attr(s, RepeatCountAttr))
+	}
+	if f, l := attr(s, FirstTimestampAttr).GetStringValue(), attr(s, LastTimestampAttr).GetStringValue(); f != "1970-01-01T00:00:02Z" || l != "1970-01-01T00:00:03Z" {
+		t.Errorf("first/last = %s/%s", f, l)
+	}
+	if got := out[0].Resource.Attributes[0].Value.GetStringValue(); got != "checkout" {
+		t.Errorf("summary lost its resource: %q", got)
+	}
+	if d.lru.Len() != 0 || d.usage != 0 {
+		t.Errorf("state not released: %d entries, %d bytes", d.lru.Len(), d.usage)
+	}
+
+	// After the window a repeat passes through again.
+	if out, _ := d.Process(ctx, testLogs(timedRecord("other", 20))); len(records(out)) != 1 {
+		t.Error("record after window closed was suppressed")
+	}
+}
+
+func TestDedup_LRUBoundAndFinalFlush(t *testing.T) {
+	d, _ := newTestDedup(t, config.DedupConfig{MaxEntries: 2})
+	ctx := context.Background()
+
+	d.Process(ctx, testLogs(stringRecord("a"), stringRecord("a"), stringRecord("b")))
+	if d.usage <= 0 {
+		t.Fatal("tracked records not accounted")
+	}
+	// "c" evicts "a", the least recently seen, whose summary is emitted inline.
+	out, _ := d.Process(ctx, testLogs(stringRecord("b"), stringRecord("c")))
+	got := records(out)
+	if len(got) != 2 || got[0].Body.GetStringValue() != "c" || attr(got[1], RepeatCountAttr).GetIntValue() != 1 {
+		t.Fatalf("eviction output = %v", got)
+	}
+	if d.lru.Len() != 2 {
+		t.Errorf("LRU holds %d entries, want 2", d.lru.Len())
+	}
+
+	got = records(d.Flush(ctx, true))
+	if len(got) != 1 || got[0].Body.GetStringValue() != "b" {
+		t.Errorf("final flush = %v, want summary of b", got)
+	}
+	if d.usage != 0 {
+		t.Errorf("usage after final flush = %d", d.usage)
+	}
+}
+
+func TestPipeline_FlushesOnShutdown(t *testing.T) {
+	d, _ := newTestDedup(t, config.DedupConfig{})
+	chain := NewChain(stochastic.Default)
+	chain.Add(d, stochastic.StatusRed)
+	sink := &sinkExporter{}
+	p := NewPipeline(chain, sink)
+
+	ctx := context.Background()
+	p.Export(ctx, testLogs(stringRecord("x"), stringRecord("x"), stringRecord("x")))
+	if err := p.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var got []*logsv1.LogRecord
+	for _, b := range sink.batches {
+		got = append(got, records(b)...)
+	}
+	if len(got) != 2 || attr(got[1], RepeatCountAttr).GetIntValue() != 2 {
+		t.Errorf("sink received %v", got)
+	}
+}
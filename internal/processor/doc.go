@@ -0,0 +1,5 @@
+// Package processor transforms OTLP log batches between the ingester worker
+// loop and the exporters. Processors run on the deferred path and are gated by
+// the somatic zone: opportunistic work is skipped under pressure, while
+// mandatory stages (such as redaction) always run.
+package processor
@@ -0,0 +1,234 @@
+package processor
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/pkg/otel"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+// DefaultPodInfoDir is where the downward-API volume is usually mounted.
+const DefaultPodInfoDir = "/etc/podinfo"
+
+// metadataSource abstracts the host so detection can be tested.
+type metadataSource struct {
+	hostname      func() (string, error)
+	getenv        func(string) string
+	cgroupPath    string
+	mountinfoPath string
+	saNamespace   string // Service account namespace file
+}
+
+var hostSource = metadataSource{
+	hostname:      os.Hostname,
+	getenv:        os.Getenv,
+	cgroupPath:    "/proc/self/cgroup",
+	mountinfoPath: "/proc/self/mountinfo",
+	saNamespace:   "/var/run/secrets/kubernetes.io/serviceaccount/namespace",
+}
+
+// k8sEnv lists the downward-API environment variables checked per attribute,
+// in order of preference.
+var k8sEnv = []struct {
+	attr string
+	vars []string
+}{
+	{"k8s.pod.name", []string{"K8S_POD_NAME", "POD_NAME"}},
+	{"k8s.pod.uid", []string{"K8S_POD_UID", "POD_UID"}},
+	{"k8s.namespace.name", []string{"K8S_NAMESPACE_NAME", "K8S_NAMESPACE", "POD_NAMESPACE"}},
+	{"k8s.node.name", []string{"K8S_NODE_NAME", "NODE_NAME"}},
+}
+
+// Enricher adds host, container and Kubernetes attributes to every resource.
+// Metadata is detected once at construction; per batch, only the missing
+// attributes are appended from the pools, so the cost is per resource rather
+// than per record.
+type Enricher struct {
+	name     string
+	attrs    []enrichAttr
+	override bool
+}
+
+type enrichAttr struct {
+	key, value string
+}
+
+// NewEnricher detects metadata for the enabled detectors.
+func NewEnricher(name string, cfg config.EnrichConfig) (*Enricher, error) {
+	return newEnricher(name, cfg, hostSource)
+}
+
+func newEnricher(name string, cfg config.EnrichConfig, src metadataSource) (*Enricher, error) {
+	enabled := map[string]bool{"host": true, "container": true, "k8s": true}
+	if len(cfg.Detectors) > 0 {
+		enabled = make(map[string]bool, len(cfg.Detectors))
+		for _, d := range cfg.Detectors {
+			switch d {
+			case "host", "container", "k8s":
+				enabled[d] = true
+			default:
+				return nil, fmt.Errorf("unknown detector %q", d)
+			}
+		}
+	}
+	podInfo := cfg.PodInfoDir
+	if podInfo == "" {
+		podInfo = DefaultPodInfoDir
+	}
+
+	e := &Enricher{name: name, override: cfg.Override}
+	add := func(k, v string) {
+		if v != "" {
+			e.attrs = append(e.attrs, enrichAttr{k, v})
+		}
+	}
+	if enabled["host"] {
+		if h, err := src.hostname(); err == nil {
+			add("host.name", h)
+		}
+		add("os.type", runtime.GOOS)
+		add("host.arch", runtime.GOARCH)
+	}
+	if enabled["container"] {
+		id := containerIDFromFile(src.cgroupPath, false)
+		if id == "" {
+			id = containerIDFromFile(src.mountinfoPath, true)
+		}
+		add("container.id", id)
+	}
+	if enabled["k8s"] {
+		e.detectK8s(src, podInfo, add)
+	}
+	return e, nil
+}
+
+func (e *Enricher) detectK8s(src metadataSource, podInfo string, add func(k, v string)) {
+	files := map[string]string{
+		"k8s.pod.name":       readTrimmed(filepath.Join(podInfo, "name")),
+		"k8s.pod.uid":        readTrimmed(filepath.Join(podInfo, "uid")),
+		"k8s.namespace.name": readTrimmed(filepath.Join(podInfo, "namespace")),
+	}
+	if files["k8s.namespace.name"] == "" {
+		files["k8s.namespace.name"] = readTrimmed(src.saNamespace)
+	}
+	for _, m := range k8sEnv {
+		v := ""
+		for _, name := range m.vars {
+			if v = src.getenv(name); v != "" {
+				break
+			}
+		}
+		if v == "" {
+			v = files[m.attr]
+		}
+		add(m.attr, v)
+	}
+
+	// Downward-API labels are one key="value" per line.
+	data, err := os.ReadFile(filepath.Join(podInfo, "labels"))
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		if uq, err := strconv.Unquote(v); err == nil {
+			v = uq
+		}
+		add("k8s.pod.label."+k, v)
+	}
+}
+
+func (e *Enricher) Name() string { return e.name }
+
+// Process merges the detected attributes into each resource. Values are
+// pooled copies so later processors may modify or release them freely.
+func (e *Enricher) Process(_ context.Context, logs []*logsv1.ResourceLogs) ([]*logsv1.ResourceLogs, error) {
+	if len(e.attrs) == 0 {
+		return logs, nil
+	}
+	for _, rl := range logs {
+		if rl.Resource == nil {
+			rl.Resource = &resourcev1.Resource{}
+		}
+		res := rl.Resource
+		for _, a := range e.attrs {
+			if otel.FindAttribute(res.Attributes, a.key) == nil {
+				res.Attributes = append(res.Attributes, otel.NewKeyValue(a.key, otel.NewStringValue(a.value)))
+			} else if e.override {
+				res.Attributes = otel.SetAttribute(res.Attributes, a.key, otel.NewStringValue(a.value))
+			}
+		}
+	}
+	return logs, nil
+}
+
+func readTrimmed(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func containerIDFromFile(path string, mountinfo bool) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	return containerID(f, mountinfo)
+}
+
+// containerID finds a 64-hex-digit container ID in /proc/self/cgroup (v1, or
+// v2 with a non-root cgroup) or, with mountinfo set, in the container runtime
+// mounts of /proc/self/mountinfo, which is the only place it appears under
+// cgroup v2 namespaces.
+func containerID(r io.Reader, mountinfo bool) string {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 4096), 64*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if mountinfo && !strings.Contains(line, "/containers/") && !strings.Contains(line, "/sandboxes/") {
+			continue
+		}
+		if id := findHexID(line); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// findHexID returns the first run of exactly 64 hex digits in s.
+func findHexID(s string) string {
+	start := -1
+	for i := 0; i <= len(s); i++ {
+		if i < len(s) && isHexDigit(s[i]) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-start == 64 {
+			return s[start:i]
+		}
+		start = -1
+	}
+	return ""
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,118 @@
+package processor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sungp/gophership/internal/config"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+const testContainerID = "3f4e5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f"
+
+func TestContainerID(t *testing.T) {
+	tests := []struct {
+		name, in  string
+		mountinfo bool
+		want      string
+	}{
+		{"cgroup v1 docker", "12:pids:/docker/" + testContainerID + "\n", false, testContainerID},
+		{"cgroup v2 containerd", "0::/kubepods.slice/kubepods-pod1.slice/cri-containerd-" + testContainerID + ".scope\n", false, testContainerID},
+		{"cgroup v2 namespaced", "0::/\n", false, ""},
+		{"mountinfo", "1 2 0:1 /var/lib/docker/containers/" + testContainerID + "/hostname /etc/hostname rw - ext4 /dev/sda1 rw\n", true, testContainerID},
+		{"mountinfo unrelated hash", "1 2 0:1 /" + testContainerID + " /data rw - ext4 /dev/sda1 rw\n", true, ""},
+		{"too long", "0::/docker/" + testContainerID + "ab\n", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containerID(strings.NewReader(tt.in), tt.mountinfo); got != tt.want {
+				t.Errorf("containerID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnricher(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	podInfo := filepath.Join(dir, "podinfo")
+	if err := os.Mkdir(podInfo, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write("podinfo/name", "checkout-7d9f\n")
+	write("podinfo/namespace", "shop\n")
+	write("podinfo/labels", "app=\"checkout\"\npod-template-hash=\"7d9f\"\n")
+
+	env := map[string]string{"NODE_NAME": "node-a", "K8S_POD_NAME": "from-env"}
+	src := metadataSource{
+		hostname:      func() (string, error) { return "host-1", nil },
+		getenv:        func(k string) string { return env[k] },
+		cgroupPath:    write("cgroup", "0::/\n"),
+		mountinfoPath: write("mountinfo", "1 2 0:1 /containers/"+testContainerID+"/hostname /etc/hostname rw\n"),
+		saNamespace:   filepath.Join(dir, "missing"),
+	}
+
+	e, err := newEnricher("meta", config.EnrichConfig{PodInfoDir: podInfo}, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := testLogs(stringRecord("a"), stringRecord("b"))
+	logs[0].Resource.Attributes = []*commonv1.KeyValue{{Key: "host.name", Value: stringValue("sender-set")}}
+	logs = append(logs, testLogs(stringRecord("c"))...)
+	logs[1].Resource = nil
+	if _, err := e.Process(context.Background(), logs); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"host.name":          "sender-set", // Not overridden by default
+		"os.type":            runtime.GOOS,
+		"host.arch":          runtime.GOARCH,
+		"container.id":       testContainerID,
+		"k8s.pod.name":       "from-env", // Env takes precedence over files
+		"k8s.namespace.name": "shop",
+		"k8s.node.name":      "node-a",
+		"k8s.pod.label.app":  "checkout",
+	}
+	res := &logsv1.LogRecord{Attributes: logs[0].Resource.Attributes}
+	for k, v := range want {
+		if got := attr(res, k).GetStringValue(); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+	if attr(res, "k8s.pod.uid") != nil {
+		t.Error("empty k8s.pod.uid was added")
+	}
+	if got := (&logsv1.LogRecord{Attributes: logs[1].Resource.GetAttributes()}); attr(got, "host.name").GetStringValue() != "host-1" {
+		t.Errorf("nil resource not enriched: %v", logs[1].Resource)
+	}
+	// Each resource gets its own values so later processors can modify them.
+	if attr(res, "os.type") == logs[1].Resource.Attributes[1].Value {
+		t.Error("attribute values shared between resources")
+	}
+
+	e, _ = newEnricher("meta", config.EnrichConfig{Detectors: []string{"host"}, Override: true}, src)
+	logs = testLogs(stringRecord("a"))
+	logs[0].Resource.Attributes = []*commonv1.KeyValue{{Key: "host.name", Value: stringValue("sender-set")}}
+	e.Process(context.Background(), logs)
+	res = &logsv1.LogRecord{Attributes: logs[0].Resource.Attributes}
+	if got := attr(res, "host.name").GetStringValue(); got != "host-1" || attr(res, "container.id") != nil {
+		t.Errorf("override/detectors not honoured: %v", logs[0].Resource.Attributes)
+	}
+
+	if _, err := newEnricher("meta", config.EnrichConfig{Detectors: []string{"gcp"}}, src); err == nil {
+		t.Error("unknown detector accepted")
+	}
+}
@@ -0,0 +1,391 @@
+package processor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sungp/gophership/pkg/otel"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+// The filter expression language, compiled once into a predicate tree:
+//
+//	expr       = or
+//	or         = and { ("or" | "||") and }
+//	and        = unary { ("and" | "&&") unary }
+//	unary      = ("not" | "!") unary | "(" expr ")" | "exists" "(" field ")" | comparison
+//	comparison = field op literal
+//	field      = "severity" | "severity_text" | "body" | "scope.name"
+//	           | ("attributes" | "attr" | "resource") "[" string "]"
+//	op         = "==" | "!=" | "<" | "<=" | ">" | ">=" | "=~" | "!~" | "contains"
+//	literal    = string | number | identifier
+//
+// severity compares by OTel severity number and accepts names (ERROR) or
+// numbers. Ordering operators on other fields compare numerically. A missing
+// attribute compares as the empty string and never satisfies a numeric
+// comparison.
+
+// evalCtx is the record being evaluated; it is reused across records.
+type evalCtx struct {
+	resource *resourcev1.Resource
+	scope    *commonv1.InstrumentationScope
+	lr       *logsv1.LogRecord
+}
+
+type predicate func(*evalCtx) bool
+
+// compileExpr parses src into a predicate.
+func compileExpr(src string) (predicate, error) {
+	toks, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks}
+	pred, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+	}
+	return pred, nil
+}
+
+type tokKind uint8
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokPunct
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+func lexExpr(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(src) && src[j] != c {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			raw := src[i : j+1]
+			if c == '\'' {
+				raw = `"` + strings.ReplaceAll(raw[1:len(raw)-1], `"`, `\"`) + `"`
+			}
+			s, err := strconv.Unquote(raw)
+			if err != nil {
+				return nil, fmt.Errorf("bad string at offset %d: %w", i, err)
+			}
+			toks = append(toks, token{tokString, s, i})
+			i = j + 1
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			j := i + 1
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.' || src[j] == 'e' || src[j] == 'E') {
+				j++
+			}
+			toks = append(toks, token{tokNumber, src[i:j], i})
+			i = j
+		case isWordByte(c):
+			j := i
+			for j < len(src) && (isWordByte(src[j]) || src[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{tokIdent, src[i:j], i})
+			i = j
+		case strings.ContainsRune("()[]", rune(c)):
+			toks = append(toks, token{tokPunct, src[i : i+1], i})
+			i++
+		default:
+			if i+1 < len(src) {
+				switch two := src[i : i+2]; two {
+				case "==", "!=", "<=", ">=", "=~", "!~", "&&", "||":
+					toks = append(toks, token{tokOp, two, i})
+					i += 2
+					continue
+				}
+			}
+			if c == '<' || c == '>' || c == '!' {
+				toks = append(toks, token{tokOp, src[i : i+1], i})
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("unexpected character %q at offset %d", c, i)
+		}
+	}
+	return append(toks, token{tokEOF, "end of expression", len(src)}), nil
+}
+
+type exprParser struct {
+	toks []token
+	i    int
+}
+
+func (p *exprParser) peek() token { return p.toks[p.i] }
+
+func (p *exprParser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *exprParser) accept(texts ...string) bool {
+	t := p.peek()
+	if t.kind == tokString || t.kind == tokNumber {
+		return false
+	}
+	for _, s := range texts {
+		if t.text == s {
+			p.i++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *exprParser) expect(text string) error {
+	if !p.accept(text) {
+		t := p.peek()
+		return fmt.Errorf("expected %q, got %q at offset %d", text, t.text, t.pos)
+	}
+	return nil
+}
+
+func (p *exprParser) parseOr() (predicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("or", "||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(c *evalCtx) bool { return l(c) || right(c) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (predicate, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("and", "&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(c *evalCtx) bool { return l(c) && right(c) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (predicate, error) {
+	switch {
+	case p.accept("not", "!"):
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(c *evalCtx) bool { return !inner(c) }, nil
+	case p.accept("("):
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	case p.accept("true"):
+		return func(*evalCtx) bool { return true }, nil
+	case p.accept("false"):
+		return func(*evalCtx) bool { return false }, nil
+	case p.accept("exists"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		f, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		if f.attrs == nil {
+			return nil, fmt.Errorf("exists() takes an attributes[...] or resource[...] field")
+		}
+		return func(c *evalCtx) bool { return otel.FindAttribute(f.attrs(c), f.key) != nil }, p.expect(")")
+	}
+	return p.parseComparison()
+}
+
+// field reads a value from the record under evaluation.
+type field struct {
+	name  string
+	key   string
+	attrs func(*evalCtx) []*commonv1.KeyValue // Set for keyed fields
+	value func(*evalCtx) *commonv1.AnyValue   // Set for body
+	str   func(*evalCtx) string               // Set for severity_text and scope.name
+}
+
+func (p *exprParser) parseField() (*field, error) {
+	t := p.next()
+	if t.kind != tokIdent {
+		return nil, fmt.Errorf("expected a field, got %q at offset %d", t.text, t.pos)
+	}
+	f := &field{name: t.text}
+	switch t.text {
+	case "severity":
+	case "severity_text":
+		f.str = func(c *evalCtx) string { return c.lr.GetSeverityText() }
+	case "body":
+		f.value = func(c *evalCtx) *commonv1.AnyValue { return c.lr.GetBody() }
+	case "scope.name":
+		f.str = func(c *evalCtx) string { return c.scope.GetName() }
+	case "attributes", "attr", "resource":
+		if err := p.expect("["); err != nil {
+			return nil, err
+		}
+		k := p.next()
+		if k.kind != tokString {
+			return nil, fmt.Errorf("expected a quoted key, got %q at offset %d", k.text, k.pos)
+		}
+		f.key = k.text
+		if t.text == "resource" {
+			f.attrs = func(c *evalCtx) []*commonv1.KeyValue { return c.resource.GetAttributes() }
+		} else {
+			f.attrs = func(c *evalCtx) []*commonv1.KeyValue { return c.lr.GetAttributes() }
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown field %q at offset %d", t.text, t.pos)
+	}
+	return f, nil
+}
+
+// lookup returns the field's value, or nil when it is absent.
+func (f *field) lookup(c *evalCtx) *commonv1.AnyValue {
+	if f.attrs != nil {
+		if kv := otel.FindAttribute(f.attrs(c), f.key); kv != nil {
+			return kv.Value
+		}
+		return nil
+	}
+	return f.value(c)
+}
+
+func (f *field) text(c *evalCtx) string {
+	if f.str != nil {
+		return f.str(c)
+	}
+	s, _ := scalarString(f.lookup(c))
+	return s
+}
+
+func (f *field) number(c *evalCtx) (float64, bool) {
+	return numberValue(f.lookup(c))
+}
+
+func (p *exprParser) parseComparison() (predicate, error) {
+	f, err := p.parseField()
+	if err != nil {
+		return nil, err
+	}
+	opTok := p.next()
+	op := opTok.text
+	switch {
+	case opTok.kind == tokOp && op != "&&" && op != "||" && op != "!",
+		opTok.kind == tokIdent && op == "contains":
+	default:
+		return nil, fmt.Errorf("expected a comparison operator after %s, got %q at offset %d", f.name, op, opTok.pos)
+	}
+	lit := p.next()
+	if lit.kind != tokString && lit.kind != tokNumber && lit.kind != tokIdent {
+		return nil, fmt.Errorf("expected a value after %s, got %q at offset %d", op, lit.text, lit.pos)
+	}
+
+	if f.name == "severity" {
+		return compileSeverity(op, lit)
+	}
+
+	switch op {
+	case "==":
+		return func(c *evalCtx) bool { return f.text(c) == lit.text }, nil
+	case "!=":
+		return func(c *evalCtx) bool { return f.text(c) != lit.text }, nil
+	case "contains":
+		return func(c *evalCtx) bool { return strings.Contains(f.text(c), lit.text) }, nil
+	case "=~", "!~":
+		re, err := regexp.Compile(lit.text)
+		if err != nil {
+			return nil, fmt.Errorf("offset %d: %w", lit.pos, err)
+		}
+		want := op == "=~"
+		return func(c *evalCtx) bool { return re.MatchString(f.text(c)) == want }, nil
+	}
+
+	if f.str != nil {
+		return nil, fmt.Errorf("%s is text and does not support %s", f.name, op)
+	}
+	n, err := strconv.ParseFloat(lit.text, 64)
+	if lit.kind != tokNumber || err != nil {
+		return nil, fmt.Errorf("%s needs a number, got %q at offset %d", op, lit.text, lit.pos)
+	}
+	cmp := numericCompare(op)
+	return func(c *evalCtx) bool {
+		v, ok := f.number(c)
+		return ok && cmp(v, n)
+	}, nil
+}
+
+func compileSeverity(op string, lit token) (predicate, error) {
+	sev, err := otel.ParseSeverity(lit.text)
+	if err != nil {
+		return nil, fmt.Errorf("offset %d: %w", lit.pos, err)
+	}
+	if op == "contains" || op == "=~" || op == "!~" {
+		return nil, fmt.Errorf("severity does not support %s", op)
+	}
+	cmp, n := numericCompare(op), float64(sev)
+	return func(c *evalCtx) bool { return cmp(float64(otel.RecordSeverity(c.lr)), n) }, nil
+}
+
+func numericCompare(op string) func(a, b float64) bool {
+	switch op {
+	case "==":
+		return func(a, b float64) bool { return a == b }
+	case "!=":
+		return func(a, b float64) bool { return a != b }
+	case "<":
+		return func(a, b float64) bool { return a < b }
+	case "<=":
+		return func(a, b float64) bool { return a <= b }
+	case ">":
+		return func(a, b float64) bool { return a > b }
+	default:
+		return func(a, b float64) bool { return a >= b }
+	}
+}
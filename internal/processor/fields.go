@@ -0,0 +1,272 @@
+package processor
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sungp/gophership/pkg/otel"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// Well-known keys recognised by the parsers when none are configured.
+var (
+	defaultTimestampKeys = []string{"timestamp", "time", "ts", "@timestamp"}
+	defaultSeverityKeys  = []string{"level", "severity", "lvl", "log.level"}
+	defaultTraceIDKeys   = []string{"trace_id", "traceId", "trace.id"}
+	defaultSpanIDKeys    = []string{"span_id", "spanId", "span.id"}
+)
+
+// wellKnown holds the keys whose values populate LogRecord fields.
+type wellKnown struct {
+	timestamp []string
+	severity  []string
+	traceID   []string
+	spanID    []string
+}
+
+func newWellKnown(ts, sev, trace, span []string) wellKnown {
+	w := wellKnown{timestamp: ts, severity: sev, traceID: trace, spanID: span}
+	if len(w.timestamp) == 0 {
+		w.timestamp = defaultTimestampKeys
+	}
+	if len(w.severity) == 0 {
+		w.severity = defaultSeverityKeys
+	}
+	if len(w.traceID) == 0 {
+		w.traceID = defaultTraceIDKeys
+	}
+	if len(w.spanID) == 0 {
+		w.spanID = defaultSpanIDKeys
+	}
+	return w
+}
+
+// apply sets the record field matching key from v, reporting whether key was well-known.
+func (w *wellKnown) apply(lr *logsv1.LogRecord, key string, v *commonv1.AnyValue) bool {
+	switch {
+	case contains(w.timestamp, key):
+		if ts, ok := parseTimestamp(v); ok {
+			lr.TimeUnixNano = ts
+		}
+	case contains(w.severity, key):
+		applySeverity(lr, v)
+	case contains(w.traceID, key):
+		if id, ok := parseHexID(v, 16); ok {
+			lr.TraceId = id
+		}
+	case contains(w.spanID, key):
+		if id, ok := parseHexID(v, 8); ok {
+			lr.SpanId = id
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func contains(keys []string, k string) bool {
+	for _, s := range keys {
+		if s == k {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTimestamp accepts RFC 3339 strings and numeric epochs, inferring the
+// unit (s, ms, us, ns) from the magnitude.
+func parseTimestamp(v *commonv1.AnyValue) (uint64, bool) {
+	switch x := v.GetValue().(type) {
+	case *commonv1.AnyValue_StringValue:
+		s := x.StringValue
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return uint64(t.UnixNano()), true
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return epochNanos(f)
+		}
+	case *commonv1.AnyValue_IntValue:
+		return epochNanos(float64(x.IntValue))
+	case *commonv1.AnyValue_DoubleValue:
+		return epochNanos(x.DoubleValue)
+	}
+	return 0, false
+}
+
+func epochNanos(f float64) (uint64, bool) {
+	if f <= 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, false
+	}
+	switch {
+	case f < 1e11:
+		f *= 1e9
+	case f < 1e14:
+		f *= 1e6
+	case f < 1e17:
+		f *= 1e3
+	}
+	return uint64(f), true
+}
+
+func applySeverity(lr *logsv1.LogRecord, v *commonv1.AnyValue) {
+	switch x := v.GetValue().(type) {
+	case *commonv1.AnyValue_StringValue:
+		if sev, err := otel.ParseSeverity(x.StringValue); err == nil {
+			lr.SeverityNumber = sev
+			lr.SeverityText = x.StringValue
+		}
+	case *commonv1.AnyValue_IntValue:
+		if x.IntValue >= 1 && x.IntValue <= 24 {
+			lr.SeverityNumber = logsv1.SeverityNumber(x.IntValue)
+		}
+	}
+}
+
+// parseHexID decodes a hex trace or span ID of exactly size bytes.
+func parseHexID(v *commonv1.AnyValue, size int) ([]byte, bool) {
+	s := strings.TrimSpace(v.GetStringValue())
+	if len(s) != size*2 {
+		return nil, false
+	}
+	id, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+	return id, true
+}
+
+// takeField removes the value at a dotted path from a kvlist and returns it.
+func takeField(kvs *commonv1.KeyValueList, path string) (*commonv1.KeyValue, bool) {
+	for {
+		head, rest, nested := strings.Cut(path, ".")
+		// Prefer an exact match on the full key so dotted keys stay addressable.
+		for i, kv := range kvs.GetValues() {
+			if kv.GetKey() == path {
+				kvs.Values = append(kvs.Values[:i], kvs.Values[i+1:]...)
+				return kv, true
+			}
+		}
+		if !nested {
+			return nil, false
+		}
+		var next *commonv1.KeyValueList
+		for _, kv := range kvs.GetValues() {
+			if kv.GetKey() == head {
+				next = kv.GetValue().GetKvlistValue()
+				break
+			}
+		}
+		if next == nil {
+			return nil, false
+		}
+		kvs, path = next, rest
+	}
+}
+
+// valueKind is the target type of a parsed field.
+type valueKind uint8
+
+const (
+	kindString valueKind = iota
+	kindInt
+	kindFloat
+	kindBool
+	kindDuration
+)
+
+// compileTypes validates a field→type coercion map.
+func compileTypes(types map[string]string) (map[string]valueKind, error) {
+	out := make(map[string]valueKind, len(types))
+	for field, t := range types {
+		switch strings.ToLower(t) {
+		case "string", "":
+			out[field] = kindString
+		case "int":
+			out[field] = kindInt
+		case "float":
+			out[field] = kindFloat
+		case "bool":
+			out[field] = kindBool
+		case "duration":
+			out[field] = kindDuration
+		default:
+			return nil, fmt.Errorf("field %q: unknown type %q", field, t)
+		}
+	}
+	return out, nil
+}
+
+// coerce converts s to a pooled AnyValue of the requested kind, falling back
+// to a string value when s does not parse.
+func coerce(kind valueKind, s string) *commonv1.AnyValue {
+	switch kind {
+	case kindInt:
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return otel.NewIntValue(i)
+		}
+	case kindFloat:
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return otel.NewDoubleValue(f)
+		}
+	case kindBool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return otel.NewBoolValue(b)
+		}
+	case kindDuration:
+		if d, err := time.ParseDuration(s); err == nil {
+			return otel.NewIntValue(int64(d))
+		}
+	}
+	return otel.NewStringValue(s)
+}
+
+// setField records a parsed field as an attribute and fills the matching
+// record field when the key is well-known.
+func setField(lr *logsv1.LogRecord, wk *wellKnown, kinds map[string]valueKind, key, raw string) {
+	v := coerce(kinds[key], raw)
+	wk.apply(lr, key, v)
+	lr.Attributes = append(lr.Attributes, otel.NewKeyValue(key, v))
+}
+
+// stringBody returns the record body when it is a string.
+func stringBody(lr *logsv1.LogRecord) (string, bool) {
+	sv, ok := lr.GetBody().GetValue().(*commonv1.AnyValue_StringValue)
+	if !ok {
+		return "", false
+	}
+	return sv.StringValue, true
+}
+
+// scalarString formats a string, int, double or bool value.
+func scalarString(v *commonv1.AnyValue) (string, bool) {
+	switch x := v.GetValue().(type) {
+	case *commonv1.AnyValue_StringValue:
+		return x.StringValue, true
+	case *commonv1.AnyValue_IntValue:
+		return strconv.FormatInt(x.IntValue, 10), true
+	case *commonv1.AnyValue_DoubleValue:
+		return strconv.FormatFloat(x.DoubleValue, 'g', -1, 64), true
+	case *commonv1.AnyValue_BoolValue:
+		return strconv.FormatBool(x.BoolValue), true
+	}
+	return "", false
+}
+
+// numberValue reads an int or double value, or a string holding a number.
+func numberValue(v *commonv1.AnyValue) (float64, bool) {
+	switch x := v.GetValue().(type) {
+	case *commonv1.AnyValue_IntValue:
+		return float64(x.IntValue), true
+	case *commonv1.AnyValue_DoubleValue:
+		return x.DoubleValue, true
+	case *commonv1.AnyValue_StringValue:
+		n, err := strconv.ParseFloat(x.StringValue, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,94 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+type filterRule struct {
+	name string
+	pred predicate
+}
+
+// Filter drops records matching any of its rules. Expressions are compiled
+// once at load; ScopeLogs and ResourceLogs left empty are removed as well.
+type Filter struct {
+	name    string
+	rules   []filterRule
+	metrics *metrics
+}
+
+// NewFilter compiles the configured drop rules, counting drops on rt.
+func NewFilter(rt *stochastic.Runtime, name string, cfg config.FilterConfig) (*Filter, error) {
+	if len(cfg.Drop) == 0 {
+		return nil, fmt.Errorf("no drop rules configured")
+	}
+	f := &Filter{name: name, rules: make([]filterRule, 0, len(cfg.Drop)), metrics: metricsFor(rt)}
+	seen := make(map[string]bool, len(cfg.Drop))
+	for _, r := range cfg.Drop {
+		if r.Name == "" {
+			return nil, fmt.Errorf("filter rule has no name")
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("duplicate filter rule %q", r.Name)
+		}
+		seen[r.Name] = true
+		pred, err := compileExpr(r.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+		f.rules = append(f.rules, filterRule{name: r.Name, pred: pred})
+		f.metrics.filteredRecordsTotal.WithLabelValues(name, r.Name) // Export zero before the first drop
+	}
+	return f, nil
+}
+
+func (f *Filter) Name() string { return f.name }
+
+// Process removes matching records in place.
+func (f *Filter) Process(_ context.Context, logs []*logsv1.ResourceLogs) ([]*logsv1.ResourceLogs, error) {
+	var ec evalCtx
+	keptRL := logs[:0]
+	for _, rl := range logs {
+		ec.resource = rl.GetResource()
+		keptSL := rl.ScopeLogs[:0]
+		for _, sl := range rl.ScopeLogs {
+			ec.scope = sl.GetScope()
+			kept := sl.LogRecords[:0]
+			for _, lr := range sl.LogRecords {
+				ec.lr = lr
+				if rule := f.match(&ec); rule != nil {
+					f.metrics.filteredRecordsTotal.WithLabelValues(f.name, rule.name).Inc()
+					continue
+				}
+				kept = append(kept, lr)
+			}
+			clear(sl.LogRecords[len(kept):])
+			sl.LogRecords = kept
+			if len(kept) > 0 {
+				keptSL = append(keptSL, sl)
+			}
+		}
+		clear(rl.ScopeLogs[len(keptSL):])
+		rl.ScopeLogs = keptSL
+		if len(keptSL) > 0 {
+			keptRL = append(keptRL, rl)
+		}
+	}
+	clear(logs[len(keptRL):])
+	return keptRL, nil
+}
+
+// match returns the first rule the record satisfies, or nil.
+func (f *Filter) match(ec *evalCtx) *filterRule {
+	for i := range f.rules {
+		if f.rules[i].pred(ec) {
+			return &f.rules[i]
+		}
+	}
+	return nil
+}
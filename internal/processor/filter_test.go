@@ -0,0 +1,121 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+func TestCompileExpr(t *testing.T) {
+	lr := stringRecord("GET /healthz 200")
+	lr.SeverityNumber = logsv1.SeverityNumber_SEVERITY_NUMBER_DEBUG
+	lr.Attributes = []*commonv1.KeyValue{
+		{Key: "http.target", Value: stringValue("/healthz")},
+		{Key: "http.status", Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: 200}}},
+		{Key: "latency_ms", Value: stringValue("12.5")},
+	}
+	ec := &evalCtx{
+		resource: &resourcev1.Resource{Attributes: []*commonv1.KeyValue{{Key: "service.name", Value: stringValue("checkout")}}},
+		scope:    &commonv1.InstrumentationScope{Name: "http"},
+		lr:       lr,
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`severity < INFO`, true},
+		{`severity >= "warn"`, false},
+		{`severity == 5`, true},
+		{`attributes["http.target"] == "/healthz"`, true},
+		{`attr['http.target'] != "/healthz"`, false},
+		{`attributes["http.status"] >= 200 && attributes["http.status"] < 300`, true},
+		{`attributes["http.status"] == "200"`, true},
+		{`attributes["latency_ms"] > 10`, true},
+		{`attributes["missing"] > 0`, false},
+		{`attributes["missing"] == ""`, true},
+		{`exists(attributes["http.target"]) and not exists(attributes["user.id"])`, true},
+		{`body contains "healthz"`, true},
+		{`body =~ "^GET /(healthz|readyz)"`, true},
+		{`body !~ "healthz"`, false},
+		{`resource["service.name"] == "checkout" and scope.name == "http"`, true},
+		{`severity_text == ""`, true},
+		{`false or (true and !false)`, true},
+		{`severity > ERROR or body contains "nope" || attributes["http.status"] != 200`, false},
+	}
+	for _, tt := range tests {
+		pred, err := compileExpr(tt.expr)
+		if err != nil {
+			t.Errorf("compileExpr(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := pred(ec); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCompileExpr_Errors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`severity <`,
+		`severity < LOUD`,
+		`severity contains ERROR`,
+		`span.name == "x"`,
+		`attributes[http.target] == "x"`,
+		`body > "abc"`,
+		`body =~ "("`,
+		`(body == "x"`,
+		`body == "x" extra`,
+		`body == "unterminated`,
+		`exists(body)`,
+		`body ~ "x"`,
+		`severity_text > 3`,
+		`severity_text >= 3`,
+		`scope.name <= 1`,
+		`scope.name < 1`,
+	} {
+		if _, err := compileExpr(expr); err == nil {
+			t.Errorf("compileExpr(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestFilter_DropsAndCountsPerRule(t *testing.T) {
+	f, err := NewFilter(stochastic.Default, "noise", config.FilterConfig{Drop: []config.FilterRule{
+		{Name: "healthcheck", Expr: `attributes["http.target"] == "/healthz"`},
+		{Name: "debug", Expr: `severity < INFO`},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	health := stringRecord("ok")
+	health.Attributes = []*commonv1.KeyValue{{Key: "http.target", Value: stringValue("/healthz")}}
+	debug := stringRecord("cache miss")
+	debug.SeverityText = "DEBUG"
+	keep := stringRecord("order placed")
+	keep.SeverityNumber = logsv1.SeverityNumber_SEVERITY_NUMBER_INFO
+
+	logs := append(testLogs(health, keep, debug), testLogs(debug)...)
+	out, err := f.Process(context.Background(), logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 || len(out[0].ScopeLogs) != 1 || len(out[0].ScopeLogs[0].LogRecords) != 1 ||
+		out[0].ScopeLogs[0].LogRecords[0] != keep {
+		t.Fatalf("unexpected result: %v", out)
+	}
+	if got := testutil.ToFloat64(FilteredRecordsTotal.WithLabelValues("noise", "healthcheck")); got != 1 {
+		t.Errorf("healthcheck drops = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(FilteredRecordsTotal.WithLabelValues("noise", "debug")); got != 2 {
+		t.Errorf("debug drops = %v, want 2", got)
+	}
+}
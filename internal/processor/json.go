@@ -0,0 +1,75 @@
+package processor
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	"github.com/sungp/gophership/pkg/otel"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// JSONParser turns JSON string bodies into structured KvlistValue bodies.
+// Well-known keys populate the record timestamp, severity and trace context,
+// and promoted fields are moved from the body into record attributes.
+// Bodies that are not JSON objects are left untouched.
+type JSONParser struct {
+	name      string
+	promote   []string
+	wellKnown wellKnown
+	metrics   *metrics
+}
+
+// NewJSONParser creates a JSON body parser counting failures on rt.
+func NewJSONParser(rt *stochastic.Runtime, name string, cfg config.JSONParserConfig) (*JSONParser, error) {
+	return &JSONParser{
+		name:      name,
+		promote:   cfg.Promote,
+		wellKnown: newWellKnown(cfg.TimestampKeys, cfg.SeverityKeys, cfg.TraceIDKeys, cfg.SpanIDKeys),
+		metrics:   metricsFor(rt),
+	}, nil
+}
+
+func (p *JSONParser) Name() string { return p.name }
+
+// Process parses every record in place.
+func (p *JSONParser) Process(_ context.Context, logs []*logsv1.ResourceLogs) ([]*logsv1.ResourceLogs, error) {
+	for _, rl := range logs {
+		for _, sl := range rl.GetScopeLogs() {
+			for _, lr := range sl.GetLogRecords() {
+				p.parse(lr)
+			}
+		}
+	}
+	return logs, nil
+}
+
+func (p *JSONParser) parse(lr *logsv1.LogRecord) {
+	body, ok := stringBody(lr)
+	if !ok {
+		return
+	}
+	body = strings.TrimSpace(body)
+	if len(body) < 2 || body[0] != '{' {
+		return
+	}
+
+	av, err := otel.ParseJSONValue([]byte(body))
+	if err != nil {
+		p.metrics.parseFailuresTotal.WithLabelValues(p.name).Inc()
+		return
+	}
+	kvs := av.GetKvlistValue()
+
+	for _, kv := range kvs.GetValues() {
+		p.wellKnown.apply(lr, kv.GetKey(), kv.GetValue())
+	}
+	for _, path := range p.promote {
+		if kv, ok := takeField(kvs, path); ok {
+			kv.Key = path
+			lr.Attributes = append(lr.Attributes, kv)
+		}
+	}
+	lr.Body = av
+}
@@ -0,0 +1,96 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func attr(lr *logsv1.LogRecord, key string) *commonv1.AnyValue {
+	for _, kv := range lr.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return nil
+}
+
+func TestJSONParser(t *testing.T) {
+	p, err := NewJSONParser(stochastic.Default, "json", config.JSONParserConfig{Promote: []string{"user.id", "status"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lr := stringRecord(`{"time":"2026-03-01T12:00:00Z","level":"warn","trace_id":"0102030405060708090a0b0c0d0e0f10",` +
+		`"msg":"slow","status":503,"user":{"id":"u1","plan":"pro"}}`)
+	plain := stringRecord("not json")
+	broken := stringRecord(`{"unterminated":`)
+	if _, err := p.Process(context.Background(), testLogs(lr, plain, broken)); err != nil {
+		t.Fatal(err)
+	}
+
+	wantTS := uint64(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC).UnixNano())
+	if lr.TimeUnixNano != wantTS {
+		t.Errorf("TimeUnixNano = %d, want %d", lr.TimeUnixNano, wantTS)
+	}
+	if lr.SeverityNumber != logsv1.SeverityNumber_SEVERITY_NUMBER_WARN || lr.SeverityText != "warn" {
+		t.Errorf("severity = %v %q", lr.SeverityNumber, lr.SeverityText)
+	}
+	if !bytes.Equal(lr.TraceId, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}) {
+		t.Errorf("TraceId = %x", lr.TraceId)
+	}
+
+	if got := attr(lr, "user.id").GetStringValue(); got != "u1" {
+		t.Errorf("promoted user.id = %q", got)
+	}
+	if got := attr(lr, "status").GetIntValue(); got != 503 {
+		t.Errorf("promoted status = %d", got)
+	}
+
+	body := lr.Body.GetKvlistValue()
+	if body == nil {
+		t.Fatalf("body not converted to kvlist: %v", lr.Body)
+	}
+	for _, kv := range body.Values {
+		if kv.Key == "status" {
+			t.Error("promoted field still present in body")
+		}
+		if kv.Key == "user" && len(kv.Value.GetKvlistValue().GetValues()) != 1 {
+			t.Errorf("nested promoted field not removed: %v", kv.Value)
+		}
+	}
+
+	if plain.Body.GetStringValue() != "not json" || broken.Body.GetStringValue() != `{"unterminated":` {
+		t.Error("non-JSON bodies must be left untouched")
+	}
+}
+
+func TestParseTimestamp_Units(t *testing.T) {
+	want := uint64(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC).UnixNano())
+	secs := int64(want / 1e9)
+	tests := []struct {
+		name string
+		v    *commonv1.AnyValue
+	}{
+		{"seconds", &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: secs}}},
+		{"millis", &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: secs * 1e3}}},
+		{"micros", &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: secs * 1e6}}},
+		{"nanos", &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: secs * 1e9}}},
+		{"float", &commonv1.AnyValue{Value: &commonv1.AnyValue_DoubleValue{DoubleValue: float64(secs)}}},
+		{"rfc3339", stringValue("2026-03-01T12:00:00Z")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseTimestamp(tt.v)
+			if !ok || got != want {
+				t.Errorf("parseTimestamp = %d, %v; want %d", got, ok, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package processor
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// LogfmtParser extracts key=value pairs from string bodies into record
+// attributes. Values may be double-quoted with backslash escapes; a bare key
+// is recorded as "true".
+type LogfmtParser struct {
+	name      string
+	kinds     map[string]valueKind
+	wellKnown wellKnown
+	metrics   *metrics
+}
+
+// NewLogfmtParser creates a logfmt body parser counting failures on rt.
+func NewLogfmtParser(rt *stochastic.Runtime, name string, cfg config.LogfmtParserConfig) (*LogfmtParser, error) {
+	kinds, err := compileTypes(cfg.Types)
+	if err != nil {
+		return nil, err
+	}
+	return &LogfmtParser{name: name, kinds: kinds, wellKnown: newWellKnown(nil, nil, nil, nil), metrics: metricsFor(rt)}, nil
+}
+
+func (p *LogfmtParser) Name() string { return p.name }
+
+// Process parses every string body in place. Bodies without any key=value
+// pair are counted as parse failures and left untouched.
+func (p *LogfmtParser) Process(_ context.Context, logs []*logsv1.ResourceLogs) ([]*logsv1.ResourceLogs, error) {
+	for _, rl := range logs {
+		for _, sl := range rl.GetScopeLogs() {
+			for _, lr := range sl.GetLogRecords() {
+				p.parse(lr)
+			}
+		}
+	}
+	return logs, nil
+}
+
+func (p *LogfmtParser) parse(lr *logsv1.LogRecord) {
+	body, ok := stringBody(lr)
+	if !ok {
+		return
+	}
+	pairs := 0
+	scanLogfmt(body, func(key, value string) {
+		setField(lr, &p.wellKnown, p.kinds, key, value)
+		pairs++
+	})
+	if pairs == 0 {
+		p.metrics.parseFailuresTotal.WithLabelValues(p.name).Inc()
+	}
+}
+
+// scanLogfmt calls fn for every pair in s; bare keys are reported as "true".
+// A body without any "=" is free-form text rather than logfmt and yields nothing.
+func scanLogfmt(s string, fn func(key, value string)) {
+	if !strings.Contains(s, "=") {
+		return
+	}
+	i := 0
+	for i < len(s) {
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		start := i
+		for i < len(s) && s[i] != '=' && s[i] != ' ' && s[i] != '"' {
+			i++
+		}
+		key := s[start:i]
+		if i >= len(s) || s[i] != '=' {
+			if key != "" {
+				fn(key, "true")
+			}
+			// Skip a stray quote or anything else we cannot parse as a key.
+			if i < len(s) && s[i] == '"' {
+				i++
+			}
+			continue
+		}
+		i++ // '='
+
+		if key == "" {
+			continue
+		}
+		if i < len(s) && s[i] == '"' {
+			var b strings.Builder
+			i++
+			for i < len(s) && s[i] != '"' {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+				i++
+			}
+			i++ // Closing quote (if present)
+			fn(key, b.String())
+			continue
+		}
+		start = i
+		for i < len(s) && s[i] != ' ' {
+			i++
+		}
+		fn(key, s[start:i])
+	}
+}
@@ -0,0 +1,233 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	"github.com/sungp/gophership/pkg/otel"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+const (
+	// DefaultMaxSeries caps the label combinations of a log-derived metric.
+	DefaultMaxSeries = 1000
+
+	// OverflowLabelValue replaces every label once a metric hits its series cap.
+	OverflowLabelValue = "_other"
+
+	severityLabel = "severity"
+)
+
+// metricNameRE accepts classic Prometheus names so metrics can be selected
+// without quoting in PromQL.
+var metricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+type logMetric struct {
+	name      string
+	filter    predicate
+	labels    []string // Source keys
+	value     string
+	counter   *prometheus.CounterVec
+	histogram *prometheus.HistogramVec
+	maxSeries int
+	series    map[string]struct{}
+	values    []string               // Reused label value buffer
+	overflow  *prometheus.CounterVec // Of the runtime the metric is registered on
+}
+
+// LogMetrics derives counters and histograms from records and registers them
+// in its runtime's registry. Records pass through unchanged.
+type LogMetrics struct {
+	name    string
+	metrics []*logMetric
+}
+
+// NewLogMetrics compiles the rules and registers their metrics on rt's
+// registry. A rule whose metric is already registered there with the same
+// shape reuses it, so chains on one runtime share a metric but chains on
+// different runtimes never do.
+func NewLogMetrics(rt *stochastic.Runtime, name string, rules []config.LogMetricRule) (*LogMetrics, error) {
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no metrics configured")
+	}
+	lm := &LogMetrics{name: name}
+	for _, r := range rules {
+		m, err := newLogMetric(rt, r)
+		if err != nil {
+			return nil, fmt.Errorf("metric %s: %w", r.Name, err)
+		}
+		lm.metrics = append(lm.metrics, m)
+	}
+	return lm, nil
+}
+
+func newLogMetric(rt *stochastic.Runtime, r config.LogMetricRule) (*logMetric, error) {
+	if !metricNameRE.MatchString(r.Name) {
+		return nil, fmt.Errorf("invalid metric name %q", r.Name)
+	}
+	m := &logMetric{
+		name:      r.Name,
+		labels:    r.Labels,
+		value:     r.Value,
+		maxSeries: r.MaxSeries,
+		series:    make(map[string]struct{}),
+		values:    make([]string, len(r.Labels)),
+		overflow:  metricsFor(rt).logMetricOverflowTotal,
+	}
+	if m.maxSeries <= 0 {
+		m.maxSeries = DefaultMaxSeries
+	}
+	if r.Filter != "" {
+		var err error
+		if m.filter, err = compileExpr(r.Filter); err != nil {
+			return nil, fmt.Errorf("filter: %w", err)
+		}
+	}
+
+	labelNames := make([]string, len(r.Labels))
+	seen := make(map[string]bool, len(r.Labels))
+	for i, k := range r.Labels {
+		labelNames[i] = sanitizeLabel(k)
+		if seen[labelNames[i]] {
+			return nil, fmt.Errorf("labels %v map to duplicate label %q", r.Labels, labelNames[i])
+		}
+		seen[labelNames[i]] = true
+	}
+	help := r.Help
+	if help == "" {
+		help = "Derived from log records."
+	}
+
+	var c prometheus.Collector
+	switch strings.ToLower(r.Type) {
+	case "", "counter":
+		m.counter = prometheus.NewCounterVec(prometheus.CounterOpts{Name: r.Name, Help: help}, labelNames)
+		c = m.counter
+	case "histogram":
+		if r.Value == "" {
+			return nil, fmt.Errorf("histogram requires a value attribute")
+		}
+		buckets := r.Buckets
+		if len(buckets) == 0 {
+			buckets = prometheus.DefBuckets
+		}
+		m.histogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: r.Name, Help: help, Buckets: buckets}, labelNames)
+		c = m.histogram
+	default:
+		return nil, fmt.Errorf("unknown metric type %q", r.Type)
+	}
+
+	if err := rt.Registry().Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return nil, err
+		}
+		// Rebuilt with the same definition (e.g. in tests): reuse the live metric.
+		switch existing := are.ExistingCollector.(type) {
+		case *prometheus.CounterVec:
+			if m.counter == nil {
+				return nil, fmt.Errorf("already registered with a different type")
+			}
+			m.counter = existing
+		case *prometheus.HistogramVec:
+			if m.histogram == nil {
+				return nil, fmt.Errorf("already registered with a different type")
+			}
+			m.histogram = existing
+		default:
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (p *LogMetrics) Name() string { return p.name }
+
+// Process updates the metrics for every record.
+func (p *LogMetrics) Process(_ context.Context, logs []*logsv1.ResourceLogs) ([]*logsv1.ResourceLogs, error) {
+	var ec evalCtx
+	for _, rl := range logs {
+		ec.resource = rl.GetResource()
+		for _, sl := range rl.GetScopeLogs() {
+			ec.scope = sl.GetScope()
+			for _, lr := range sl.GetLogRecords() {
+				ec.lr = lr
+				for _, m := range p.metrics {
+					m.observe(p.name, &ec)
+				}
+			}
+		}
+	}
+	return logs, nil
+}
+
+func (m *logMetric) observe(processor string, ec *evalCtx) {
+	if m.filter != nil && !m.filter(ec) {
+		return
+	}
+	v := 1.0
+	if m.value != "" {
+		kv := otel.FindAttribute(ec.lr.GetAttributes(), m.value)
+		if kv == nil {
+			return
+		}
+		var ok bool
+		if v, ok = numberValue(kv.Value); !ok || (m.counter != nil && v < 0) {
+			return
+		}
+	}
+
+	for i, k := range m.labels {
+		m.values[i] = labelValue(ec, k)
+	}
+	if _, ok := m.series[strings.Join(m.values, "\xff")]; !ok {
+		if len(m.series) >= m.maxSeries {
+			for i := range m.values {
+				m.values[i] = OverflowLabelValue
+			}
+			m.overflow.WithLabelValues(processor, m.name).Inc()
+		} else {
+			m.series[strings.Join(m.values, "\xff")] = struct{}{}
+		}
+	}
+
+	if m.counter != nil {
+		m.counter.WithLabelValues(m.values...).Add(v)
+	} else {
+		m.histogram.WithLabelValues(m.values...).Observe(v)
+	}
+}
+
+// labelValue reads key from the record attributes, then the resource attributes.
+func labelValue(ec *evalCtx, key string) string {
+	kv := otel.FindAttribute(ec.lr.GetAttributes(), key)
+	if kv == nil {
+		kv = otel.FindAttribute(ec.resource.GetAttributes(), key)
+	}
+	if kv == nil {
+		if key == severityLabel {
+			return strings.TrimPrefix(otel.RecordSeverity(ec.lr).String(), "SEVERITY_NUMBER_")
+		}
+		return ""
+	}
+	s, _ := scalarString(kv.Value)
+	return s
+}
+
+// sanitizeLabel turns an attribute key such as service.name into a valid
+// Prometheus label name.
+func sanitizeLabel(key string) string {
+	b := []byte(key)
+	for i, c := range b {
+		if !isWordByte(c) || i == 0 && c >= '0' && c <= '9' {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,153 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func severityRecord(body string, sev logsv1.SeverityNumber, attrs ...*commonv1.KeyValue) *logsv1.LogRecord {
+	lr := stringRecord(body)
+	lr.SeverityNumber = sev
+	lr.Attributes = attrs
+	return lr
+}
+
+func TestLogMetrics(t *testing.T) {
+	chain, err := Build(stochastic.Default, []config.ProcessorConfig{
+		{Name: "enrich-stub", Type: "json"},
+		{Name: "l2m", Type: "metrics", Metrics: []config.LogMetricRule{
+			{Name: "gophership_test_log_errors_total", Filter: "severity >= ERROR", Labels: []string{"service.name", "severity"}},
+			{Name: "gophership_test_log_duration_ms", Type: "histogram", Value: "duration_ms", Buckets: []float64{10, 100}},
+			{Name: "gophership_test_log_routes_total", Labels: []string{"route"}, MaxSeries: 2},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	route := func(r string) *commonv1.KeyValue { return &commonv1.KeyValue{Key: "route", Value: stringValue(r)} }
+	dur := func(v int64) *commonv1.KeyValue {
+		return &commonv1.KeyValue{Key: "duration_ms", Value: &commonv1.AnyValue{Value: &commonv1.AnyValue_IntValue{IntValue: v}}}
+	}
+	logs := testLogs(
+		severityRecord("boom", logsv1.SeverityNumber_SEVERITY_NUMBER_ERROR, route("/a"), dur(5)),
+		severityRecord("boom", logsv1.SeverityNumber_SEVERITY_NUMBER_FATAL, route("/b"), dur(50)),
+		severityRecord("ok", logsv1.SeverityNumber_SEVERITY_NUMBER_INFO, route("/c"), &commonv1.KeyValue{Key: "duration_ms", Value: stringValue("500")}),
+		severityRecord("ok", logsv1.SeverityNumber_SEVERITY_NUMBER_INFO, route("/d")),
+	)
+	logs[0].Resource.Attributes = []*commonv1.KeyValue{{Key: "service.name", Value: stringValue("checkout")}}
+
+	// Counting keeps going in Yellow while opportunistic parsing is skipped.
+	setZone(t, stochastic.StatusYellow)
+	out, err := chain.Process(context.Background(), logs)
+	if err != nil || len(records(out)) != 4 {
+		t.Fatalf("records not passed through: %v, %v", out, err)
+	}
+
+	l2m := chain.stages[1].p.(*LogMetrics)
+	errs, hist, routes := l2m.metrics[0].counter, l2m.metrics[1].histogram, l2m.metrics[2].counter
+	if got := testutil.ToFloat64(errs.WithLabelValues("checkout", "ERROR")); got != 1 {
+		t.Errorf("errors{ERROR} = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(errs.WithLabelValues("checkout", "FATAL")); got != 1 {
+		t.Errorf("errors{FATAL} = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(errs); got != 2 {
+		t.Errorf("errors has %d series, want 2", got)
+	}
+	if n, err := testutil.GatherAndCount(stochastic.Registry, "gophership_test_log_duration_ms"); err != nil || n != 1 {
+		t.Errorf("histogram not registered: %d, %v", n, err)
+	}
+	if got := testutil.CollectAndCount(hist); got != 1 {
+		t.Errorf("histogram has %d series, want 1", got)
+	}
+
+	// Cardinality cap: /c and /d fold into the overflow series.
+	if got := testutil.ToFloat64(routes.WithLabelValues(OverflowLabelValue)); got != 2 {
+		t.Errorf("overflow series = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(LogMetricOverflowTotal.WithLabelValues("l2m", "gophership_test_log_routes_total")); got != 2 {
+		t.Errorf("overflow counter = %v, want 2", got)
+	}
+}
+
+func TestLogMetrics_PerRuntime(t *testing.T) {
+	rules := []config.LogMetricRule{{Name: "gophership_test_log_runtime_total"}}
+	a, b := stochastic.NewRuntime(), stochastic.NewRuntime()
+	lmA, err := NewLogMetrics(a, "l2m", rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := NewLogMetrics(a, "l2m", rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lmB, err := NewLogMetrics(b, "l2m", rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.metrics[0].counter != lmA.metrics[0].counter {
+		t.Error("rebuilding on the same runtime did not reuse its metric")
+	}
+	if lmB.metrics[0].counter == lmA.metrics[0].counter {
+		t.Fatal("two runtimes share a log-derived metric")
+	}
+
+	if _, err := lmA.Process(context.Background(), testLogs(stringRecord("x"), stringRecord("y"))); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(lmA.metrics[0].counter); got != 2 {
+		t.Errorf("counter = %v, want 2", got)
+	}
+	for _, tt := range []struct {
+		name string
+		reg  *prometheus.Registry
+		want int
+	}{
+		{"own runtime", a.Registry(), 1},
+		{"other runtime", b.Registry(), 0},
+		{"default runtime", stochastic.Registry, 0},
+	} {
+		if got, err := testutil.GatherAndCount(tt.reg, "gophership_test_log_runtime_total"); err != nil || got != tt.want {
+			t.Errorf("%s exports %d series (err %v), want %d", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestNewLogMetrics_Validation(t *testing.T) {
+	tests := [][]config.LogMetricRule{
+		nil,
+		{{Name: ""}},
+		{{Name: "bad-name"}},
+		{{Name: "gophership_test_bad_type", Type: "gauge"}},
+		{{Name: "gophership_test_no_value", Type: "histogram"}},
+		{{Name: "gophership_test_bad_filter", Filter: "severity >"}},
+		{{Name: "gophership_test_text_filter", Filter: "severity_text > 3"}},
+		{{Name: "gophership_test_dup_labels", Labels: []string{"a.b", "a_b"}}},
+	}
+	for _, rules := range tests {
+		if _, err := NewLogMetrics(stochastic.Default, "l2m", rules); err == nil {
+			t.Errorf("NewLogMetrics(stochastic.Default, %+v) succeeded, want error", rules)
+		}
+	}
+
+	// Rebuilding the same rule reuses the registered metric; a changed type is rejected.
+	rule := config.LogMetricRule{Name: "gophership_test_rebuilt_total"}
+	if _, err := NewLogMetrics(stochastic.Default, "l2m", []config.LogMetricRule{rule}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewLogMetrics(stochastic.Default, "l2m", []config.LogMetricRule{rule}); err != nil {
+		t.Errorf("rebuild failed: %v", err)
+	}
+	rule.Type, rule.Value = "histogram", "v"
+	if _, err := NewLogMetrics(stochastic.Default, "l2m", []config.LogMetricRule{rule}); err == nil {
+		t.Error("re-registering with a different type succeeded")
+	}
+}
@@ -0,0 +1,116 @@
+package processor
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sungp/gophership/internal/stochastic"
+)
+
+// metrics holds the collectors of the processors built on one runtime.
+type metrics struct {
+	skippedBatchesTotal    *prometheus.CounterVec
+	redactionsTotal        *prometheus.CounterVec
+	parseFailuresTotal     *prometheus.CounterVec
+	filteredRecordsTotal   *prometheus.CounterVec
+	dedupSuppressedTotal   *prometheus.CounterVec
+	logMetricOverflowTotal *prometheus.CounterVec
+	samplingDecisionsTotal *prometheus.CounterVec
+	multilineMergedTotal   *prometheus.CounterVec
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		skippedBatchesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gophership_processor_skipped_batches_total",
+			Help: "Total number of batches skipped by processors paused in the current somatic zone.",
+		}, []string{"processor"}),
+		redactionsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gophership_processor_redactions_total",
+			Help: "Total number of sensitive values redacted.",
+		}, []string{"processor", "detector"}),
+		parseFailuresTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gophership_processor_parse_failures_total",
+			Help: "Total number of log records that failed to parse.",
+		}, []string{"processor"}),
+		filteredRecordsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gophership_processor_filtered_records_total",
+			Help: "Total number of log records dropped by filter rules.",
+		}, []string{"processor", "rule"}),
+		dedupSuppressedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gophership_processor_dedup_suppressed_total",
+			Help: "Total number of repeated log records collapsed by dedup processors.",
+		}, []string{"processor"}),
+		logMetricOverflowTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gophership_processor_log_metric_overflow_total",
+			Help: "Total number of observations folded into the overflow series of log-derived metrics.",
+		}, []string{"processor", "metric"}),
+		samplingDecisionsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gophership_processor_sampling_decisions_total",
+			Help: "Total number of log records kept or dropped by sampling processors.",
+		}, []string{"processor", "decision"}),
+		multilineMergedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gophership_processor_multiline_merged_lines_total",
+			Help: "Total number of continuation lines merged into a preceding log record.",
+		}, []string{"processor"}),
+	}
+}
+
+func (m *metrics) mustRegister(reg *prometheus.Registry) {
+	reg.MustRegister(m.skippedBatchesTotal)
+	reg.MustRegister(m.redactionsTotal)
+	reg.MustRegister(m.parseFailuresTotal)
+	reg.MustRegister(m.filteredRecordsTotal)
+	reg.MustRegister(m.dedupSuppressedTotal)
+	reg.MustRegister(m.logMetricOverflowTotal)
+	reg.MustRegister(m.samplingDecisionsTotal)
+	reg.MustRegister(m.multilineMergedTotal)
+}
+
+type metricsKey struct{}
+
+// metricsFor returns the collectors of the processors built on rt, registered on
+// its registry.
+func metricsFor(rt *stochastic.Runtime) *metrics {
+	if rt == stochastic.Default {
+		return defaultMetrics
+	}
+	return rt.Collectors(metricsKey{}, func(reg *prometheus.Registry) any {
+		m := newMetrics()
+		m.mustRegister(reg)
+		return m
+	}).(*metrics)
+}
+
+var defaultMetrics = newMetrics()
+
+// The collectors of the processors built on stochastic.Default, registered on its
+// registry at init.
+var (
+	// SkippedBatchesTotal counts batches a processor skipped because the somatic zone exceeded its max zone.
+	SkippedBatchesTotal = defaultMetrics.skippedBatchesTotal
+
+	// RedactionsTotal counts values redacted per processor and detector.
+	RedactionsTotal = defaultMetrics.redactionsTotal
+
+	// ParseFailuresTotal counts records a parsing processor could not parse.
+	ParseFailuresTotal = defaultMetrics.parseFailuresTotal
+
+	// FilteredRecordsTotal counts records dropped per filter processor and rule.
+	FilteredRecordsTotal = defaultMetrics.filteredRecordsTotal
+
+	// DedupSuppressedTotal counts repeated records collapsed by dedup processors.
+	DedupSuppressedTotal = defaultMetrics.dedupSuppressedTotal
+
+	// LogMetricOverflowTotal counts observations folded into the overflow series
+	// because a log-derived metric reached its series cap.
+	LogMetricOverflowTotal = defaultMetrics.logMetricOverflowTotal
+
+	// SamplingDecisionsTotal counts records kept or dropped by samplers.
+	SamplingDecisionsTotal = defaultMetrics.samplingDecisionsTotal
+
+	// MultilineMergedTotal counts continuation lines merged into a preceding record.
+	MultilineMergedTotal = defaultMetrics.multilineMergedTotal
+)
+
+func init() {
+	defaultMetrics.mustRegister(stochastic.Registry)
+}
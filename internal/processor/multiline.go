@@ -0,0 +1,261 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	"github.com/sungp/gophership/pkg/otel"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	DefaultMultilineTimeout  = 2 * time.Second
+	DefaultMultilineMaxLines = 500
+)
+
+// multilineStream is the record being assembled for one stream.
+type multilineStream struct {
+	lr    *logsv1.LogRecord
+	body  strings.Builder
+	lines int
+	last  time.Time // Processing time of the latest line
+
+	// Containers of the record in the batch it arrived in.
+	origRes   *resourcev1.Resource
+	origScope *commonv1.InstrumentationScope
+
+	// Set once the record is held across batches (it is no longer in one).
+	held     bool
+	resource *resourcev1.Resource
+	scope    *commonv1.InstrumentationScope
+	size     int64
+}
+
+// Multiline stitches continuation lines into the record that started them.
+// Within a batch the first line's record is updated in place and the
+// continuation records are removed. A record still open at the end of a batch
+// is held until a later line, Timeout or MaxLines closes it.
+type Multiline struct {
+	name     string
+	start    *regexp.Regexp
+	cont     *regexp.Regexp
+	keys     []string
+	timeout  time.Duration
+	maxLines int
+
+	streams map[string]*multilineStream
+	usage   int64
+	rt      *stochastic.Runtime // Whose monitor is told of usage
+	metrics *metrics
+	now     func() time.Time
+}
+
+// NewMultiline compiles the patterns. Open records are reported to rt's
+// monitor.
+func NewMultiline(rt *stochastic.Runtime, name string, cfg config.MultilineConfig) (*Multiline, error) {
+	if cfg.StartPattern == "" && cfg.ContinuePattern == "" {
+		return nil, fmt.Errorf("start_pattern or continue_pattern is required")
+	}
+	m := &Multiline{
+		name:     name,
+		keys:     cfg.StreamKeys,
+		timeout:  cfg.Timeout,
+		maxLines: cfg.MaxLines,
+		streams:  make(map[string]*multilineStream),
+		rt:       rt,
+		metrics:  metricsFor(rt),
+		now:      time.Now,
+	}
+	var err error
+	if cfg.StartPattern != "" {
+		if m.start, err = regexp.Compile(cfg.StartPattern); err != nil {
+			return nil, fmt.Errorf("start_pattern: %w", err)
+		}
+	}
+	if cfg.ContinuePattern != "" {
+		if m.cont, err = regexp.Compile(cfg.ContinuePattern); err != nil {
+			return nil, fmt.Errorf("continue_pattern: %w", err)
+		}
+	}
+	if len(m.keys) == 0 {
+		m.keys = []string{"service.name"}
+	}
+	if m.timeout <= 0 {
+		m.timeout = DefaultMultilineTimeout
+	}
+	if m.maxLines <= 0 {
+		m.maxLines = DefaultMultilineMaxLines
+	}
+	return m, nil
+}
+
+func (m *Multiline) Name() string { return m.name }
+
+// continues reports whether line belongs to the preceding record.
+func (m *Multiline) continues(line string) bool {
+	if m.start != nil && m.start.MatchString(line) {
+		return false
+	}
+	if m.cont != nil {
+		return m.cont.MatchString(line)
+	}
+	return true
+}
+
+// Process stitches the batch and appends the held records it closes.
+func (m *Multiline) Process(_ context.Context, logs []*logsv1.ResourceLogs) ([]*logsv1.ResourceLogs, error) {
+	now := m.now()
+	var closed []*logsv1.ResourceLogs
+	merged := make(map[*logsv1.LogRecord]bool)
+	open := make(map[string]*multilineStream) // Streams started in this batch
+
+	for _, rl := range logs {
+		for _, sl := range rl.GetScopeLogs() {
+			for _, lr := range sl.GetLogRecords() {
+				line, ok := stringBody(lr)
+				if !ok {
+					continue
+				}
+				key := m.streamKey(rl.GetResource(), lr)
+				st := m.streams[key]
+				if st != nil && st.held && now.Sub(st.last) >= m.timeout {
+					closed = m.close(closed, key, st)
+					st = nil
+				}
+
+				if st != nil && m.continues(line) {
+					st.body.WriteByte('\n')
+					st.body.WriteString(line)
+					st.lines++
+					st.last = now
+					merged[lr] = true
+					m.metrics.multilineMergedTotal.WithLabelValues(m.name).Inc()
+					if st.held {
+						m.account(st, int64(len(line)+1))
+					}
+					if st.lines >= m.maxLines {
+						closed = m.close(closed, key, st)
+					}
+					continue
+				}
+
+				if st != nil {
+					closed = m.close(closed, key, st)
+				}
+				st = &multilineStream{lr: lr, lines: 1, last: now, origRes: rl.GetResource(), origScope: sl.GetScope()}
+				st.body.WriteString(line)
+				m.streams[key] = st
+				open[key] = st
+			}
+		}
+	}
+
+	// Records still open at the end of the batch wait for more lines.
+	for key, st := range open {
+		if m.streams[key] != st {
+			continue // Closed within the batch
+		}
+		st.held = true
+		if st.origRes != nil {
+			st.resource = proto.Clone(st.origRes).(*resourcev1.Resource)
+		}
+		if st.origScope != nil {
+			st.scope = proto.Clone(st.origScope).(*commonv1.InstrumentationScope)
+		}
+		st.origRes, st.origScope = nil, nil
+		merged[st.lr] = true
+		m.account(st, int64(proto.Size(st.lr)+proto.Size(st.resource)+proto.Size(st.scope)+st.body.Len()))
+	}
+
+	if len(merged) > 0 {
+		logs = removeRecords(logs, merged)
+	}
+	return append(logs, closed...), nil
+}
+
+// close finalizes the stream's record. Held records are appended to out;
+// records still in the current batch were updated in place.
+func (m *Multiline) close(out []*logsv1.ResourceLogs, key string, st *multilineStream) []*logsv1.ResourceLogs {
+	delete(m.streams, key)
+	if st.lines > 1 {
+		st.lr.Body = otel.NewStringValue(st.body.String())
+	}
+	if !st.held {
+		return out
+	}
+	m.account(st, -st.size)
+	return append(out, &logsv1.ResourceLogs{
+		Resource:  st.resource,
+		ScopeLogs: []*logsv1.ScopeLogs{{Scope: st.scope, LogRecords: []*logsv1.LogRecord{st.lr}}},
+	})
+}
+
+// Flush releases held records that timed out, or all of them when final is set.
+func (m *Multiline) Flush(_ context.Context, final bool) []*logsv1.ResourceLogs {
+	now := m.now()
+	var out []*logsv1.ResourceLogs
+	for key, st := range m.streams {
+		if final || now.Sub(st.last) >= m.timeout {
+			out = m.close(out, key, st)
+		}
+	}
+	return out
+}
+
+func (m *Multiline) account(st *multilineStream, delta int64) {
+	st.size += delta
+	m.usage += delta
+	if mon := m.rt.Monitor(); mon != nil {
+		mon.ReportProcessorUsage(delta)
+	}
+}
+
+func (m *Multiline) streamKey(res *resourcev1.Resource, lr *logsv1.LogRecord) string {
+	var b strings.Builder
+	for _, k := range m.keys {
+		kv := otel.FindAttribute(lr.GetAttributes(), k)
+		if kv == nil {
+			kv = otel.FindAttribute(res.GetAttributes(), k)
+		}
+		s, _ := scalarString(kv.GetValue())
+		b.WriteString(s)
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// removeRecords drops the given records, and containers left empty.
+func removeRecords(logs []*logsv1.ResourceLogs, drop map[*logsv1.LogRecord]bool) []*logsv1.ResourceLogs {
+	keptRL := logs[:0]
+	for _, rl := range logs {
+		keptSL := rl.ScopeLogs[:0]
+		for _, sl := range rl.ScopeLogs {
+			kept := sl.LogRecords[:0]
+			for _, lr := range sl.LogRecords {
+				if !drop[lr] {
+					kept = append(kept, lr)
+				}
+			}
+			clear(sl.LogRecords[len(kept):])
+			sl.LogRecords = kept
+			if len(kept) > 0 {
+				keptSL = append(keptSL, sl)
+			}
+		}
+		clear(rl.ScopeLogs[len(keptSL):])
+		rl.ScopeLogs = keptSL
+		if len(keptSL) > 0 {
+			keptRL = append(keptRL, rl)
+		}
+	}
+	clear(logs[len(keptRL):])
+	return keptRL
+}
@@ -0,0 +1,139 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func newTestMultiline(t *testing.T, cfg config.MultilineConfig) (*Multiline, *time.Time) {
+	t.Helper()
+	m, err := NewMultiline(stochastic.Default, "stitch", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func fileRecord(path, body string) *logsv1.LogRecord {
+	lr := stringRecord(body)
+	lr.Attributes = []*commonv1.KeyValue{{Key: "log.file.path", Value: stringValue(path)}}
+	return lr
+}
+
+func bodiesOf(logs []*logsv1.ResourceLogs) []string {
+	var out []string
+	for _, lr := range records(logs) {
+		out = append(out, lr.Body.GetStringValue())
+	}
+	return out
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMultiline_JavaStackTrace(t *testing.T) {
+	m, now := newTestMultiline(t, config.MultilineConfig{ContinuePattern: `^(\s+at |\s+\.\.\. \d+ more|Caused by: )`})
+	ctx := context.Background()
+
+	out, _ := m.Process(ctx, testLogs(
+		stringRecord("java.lang.IllegalStateException: boom"),
+		stringRecord("\tat com.example.Foo.bar(Foo.java:10)"),
+		stringRecord("Caused by: java.io.IOException: disk"),
+		stringRecord("\t... 3 more"),
+		stringRecord("request served"),
+	))
+	want := []string{"java.lang.IllegalStateException: boom\n\tat com.example.Foo.bar(Foo.java:10)\nCaused by: java.io.IOException: disk\n\t... 3 more"}
+	if got := bodiesOf(out); !equalStrings(got, want) {
+		t.Fatalf("stitched = %q, want %q", got, want)
+	}
+	if m.usage <= 0 {
+		t.Error("held record not accounted")
+	}
+
+	// The trailing record is held until the timeout.
+	if out := m.Flush(ctx, false); len(out) != 0 {
+		t.Errorf("flushed before timeout: %v", bodiesOf(out))
+	}
+	*now = now.Add(2 * time.Second)
+	if got := bodiesOf(m.Flush(ctx, false)); !equalStrings(got, []string{"request served"}) {
+		t.Errorf("flush after timeout = %q", got)
+	}
+	if m.usage != 0 || len(m.streams) != 0 {
+		t.Errorf("state left: %d bytes, %d streams", m.usage, len(m.streams))
+	}
+}
+
+func TestMultiline_InterleavedStreamsAcrossBatches(t *testing.T) {
+	m, _ := newTestMultiline(t, config.MultilineConfig{StartPattern: `^\d{4}-\d{2}-\d{2} `, StreamKeys: []string{"log.file.path"}})
+	ctx := context.Background()
+
+	out, _ := m.Process(ctx, testLogs(
+		fileRecord("a.log", "2024-01-01 Traceback (most recent call last):"),
+		fileRecord("b.log", "2024-01-01 b started"),
+		fileRecord("a.log", `  File "app.py", line 3`),
+		fileRecord("b.log", "  b detail"),
+		fileRecord("a.log", "2024-01-01 a next"),
+	))
+	want := []string{"2024-01-01 Traceback (most recent call last):\n  File \"app.py\", line 3"}
+	if got := bodiesOf(out); !equalStrings(got, want) {
+		t.Fatalf("batch 1 = %q, want %q", got, want)
+	}
+
+	out, _ = m.Process(ctx, testLogs(
+		fileRecord("b.log", "  b more"),
+		fileRecord("a.log", "2024-01-01 a last"),
+	))
+	if got := bodiesOf(out); !equalStrings(got, []string{"2024-01-01 a next"}) {
+		t.Fatalf("batch 2 = %q", got)
+	}
+	got := bodiesOf(m.Flush(ctx, true))
+	if len(got) != 2 {
+		t.Fatalf("final flush = %q", got)
+	}
+	gotSet := map[string]bool{got[0]: true, got[1]: true}
+	if !gotSet["2024-01-01 b started\n  b detail\n  b more"] || !gotSet["2024-01-01 a last"] {
+		t.Errorf("final flush = %q", got)
+	}
+	if m.usage != 0 {
+		t.Errorf("usage after final flush = %d", m.usage)
+	}
+}
+
+func TestMultiline_MaxLines(t *testing.T) {
+	m, _ := newTestMultiline(t, config.MultilineConfig{ContinuePattern: `^\s`, MaxLines: 2})
+	out, _ := m.Process(context.Background(), testLogs(
+		stringRecord("panic: oops"), stringRecord(" frame 1"), stringRecord(" frame 2"), stringRecord(" frame 3"),
+	))
+	// Both records reach max_lines within the batch, so nothing is held.
+	if got := bodiesOf(out); !equalStrings(got, []string{"panic: oops\n frame 1", " frame 2\n frame 3"}) {
+		t.Fatalf("got %q", got)
+	}
+	if len(m.streams) != 0 {
+		t.Errorf("%d streams still open", len(m.streams))
+	}
+}
+
+func TestNewMultiline_Validation(t *testing.T) {
+	for _, cfg := range []config.MultilineConfig{{}, {StartPattern: "("}, {ContinuePattern: "["}} {
+		if _, err := NewMultiline(stochastic.Default, "m", cfg); err == nil {
+			t.Errorf("NewMultiline(%+v) succeeded, want error", cfg)
+		}
+	}
+}
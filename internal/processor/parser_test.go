@@ -0,0 +1,124 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestRegexParser_NginxAccessLog(t *testing.T) {
+	p, err := NewRegexParser(stochastic.Default, "nginx", config.RegexParserConfig{
+		Pattern: `^(?P<client>\S+) \S+ \S+ \[[^\]]+\] "(?P<method>\S+) (?P<path>\S+) \S+" (?P<status>\d{3}) (?P<bytes>\d+) (?P<rt>\S+)`,
+		Types:   map[string]string{"status": "int", "bytes": "int", "rt": "duration"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lr := stringRecord(`10.0.0.1 - - [10/Oct/2026:13:55:36 +0000] "GET /api/v1 HTTP/1.1" 404 512 1.5ms`)
+	miss := stringRecord("garbage")
+	p.Process(context.Background(), testLogs(lr, miss))
+
+	if got := attr(lr, "client").GetStringValue(); got != "10.0.0.1" {
+		t.Errorf("client = %q", got)
+	}
+	if got := attr(lr, "path").GetStringValue(); got != "/api/v1" {
+		t.Errorf("path = %q", got)
+	}
+	if got := attr(lr, "status").GetIntValue(); got != 404 {
+		t.Errorf("status = %d", got)
+	}
+	if got := attr(lr, "rt").GetIntValue(); got != int64(1500*time.Microsecond) {
+		t.Errorf("rt = %d", got)
+	}
+	if len(miss.Attributes) != 0 {
+		t.Errorf("non-matching record gained attributes: %v", miss.Attributes)
+	}
+}
+
+func TestNewRegexParser_Validation(t *testing.T) {
+	tests := []config.RegexParserConfig{
+		{Pattern: `(`},
+		{Pattern: `(\d+)`},
+		{Pattern: `(?P<n>\d+)`, Types: map[string]string{"n": "uint128"}},
+	}
+	for _, cfg := range tests {
+		if _, err := NewRegexParser(stochastic.Default, "r", cfg); err == nil {
+			t.Errorf("NewRegexParser(stochastic.Default, %+v) succeeded, want error", cfg)
+		}
+	}
+}
+
+func TestLogfmtParser(t *testing.T) {
+	p, err := NewLogfmtParser(stochastic.Default, "logfmt", config.LogfmtParserConfig{
+		Types: map[string]string{"took": "duration", "n": "int", "ok": "bool", "ratio": "float"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lr := stringRecord(`level=error msg="dial failed: \"conn refused\"" took=250ms n=3 ok=false ratio=0.5 retry`)
+	plain := stringRecord("just some text")
+	p.Process(context.Background(), testLogs(lr, plain))
+
+	tests := []struct {
+		key  string
+		want any
+	}{
+		{"msg", `dial failed: "conn refused"`},
+		{"took", int64(250 * time.Millisecond)},
+		{"n", int64(3)},
+		{"ok", false},
+		{"ratio", 0.5},
+		{"retry", "true"},
+	}
+	for _, tt := range tests {
+		v := attr(lr, tt.key)
+		if v == nil {
+			t.Errorf("%s missing", tt.key)
+			continue
+		}
+		var got any
+		switch tt.want.(type) {
+		case string:
+			got = v.GetStringValue()
+		case int64:
+			got = v.GetIntValue()
+		case bool:
+			got = v.GetBoolValue()
+		case float64:
+			got = v.GetDoubleValue()
+		}
+		if got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+	if lr.SeverityNumber != logsv1.SeverityNumber_SEVERITY_NUMBER_ERROR {
+		t.Errorf("severity = %v, want ERROR from level key", lr.SeverityNumber)
+	}
+	if len(plain.Attributes) != 0 {
+		t.Errorf("free-form text parsed as logfmt: %v", plain.Attributes)
+	}
+}
+
+func TestBuild_ParsersSkippedUnderPressure(t *testing.T) {
+	chain, err := Build(stochastic.Default, []config.ProcessorConfig{
+		{Name: "lf", Type: "logfmt"},
+		{Name: "re", Type: "regex", Regex: config.RegexParserConfig{Pattern: `(?P<a>\w+)`}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, zone := range []stochastic.AmbientStatus{stochastic.StatusYellow, stochastic.StatusRed} {
+		setZone(t, zone)
+		lr := stringRecord("a=1")
+		chain.Process(context.Background(), testLogs(lr))
+		if len(lr.Attributes) != 0 {
+			t.Errorf("%s: parsers ran under pressure: %v", zone, lr.Attributes)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/exporter"
+	"github.com/sungp/gophership/internal/stochastic"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// Processor transforms a batch in place or returns a new slice (e.g. with
+// records removed). Implementations are never called concurrently.
+type Processor interface {
+	// Name returns the configured identifier used in logs and metrics.
+	Name() string
+	Process(ctx context.Context, logs []*logsv1.ResourceLogs) ([]*logsv1.ResourceLogs, error)
+}
+
+// Flusher is implemented by processors that hold records back (e.g. to
+// collapse repeats) and release them later. Flush returns the records that are
+// due, or everything held when final is set.
+type Flusher interface {
+	Flush(ctx context.Context, final bool) []*logsv1.ResourceLogs
+}
+
+// DefaultFlushInterval is how often a Pipeline flushes processors that hold records.
+const DefaultFlushInterval = time.Second
+
+type stage struct {
+	p       Processor
+	maxZone stochastic.AmbientStatus
+}
+
+// Chain runs processors in order, skipping those whose max zone is below the
+// current somatic zone of its runtime.
+type Chain struct {
+	rt      *stochastic.Runtime
+	stages  []stage
+	metrics *metrics
+}
+
+// NewChain returns an empty chain gated by the zone of rt; stages are added
+// with Add.
+func NewChain(rt *stochastic.Runtime) *Chain {
+	return &Chain{rt: rt, metrics: metricsFor(rt)}
+}
+
+// Add appends a processor that runs while the somatic zone is at most maxZone.
+func (c *Chain) Add(p Processor, maxZone stochastic.AmbientStatus) {
+	c.stages = append(c.stages, stage{p: p, maxZone: maxZone})
+}
+
+// Len returns the number of stages.
+func (c *Chain) Len() int { return len(c.stages) }
+
+// Build constructs the processors declared in the configuration on rt: the
+// chain follows its zone and processors holding records report their memory
+// to its monitor.
+func Build(rt *stochastic.Runtime, cfgs []config.ProcessorConfig) (*Chain, error) {
+	c := NewChain(rt)
+	seen := make(map[string]bool, len(cfgs))
+	for _, pc := range cfgs {
+		if pc.Name == "" {
+			return nil, fmt.Errorf("processor of type %q has no name", pc.Type)
+		}
+		if seen[pc.Name] {
+			return nil, fmt.Errorf("duplicate processor name %q", pc.Name)
+		}
+		seen[pc.Name] = true
+
+		var (
+			p         Processor
+			maxZone   stochastic.AmbientStatus
+			mandatory bool // Runs in every zone; max_zone cannot lower it
+			err       error
+		)
+		switch pc.Type {
+		case "json":
+			p, err = NewJSONParser(rt, pc.Name, pc.JSON)
+			maxZone = stochastic.StatusGreen
+		case "regex":
+			p, err = NewRegexParser(rt, pc.Name, pc.Regex)
+			maxZone = stochastic.StatusGreen
+		case "logfmt":
+			p, err = NewLogfmtParser(rt, pc.Name, pc.Logfmt)
+			maxZone = stochastic.StatusGreen
+		case "multiline":
+			p, err = NewMultiline(rt, pc.Name, pc.Multiline)
+			maxZone = stochastic.StatusGreen
+		case "enrich":
+			p, err = NewEnricher(pc.Name, pc.Enrich)
+			maxZone = stochastic.StatusGreen
+		case "attributes":
+			// Cheap and relied on by routing, so it keeps running under moderate pressure.
+			p, err = NewAttributeProcessor(pc.Name, pc.Attributes)
+			maxZone = stochastic.StatusYellow
+		case "filter":
+			// Dropping noise sheds load, so it runs in every zone by default.
+			p, err = NewFilter(rt, pc.Name, pc.Filter)
+			maxZone = stochastic.StatusRed
+		case "dedup":
+			// Collapsing repeats sheds load during incidents, so it runs in every zone.
+			p, err = NewDedup(rt, pc.Name, pc.Dedup)
+			maxZone = stochastic.StatusRed
+		case "metrics":
+			// Observability of the logs themselves stays on under moderate pressure.
+			p, err = NewLogMetrics(rt, pc.Name, pc.Metrics)
+			maxZone = stochastic.StatusYellow
+		case "sample":
+			// Sampling sheds load, so it runs in every zone.
+			p, err = NewSampler(rt, pc.Name, pc.Sampling)
+			maxZone = stochastic.StatusRed
+		case "redact":
+			p, err = NewRedactor(rt, pc.Name, pc.Redact)
+			maxZone, mandatory = stochastic.StatusRed, true
+		default:
+			return nil, fmt.Errorf("processor %s: unknown type %q", pc.Name, pc.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("processor %s: %w", pc.Name, err)
+		}
+
+		if pc.MaxZone != "" {
+			if maxZone, err = stochastic.ParseStatus(pc.MaxZone); err != nil {
+				return nil, fmt.Errorf("processor %s: %w", pc.Name, err)
+			}
+			if mandatory && maxZone != stochastic.StatusRed {
+				return nil, fmt.Errorf("processor %s: %s processors run in every zone; max_zone must be red", pc.Name, pc.Type)
+			}
+		}
+		c.Add(p, maxZone)
+	}
+	return c, nil
+}
+
+// Process runs every active stage. An error aborts the batch.
+func (c *Chain) Process(ctx context.Context, logs []*logsv1.ResourceLogs) ([]*logsv1.ResourceLogs, error) {
+	zone := c.rt.Status()
+	for _, s := range c.stages {
+		if zone > s.maxZone {
+			c.metrics.skippedBatchesTotal.WithLabelValues(s.p.Name()).Inc()
+			continue
+		}
+		var err error
+		if logs, err = s.p.Process(ctx, logs); err != nil {
+			return nil, fmt.Errorf("%s: %w", s.p.Name(), err)
+		}
+		if len(logs) == 0 {
+			return nil, nil
+		}
+	}
+	return logs, nil
+}
+
+// Flush collects records released by Flusher stages and runs them through the
+// stages that follow. Flushers are asked for records regardless of zone so
+// that held state drains.
+func (c *Chain) Flush(ctx context.Context, final bool) ([]*logsv1.ResourceLogs, error) {
+	zone := c.rt.Status()
+	var out []*logsv1.ResourceLogs
+	for i, s := range c.stages {
+		f, ok := s.p.(Flusher)
+		if !ok {
+			continue
+		}
+		logs := f.Flush(ctx, final)
+		for _, next := range c.stages[i+1:] {
+			if len(logs) == 0 {
+				break
+			}
+			if zone > next.maxZone {
+				continue
+			}
+			var err error
+			if logs, err = next.p.Process(ctx, logs); err != nil {
+				return nil, fmt.Errorf("%s: %w", next.p.Name(), err)
+			}
+		}
+		out = append(out, logs...)
+	}
+	return out, nil
+}
+
+func (c *Chain) hasFlushers() bool {
+	for _, s := range c.stages {
+		if _, ok := s.p.(Flusher); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Pipeline is an exporter that runs a Chain before handing the batch on.
+// Every batch the worker loop exports, including vault replays, passes through it.
+// When the chain holds records back, a background loop flushes them every
+// DefaultFlushInterval.
+type Pipeline struct {
+	chain *Chain
+	next  exporter.Exporter
+
+	mu   sync.Mutex // Serializes the chain between Export and the flush loop
+	quit chan struct{}
+	done chan struct{}
+}
+
+// NewPipeline wraps next with chain, which keeps following its own runtime.
+func NewPipeline(chain *Chain, next exporter.Exporter) *Pipeline {
+	p := &Pipeline{chain: chain, next: next}
+	if chain.hasFlushers() {
+		p.quit = make(chan struct{})
+		p.done = make(chan struct{})
+		go p.flushLoop(DefaultFlushInterval)
+	}
+	return p
+}
+
+func (p *Pipeline) Name() string { return p.next.Name() }
+
+// Export processes the batch and forwards what remains.
+func (p *Pipeline) Export(ctx context.Context, logs []*logsv1.ResourceLogs) error {
+	p.mu.Lock()
+	logs, err := p.chain.Process(ctx, logs)
+	p.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("processor %w", err)
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+	return p.next.Export(ctx, logs)
+}
+
+func (p *Pipeline) flushLoop(interval time.Duration) {
+	defer close(p.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.flush(context.Background(), false); err != nil {
+				log.Error().Err(err).Str("exporter", p.next.Name()).Msg("Export of flushed records failed")
+			}
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+func (p *Pipeline) flush(ctx context.Context, final bool) error {
+	p.mu.Lock()
+	logs, err := p.chain.Flush(ctx, final)
+	p.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("processor %w", err)
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+	return p.next.Export(ctx, logs)
+}
+
+// Shutdown releases every held record and shuts down the wrapped exporter.
+func (p *Pipeline) Shutdown(ctx context.Context) error {
+	if p.quit != nil {
+		close(p.quit)
+		<-p.done
+		if err := p.flush(ctx, true); err != nil {
+			log.Error().Err(err).Str("exporter", p.next.Name()).Msg("Export of flushed records failed")
+		}
+	}
+	return p.next.Shutdown(ctx)
+}
@@ -0,0 +1,156 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+// countingProcessor counts invocations and optionally fails.
+type countingProcessor struct {
+	name  string
+	calls int
+	err   error
+}
+
+func (c *countingProcessor) Name() string { return c.name }
+
+func (c *countingProcessor) Process(_ context.Context, logs []*logsv1.ResourceLogs) ([]*logsv1.ResourceLogs, error) {
+	c.calls++
+	return logs, c.err
+}
+
+// sinkExporter records the batches it receives.
+type sinkExporter struct {
+	batches [][]*logsv1.ResourceLogs
+}
+
+func (s *sinkExporter) Name() string { return "sink" }
+
+func (s *sinkExporter) Export(_ context.Context, logs []*logsv1.ResourceLogs) error {
+	s.batches = append(s.batches, logs)
+	return nil
+}
+
+func (s *sinkExporter) Shutdown(context.Context) error { return nil }
+
+// testLogs wraps records in a single ResourceLogs/ScopeLogs.
+func testLogs(records ...*logsv1.LogRecord) []*logsv1.ResourceLogs {
+	return []*logsv1.ResourceLogs{{
+		Resource:  &resourcev1.Resource{},
+		ScopeLogs: []*logsv1.ScopeLogs{{LogRecords: records}},
+	}}
+}
+
+func stringRecord(body string) *logsv1.LogRecord {
+	return &logsv1.LogRecord{Body: stringValue(body)}
+}
+
+func stringValue(s string) *commonv1.AnyValue {
+	return &commonv1.AnyValue{Value: &commonv1.AnyValue_StringValue{StringValue: s}}
+}
+
+func setZone(t *testing.T, zone stochastic.AmbientStatus) {
+	t.Helper()
+	prev := stochastic.GetAmbientStatus()
+	stochastic.MustSetAmbientStatus(zone)
+	t.Cleanup(func() { stochastic.MustSetAmbientStatus(prev) })
+}
+
+func TestChain_ZoneGating(t *testing.T) {
+	tests := []struct {
+		zone          stochastic.AmbientStatus
+		opportunistic int
+		mandatory     int
+	}{
+		{stochastic.StatusGreen, 1, 1},
+		{stochastic.StatusYellow, 0, 1},
+		{stochastic.StatusRed, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.zone.String(), func(t *testing.T) {
+			setZone(t, tt.zone)
+			opp := &countingProcessor{name: "opportunistic"}
+			man := &countingProcessor{name: "mandatory"}
+			c := NewChain(stochastic.Default)
+			c.Add(opp, stochastic.StatusGreen)
+			c.Add(man, stochastic.StatusRed)
+
+			if _, err := c.Process(context.Background(), testLogs(stringRecord("x"))); err != nil {
+				t.Fatal(err)
+			}
+			if opp.calls != tt.opportunistic || man.calls != tt.mandatory {
+				t.Errorf("calls = (%d, %d), want (%d, %d)", opp.calls, man.calls, tt.opportunistic, tt.mandatory)
+			}
+		})
+	}
+}
+
+func TestPipeline_ErrorAbortsBatch(t *testing.T) {
+	setZone(t, stochastic.StatusGreen)
+	sink := &sinkExporter{}
+	c := NewChain(stochastic.Default)
+	c.Add(&countingProcessor{name: "broken", err: errors.New("boom")}, stochastic.StatusRed)
+
+	if err := NewPipeline(c, sink).Export(context.Background(), testLogs(stringRecord("x"))); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(sink.batches) != 0 {
+		t.Errorf("batch forwarded despite processor error")
+	}
+}
+
+func TestChain_FollowsItsRuntime(t *testing.T) {
+	setZone(t, stochastic.StatusGreen)
+	rt := stochastic.NewRuntime()
+	rt.SetStatus(stochastic.StatusRed)
+	m := stochastic.NewSensingMonitor(1024, 1<<30, 0.80, 0.95, 1<<20, 1<<20)
+	rt.SetMonitor(m)
+
+	opp := &countingProcessor{name: "opportunistic"}
+	d, err := NewDedup(rt, "collapse", config.DedupConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewChain(rt)
+	c.Add(opp, stochastic.StatusGreen)
+	c.Add(d, stochastic.StatusRed)
+	p := NewPipeline(c, &sinkExporter{})
+	defer p.Shutdown(context.Background())
+
+	if err := p.Export(context.Background(), testLogs(stringRecord("x"))); err != nil {
+		t.Fatal(err)
+	}
+	// Red in rt skips the stage although the default runtime is Green.
+	if opp.calls != 0 {
+		t.Errorf("opportunistic stage ran %d times in a Red runtime", opp.calls)
+	}
+	if usage, _, _ := m.Telemetry(); usage == 0 {
+		t.Error("dedup state was not reported to the chain runtime's monitor")
+	}
+}
+
+func TestBuild_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfgs []config.ProcessorConfig
+	}{
+		{"missing name", []config.ProcessorConfig{{Type: "json"}}},
+		{"unknown type", []config.ProcessorConfig{{Name: "p", Type: "xml"}}},
+		{"duplicate", []config.ProcessorConfig{{Name: "p", Type: "json"}, {Name: "p", Type: "json"}}},
+		{"bad zone", []config.ProcessorConfig{{Name: "p", Type: "json", MaxZone: "blue"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Build(stochastic.Default, tt.cfgs); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
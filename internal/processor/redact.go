@@ -0,0 +1,261 @@
+package processor
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+type redactAction uint8
+
+const (
+	actionMask redactAction = iota
+	actionHash
+	actionDrop
+)
+
+func parseRedactAction(s string) (redactAction, error) {
+	switch strings.ToLower(s) {
+	case "", "mask":
+		return actionMask, nil
+	case "hash":
+		return actionHash, nil
+	case "drop":
+		return actionDrop, nil
+	default:
+		return actionMask, fmt.Errorf("unknown redaction action %q", s)
+	}
+}
+
+// detector finds one kind of sensitive value. If re has a capture group, only
+// the first group is redacted. validate, when set, confirms a candidate span
+// s[start:end] (e.g. Luhn for card numbers).
+type detector struct {
+	name     string
+	re       *regexp.Regexp
+	validate func(s string, start, end int) bool
+	action   redactAction
+}
+
+// builtinDetectors are applied in this order; tokens go first so that an
+// email-like fragment inside a JWT is not redacted twice.
+var builtinDetectors = []detector{
+	{name: "jwt", re: regexp.MustCompile(`eyJ[A-Za-z0-9_-]{4,}\.eyJ[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]*`)},
+	{name: "bearer", re: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`)},
+	{name: "email", re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	{name: "credit_card", re: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), validate: validCardNumber},
+	{name: "ip", re: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|(?i:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}`), validate: validIP},
+}
+
+// Redactor scrubs sensitive values from record bodies, record attributes and
+// resource attributes, recursing into structured values. It is mandatory: it
+// runs in every somatic zone, so vault replays are scrubbed before export too.
+type Redactor struct {
+	name      string
+	detectors []detector
+	hashKey   []byte
+	skip      map[string]bool
+	metrics   *metrics
+}
+
+// NewRedactor compiles the configured detectors and rules, counting
+// redactions on rt.
+func NewRedactor(rt *stochastic.Runtime, name string, cfg config.RedactionConfig) (*Redactor, error) {
+	action, err := parseRedactAction(cfg.Action)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Redactor{name: name, hashKey: []byte(cfg.HashKey), skip: make(map[string]bool, len(cfg.SkipAttributes)), metrics: metricsFor(rt)}
+	for _, k := range cfg.SkipAttributes {
+		r.skip[k] = true
+	}
+
+	enabled := make(map[string]bool, len(cfg.Detectors))
+	for _, d := range cfg.Detectors {
+		enabled[d] = true
+	}
+	for _, d := range builtinDetectors {
+		if len(enabled) == 0 || enabled[d.name] {
+			d.action = action
+			r.detectors = append(r.detectors, d)
+			delete(enabled, d.name)
+		}
+	}
+	for d := range enabled {
+		return nil, fmt.Errorf("unknown detector %q", d)
+	}
+
+	for _, rule := range cfg.Rules {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("redaction rule has no name")
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+		ra := action
+		if rule.Action != "" {
+			if ra, err = parseRedactAction(rule.Action); err != nil {
+				return nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+			}
+		}
+		r.detectors = append(r.detectors, detector{name: rule.Name, re: re, action: ra})
+	}
+	return r, nil
+}
+
+func (r *Redactor) Name() string { return r.name }
+
+// Process scrubs the batch in place.
+func (r *Redactor) Process(_ context.Context, logs []*logsv1.ResourceLogs) ([]*logsv1.ResourceLogs, error) {
+	for _, rl := range logs {
+		if res := rl.GetResource(); res != nil {
+			res.Attributes = r.scrubAttributes(res.Attributes)
+		}
+		for _, sl := range rl.GetScopeLogs() {
+			for _, lr := range sl.GetLogRecords() {
+				if lr.Body != nil && r.scrubValue(lr.Body) {
+					lr.Body = nil
+				}
+				lr.Attributes = r.scrubAttributes(lr.Attributes)
+			}
+		}
+	}
+	return logs, nil
+}
+
+// scrubAttributes scrubs values in place and removes dropped attributes.
+func (r *Redactor) scrubAttributes(kvs []*commonv1.KeyValue) []*commonv1.KeyValue {
+	out := kvs[:0]
+	for _, kv := range kvs {
+		if r.skip[kv.GetKey()] || kv.GetValue() == nil || !r.scrubValue(kv.Value) {
+			out = append(out, kv)
+		}
+	}
+	clear(kvs[len(out):])
+	return out
+}
+
+// scrubValue redacts av in place and reports whether it must be dropped.
+func (r *Redactor) scrubValue(av *commonv1.AnyValue) bool {
+	switch v := av.GetValue().(type) {
+	case *commonv1.AnyValue_StringValue:
+		s, drop := r.redact(v.StringValue)
+		if drop {
+			return true
+		}
+		v.StringValue = s
+	case *commonv1.AnyValue_KvlistValue:
+		if v.KvlistValue != nil {
+			v.KvlistValue.Values = r.scrubAttributes(v.KvlistValue.Values)
+		}
+	case *commonv1.AnyValue_ArrayValue:
+		if v.ArrayValue == nil {
+			return false
+		}
+		out := v.ArrayValue.Values[:0]
+		for _, e := range v.ArrayValue.Values {
+			if e == nil || !r.scrubValue(e) {
+				out = append(out, e)
+			}
+		}
+		clear(v.ArrayValue.Values[len(out):])
+		v.ArrayValue.Values = out
+	}
+	return false
+}
+
+// redact applies every detector to s. It reports drop when a match belongs
+// to a detector whose action is drop.
+func (r *Redactor) redact(s string) (string, bool) {
+	for i := range r.detectors {
+		d := &r.detectors[i]
+		matches := d.re.FindAllStringSubmatchIndex(s, -1)
+		if matches == nil {
+			continue
+		}
+
+		var b strings.Builder
+		last, hits := 0, 0
+		for _, m := range matches {
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			if start == end || (d.validate != nil && !d.validate(s, start, end)) {
+				continue
+			}
+			if d.action == actionDrop {
+				r.metrics.redactionsTotal.WithLabelValues(r.name, d.name).Inc()
+				return "", true
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(r.replacement(d, s[start:end]))
+			last = end
+			hits++
+		}
+		if hits > 0 {
+			b.WriteString(s[last:])
+			s = b.String()
+			r.metrics.redactionsTotal.WithLabelValues(r.name, d.name).Add(float64(hits))
+		}
+	}
+	return s, false
+}
+
+func (r *Redactor) replacement(d *detector, value string) string {
+	if d.action == actionHash {
+		mac := hmac.New(sha256.New, r.hashKey)
+		mac.Write([]byte(value))
+		return d.name + ":" + hex.EncodeToString(mac.Sum(nil))[:16]
+	}
+	return "[REDACTED:" + d.name + "]"
+}
+
+// validCardNumber accepts 13-19 digit numbers that pass the Luhn check.
+func validCardNumber(s string, start, end int) bool {
+	sum, n := 0, 0
+	double := false
+	for i := end - 1; i >= start; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+		n++
+	}
+	return n >= 13 && n <= 19 && sum%10 == 0
+}
+
+// validIP accepts candidates that parse as an address and are not embedded
+// in a longer identifier (e.g. "std::vector").
+func validIP(s string, start, end int) bool {
+	if start > 0 && isWordByte(s[start-1]) || end < len(s) && isWordByte(s[end]) {
+		return false
+	}
+	_, err := netip.ParseAddr(s[start:end])
+	return err == nil
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
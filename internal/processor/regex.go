@@ -0,0 +1,85 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// RegexParser extracts the named capture groups of a pattern from string
+// bodies into record attributes, e.g. for nginx/apache access logs.
+type RegexParser struct {
+	name      string
+	re        *regexp.Regexp
+	names     []string
+	kinds     map[string]valueKind
+	wellKnown wellKnown
+	metrics   *metrics
+}
+
+// NewRegexParser compiles the pattern, which must contain named groups.
+// Failures are counted on rt.
+func NewRegexParser(rt *stochastic.Runtime, name string, cfg config.RegexParserConfig) (*RegexParser, error) {
+	re, err := regexp.Compile(cfg.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("pattern: %w", err)
+	}
+	named := 0
+	for _, n := range re.SubexpNames() {
+		if n != "" {
+			named++
+		}
+	}
+	if named == 0 {
+		return nil, fmt.Errorf("pattern has no named capture groups")
+	}
+	kinds, err := compileTypes(cfg.Types)
+	if err != nil {
+		return nil, err
+	}
+	return &RegexParser{
+		name:      name,
+		re:        re,
+		names:     re.SubexpNames(),
+		kinds:     kinds,
+		wellKnown: newWellKnown(nil, nil, nil, nil),
+		metrics:   metricsFor(rt),
+	}, nil
+}
+
+func (p *RegexParser) Name() string { return p.name }
+
+// Process parses every string body in place. Non-matching records are counted
+// as parse failures and left untouched.
+func (p *RegexParser) Process(_ context.Context, logs []*logsv1.ResourceLogs) ([]*logsv1.ResourceLogs, error) {
+	for _, rl := range logs {
+		for _, sl := range rl.GetScopeLogs() {
+			for _, lr := range sl.GetLogRecords() {
+				p.parse(lr)
+			}
+		}
+	}
+	return logs, nil
+}
+
+func (p *RegexParser) parse(lr *logsv1.LogRecord) {
+	body, ok := stringBody(lr)
+	if !ok {
+		return
+	}
+	m := p.re.FindStringSubmatchIndex(body)
+	if m == nil {
+		p.metrics.parseFailuresTotal.WithLabelValues(p.name).Inc()
+		return
+	}
+	for i, n := range p.names {
+		if n == "" || m[2*i] < 0 || m[2*i] == m[2*i+1] {
+			continue // Unnamed, unmatched or empty group
+		}
+		setField(lr, &p.wellKnown, p.kinds, n, body[m[2*i]:m[2*i+1]])
+	}
+}
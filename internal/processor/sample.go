@@ -0,0 +1,341 @@
+package processor
+
+import (
+	"container/list"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	"github.com/sungp/gophership/pkg/otel"
+	commonv1 "go.opentelemetry.io/proto/otlp/common/v1"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcev1 "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	DefaultSamplingWindow    = 30 * time.Second
+	DefaultSamplingMaxTraces = 10000
+
+	// heldTraceOverhead approximates the bookkeeping per tracked trace.
+	heldTraceOverhead = 128
+)
+
+// Attributes set on records kept by a sampler with a rate below 1.
+const (
+	SamplingRateAttr   = "sampling.rate"
+	SamplingReasonAttr = "sampling.reason"
+)
+
+// Sampling reasons.
+const (
+	reasonTraceID    = "trace_id"
+	reasonRandom     = "random"
+	reasonErrorTrace = "error_trace"
+)
+
+// severityBuckets covers UNSPECIFIED plus TRACE, DEBUG, INFO, WARN, ERROR, FATAL.
+const severityBuckets = 7
+
+func severityBucket(n logsv1.SeverityNumber) int {
+	if n <= 0 || n > 24 {
+		return 0
+	}
+	return (int(n)-1)/4 + 1
+}
+
+// heldRecord is a record of an unsampled trace awaiting a possible error.
+type heldRecord struct {
+	resource *resourcev1.Resource
+	scope    *commonv1.InstrumentationScope
+	lr       *logsv1.LogRecord
+}
+
+// traceState tracks an unsampled trace in keep_error_traces mode.
+type traceState struct {
+	id      string
+	expires time.Time
+	errored bool // Once set, every record of the trace is kept until expiry
+	held    []heldRecord
+	size    int64
+
+	// Originals of the last held resource/scope, so consecutive records
+	// share one clone.
+	origRes   *resourcev1.Resource
+	origScope *commonv1.InstrumentationScope
+}
+
+// Sampler keeps a fraction of records per severity. Records with a trace ID
+// are sampled by hashing it, so every instance and every record of a trace
+// reaches the same decision; records without one are sampled at random. Kept
+// records are annotated with sampling.rate and sampling.reason.
+type Sampler struct {
+	name      string
+	rates     [severityBuckets]float64
+	seed      uint32
+	keepError bool
+	window    time.Duration
+	maxTraces int
+
+	traces  map[string]*list.Element
+	lru     *list.List // Front is the oldest trace
+	usage   int64
+	rt      *stochastic.Runtime // Whose monitor is told of usage
+	metrics *metrics
+	now     func() time.Time
+	rand    func() float64
+}
+
+// NewSampler validates the rates. Held traces are reported to rt's monitor.
+func NewSampler(rt *stochastic.Runtime, name string, cfg config.SamplingConfig) (*Sampler, error) {
+	s := &Sampler{
+		name:      name,
+		seed:      cfg.HashSeed,
+		keepError: cfg.KeepErrorTraces,
+		window:    cfg.Window,
+		maxTraces: cfg.MaxTraces,
+		traces:    make(map[string]*list.Element),
+		lru:       list.New(),
+		rt:        rt,
+		metrics:   metricsFor(rt),
+		now:       time.Now,
+		rand:      rand.Float64,
+	}
+	if s.window <= 0 {
+		s.window = DefaultSamplingWindow
+	}
+	if s.maxTraces <= 0 {
+		s.maxTraces = DefaultSamplingMaxTraces
+	}
+
+	def := 1.0
+	if r, ok := cfg.Rates["default"]; ok {
+		def = r
+	}
+	for i := range s.rates {
+		s.rates[i] = def
+	}
+	for k, r := range cfg.Rates {
+		if r < 0 || r > 1 || math.IsNaN(r) {
+			return nil, fmt.Errorf("rate %s=%v is outside [0, 1]", k, r)
+		}
+		if strings.EqualFold(k, "default") {
+			continue
+		}
+		sev, err := otel.ParseSeverity(k)
+		if err != nil {
+			return nil, err
+		}
+		s.rates[severityBucket(sev)] = r
+	}
+	return s, nil
+}
+
+func (s *Sampler) Name() string { return s.name }
+
+// Process drops unsampled records and, in keep_error_traces mode, releases
+// the held records of traces that just logged an error.
+func (s *Sampler) Process(_ context.Context, logs []*logsv1.ResourceLogs) ([]*logsv1.ResourceLogs, error) {
+	now := s.now()
+	var released []*logsv1.ResourceLogs
+
+	keptRL := logs[:0]
+	for _, rl := range logs {
+		keptSL := rl.ScopeLogs[:0]
+		for _, sl := range rl.ScopeLogs {
+			kept := sl.LogRecords[:0]
+			for _, lr := range sl.LogRecords {
+				if keep, rel := s.decide(now, rl.GetResource(), sl.GetScope(), lr); keep {
+					kept = append(kept, lr)
+					released = append(released, rel...)
+				}
+			}
+			clear(sl.LogRecords[len(kept):])
+			sl.LogRecords = kept
+			if len(kept) > 0 {
+				keptSL = append(keptSL, sl)
+			}
+		}
+		clear(rl.ScopeLogs[len(keptSL):])
+		rl.ScopeLogs = keptSL
+		if len(keptSL) > 0 {
+			keptRL = append(keptRL, rl)
+		}
+	}
+	clear(logs[len(keptRL):])
+
+	for s.lru.Len() > s.maxTraces {
+		s.expire(s.lru.Front())
+	}
+	return append(keptRL, released...), nil
+}
+
+// decide returns whether lr is kept, plus any held records it releases.
+func (s *Sampler) decide(now time.Time, res *resourcev1.Resource, scope *commonv1.InstrumentationScope, lr *logsv1.LogRecord) (bool, []*logsv1.ResourceLogs) {
+	sev := otel.RecordSeverity(lr)
+	rate := s.rates[severityBucket(sev)]
+	traceID := lr.GetTraceId()
+	hasTrace := len(traceID) == 16 && !allZero(traceID)
+
+	var ts *traceState
+	if s.keepError && hasTrace {
+		if el, ok := s.traces[string(traceID)]; ok {
+			if ts = el.Value.(*traceState); !now.Before(ts.expires) {
+				s.expire(el)
+				ts = nil
+			}
+		}
+		if sev >= logsv1.SeverityNumber_SEVERITY_NUMBER_ERROR {
+			return true, s.markErrored(now, ts, string(traceID), lr)
+		}
+		if ts != nil && ts.errored {
+			s.keep(lr, 1, reasonErrorTrace)
+			return true, nil
+		}
+	}
+
+	switch {
+	case rate >= 1:
+		// Unsampled records are not annotated but still count as kept.
+		s.metrics.samplingDecisionsTotal.WithLabelValues(s.name, "kept").Inc()
+		return true, nil
+	case hasTrace && s.traceHash(traceID) < rate:
+		s.keep(lr, rate, reasonTraceID)
+		return true, nil
+	case !hasTrace && rate > 0 && s.rand() < rate:
+		s.keep(lr, rate, reasonRandom)
+		return true, nil
+	case !s.keepError || !hasTrace:
+		s.metrics.samplingDecisionsTotal.WithLabelValues(s.name, "dropped").Inc()
+		return false, nil
+	}
+
+	if ts == nil {
+		ts = s.track(now, string(traceID))
+	}
+	s.hold(ts, res, scope, lr)
+	return false, nil
+}
+
+// markErrored keeps the rest of the trace for a window and releases the
+// records held so far.
+func (s *Sampler) markErrored(now time.Time, ts *traceState, id string, lr *logsv1.LogRecord) []*logsv1.ResourceLogs {
+	if ts == nil {
+		ts = s.track(now, id)
+	}
+	ts.errored = true
+	ts.expires = now.Add(s.window)
+	s.lru.MoveToBack(s.traces[id])
+	s.keep(lr, 1, reasonErrorTrace)
+
+	var out []*logsv1.ResourceLogs
+	for _, h := range ts.held {
+		s.keep(h.lr, 1, reasonErrorTrace)
+		out = append(out, &logsv1.ResourceLogs{
+			Resource:  h.resource,
+			ScopeLogs: []*logsv1.ScopeLogs{{Scope: h.scope, LogRecords: []*logsv1.LogRecord{h.lr}}},
+		})
+	}
+	s.account(heldTraceOverhead - ts.size)
+	ts.size = heldTraceOverhead
+	ts.held, ts.origRes, ts.origScope = nil, nil, nil
+	return out
+}
+
+func (s *Sampler) track(now time.Time, id string) *traceState {
+	ts := &traceState{id: id, expires: now.Add(s.window), size: heldTraceOverhead}
+	s.traces[id] = s.lru.PushBack(ts)
+	s.account(ts.size)
+	return ts
+}
+
+func (s *Sampler) hold(ts *traceState, res *resourcev1.Resource, scope *commonv1.InstrumentationScope, lr *logsv1.LogRecord) {
+	h := heldRecord{lr: lr}
+	size := int64(proto.Size(lr))
+	if n := len(ts.held); n > 0 && res == ts.origRes && scope == ts.origScope {
+		h.resource, h.scope = ts.held[n-1].resource, ts.held[n-1].scope
+	} else {
+		if res != nil {
+			h.resource = proto.Clone(res).(*resourcev1.Resource)
+		}
+		if scope != nil {
+			h.scope = proto.Clone(scope).(*commonv1.InstrumentationScope)
+		}
+		ts.origRes, ts.origScope = res, scope
+		size += int64(proto.Size(h.resource) + proto.Size(h.scope))
+	}
+	ts.held = append(ts.held, h)
+	ts.size += size
+	s.account(size)
+}
+
+// expire forgets a trace; records still held were never sampled and are dropped.
+func (s *Sampler) expire(el *list.Element) {
+	ts := s.lru.Remove(el).(*traceState)
+	delete(s.traces, ts.id)
+	if n := len(ts.held); n > 0 {
+		s.metrics.samplingDecisionsTotal.WithLabelValues(s.name, "dropped").Add(float64(n))
+	}
+	s.account(-ts.size)
+}
+
+// Flush drops the held records of traces whose window closed without an
+// error, or of every trace when final is set. Nothing is released.
+func (s *Sampler) Flush(_ context.Context, final bool) []*logsv1.ResourceLogs {
+	now := s.now()
+	for el := s.lru.Front(); el != nil; {
+		next := el.Next()
+		if final || !now.Before(el.Value.(*traceState).expires) {
+			s.expire(el)
+		}
+		el = next
+	}
+	return nil
+}
+
+func (s *Sampler) account(delta int64) {
+	s.usage += delta
+	if m := s.rt.Monitor(); m != nil {
+		m.ReportProcessorUsage(delta)
+	}
+}
+
+// keep annotates and counts a record kept by a sampling decision.
+func (s *Sampler) keep(lr *logsv1.LogRecord, rate float64, reason string) {
+	s.metrics.samplingDecisionsTotal.WithLabelValues(s.name, "kept").Inc()
+	lr.Attributes = otel.SetAttribute(lr.Attributes, SamplingRateAttr, otel.NewDoubleValue(rate))
+	lr.Attributes = otel.SetAttribute(lr.Attributes, SamplingReasonAttr, otel.NewStringValue(reason))
+}
+
+// traceHash maps a trace ID to [0, 1).
+func (s *Sampler) traceHash(id []byte) float64 {
+	h := fnv.New64a()
+	var seed [4]byte
+	binary.BigEndian.PutUint32(seed[:], s.seed)
+	h.Write(seed[:])
+	h.Write(id)
+	x := h.Sum64()
+	// murmur3 fmix64 finalizer for an even spread of similar IDs
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return float64(x>>11) / (1 << 53)
+}
+
+func allZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
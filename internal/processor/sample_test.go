@@ -0,0 +1,149 @@
+package processor
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func traceRecord(trace uint64, sev logsv1.SeverityNumber, body string) *logsv1.LogRecord {
+	lr := severityRecord(body, sev)
+	lr.TraceId = make([]byte, 16)
+	binary.BigEndian.PutUint64(lr.TraceId[8:], trace)
+	return lr
+}
+
+func newTestSampler(t *testing.T, cfg config.SamplingConfig) (*Sampler, *time.Time) {
+	t.Helper()
+	s, err := NewSampler(stochastic.Default, "sample", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestSampler_ConsistentByTraceID(t *testing.T) {
+	const info = logsv1.SeverityNumber_SEVERITY_NUMBER_INFO
+	a, _ := newTestSampler(t, config.SamplingConfig{Rates: map[string]float64{"INFO": 0.25}})
+	b, _ := newTestSampler(t, config.SamplingConfig{Rates: map[string]float64{"INFO": 0.25}})
+
+	const traces = 4000
+	kept := 0
+	for i := uint64(1); i <= traces; i++ {
+		outA, _ := a.Process(context.Background(), testLogs(traceRecord(i, info, "a"), traceRecord(i, info, "b")))
+		outB, _ := b.Process(context.Background(), testLogs(traceRecord(i, info, "c")))
+		nA, nB := len(records(outA)), len(records(outB))
+		if nA == 1 || nA == 2 && nB == 0 || nA == 0 && nB == 1 {
+			t.Fatalf("trace %d split: %d/2 kept by a, %d/1 by b", i, nA, nB)
+		}
+		if nA == 2 {
+			kept++
+			lr := records(outA)[0]
+			if attr(lr, SamplingRateAttr).GetDoubleValue() != 0.25 || attr(lr, SamplingReasonAttr).GetStringValue() != reasonTraceID {
+				t.Fatalf("kept record not annotated: %v", lr.Attributes)
+			}
+		}
+	}
+	if frac := float64(kept) / traces; frac < 0.21 || frac > 0.29 {
+		t.Errorf("kept %.3f of traces, want ~0.25", frac)
+	}
+}
+
+func TestSampler_SeverityRatesAndRandom(t *testing.T) {
+	s, _ := newTestSampler(t, config.SamplingConfig{Rates: map[string]float64{"default": 0, "debug": 0.5, "warn": 1}})
+	draws := []float64{0.4, 0.6}
+	s.rand = func() float64 { d := draws[0]; draws = draws[1:]; return d }
+	kept := testutil.ToFloat64(SamplingDecisionsTotal.WithLabelValues("sample", "kept"))
+	dropped := testutil.ToFloat64(SamplingDecisionsTotal.WithLabelValues("sample", "dropped"))
+
+	out, _ := s.Process(context.Background(), testLogs(
+		severityRecord("info", logsv1.SeverityNumber_SEVERITY_NUMBER_INFO),
+		severityRecord("debug kept", logsv1.SeverityNumber_SEVERITY_NUMBER_DEBUG2),
+		severityRecord("debug dropped", logsv1.SeverityNumber_SEVERITY_NUMBER_DEBUG),
+		severityRecord("warn", logsv1.SeverityNumber_SEVERITY_NUMBER_WARN3),
+	))
+	got := records(out)
+	if len(got) != 2 || got[0].Body.GetStringValue() != "debug kept" || got[1].Body.GetStringValue() != "warn" {
+		t.Fatalf("kept %v", got)
+	}
+	if attr(got[0], SamplingReasonAttr).GetStringValue() != reasonRandom {
+		t.Errorf("random decision not annotated: %v", got[0].Attributes)
+	}
+	if attr(got[1], SamplingRateAttr) != nil {
+		t.Error("record at rate 1 was annotated")
+	}
+	// The record at rate 1 counts as kept like the sampled one.
+	if n := testutil.ToFloat64(SamplingDecisionsTotal.WithLabelValues("sample", "kept")) - kept; n != 2 {
+		t.Errorf("counted %v kept decisions, want 2", n)
+	}
+	if n := testutil.ToFloat64(SamplingDecisionsTotal.WithLabelValues("sample", "dropped")) - dropped; n != 2 {
+		t.Errorf("counted %v dropped decisions, want 2", n)
+	}
+}
+
+func TestSampler_KeepErrorTraces(t *testing.T) {
+	const (
+		info = logsv1.SeverityNumber_SEVERITY_NUMBER_INFO
+		errS = logsv1.SeverityNumber_SEVERITY_NUMBER_ERROR
+	)
+	s, now := newTestSampler(t, config.SamplingConfig{
+		Rates:           map[string]float64{"INFO": 0},
+		KeepErrorTraces: true,
+		Window:          10 * time.Second,
+	})
+	ctx := context.Background()
+
+	out, _ := s.Process(ctx, testLogs(traceRecord(1, info, "t1 step 1"), traceRecord(1, info, "t1 step 2"), traceRecord(2, info, "t2 only info")))
+	if len(records(out)) != 0 {
+		t.Fatalf("unsampled records passed: %v", records(out))
+	}
+	if s.usage <= 0 {
+		t.Fatal("held records not accounted")
+	}
+
+	*now = now.Add(5 * time.Second)
+	out, _ = s.Process(ctx, testLogs(traceRecord(1, errS, "t1 failed")))
+	got := records(out)
+	if len(got) != 3 || got[0].Body.GetStringValue() != "t1 failed" || got[1].Body.GetStringValue() != "t1 step 1" {
+		t.Fatalf("error did not release the trace: %v", got)
+	}
+	for _, lr := range got {
+		if attr(lr, SamplingReasonAttr).GetStringValue() != reasonErrorTrace {
+			t.Errorf("%q not annotated as error_trace", lr.Body.GetStringValue())
+		}
+	}
+
+	// Later records of the errored trace are kept until its window closes.
+	if out, _ := s.Process(ctx, testLogs(traceRecord(1, info, "t1 cleanup"))); len(records(out)) != 1 {
+		t.Error("record after error was not kept")
+	}
+
+	// Trace 2 never errored: its held record is dropped when the window closes.
+	*now = now.Add(6 * time.Second)
+	if out := s.Flush(ctx, false); len(out) != 0 {
+		t.Errorf("flush released %v", out)
+	}
+	if _, ok := s.traces[string(traceRecord(2, info, "").TraceId)]; ok {
+		t.Error("expired trace still tracked")
+	}
+	s.Flush(ctx, true)
+	if s.usage != 0 || s.lru.Len() != 0 {
+		t.Errorf("state left after final flush: %d bytes, %d traces", s.usage, s.lru.Len())
+	}
+}
+
+func TestNewSampler_Validation(t *testing.T) {
+	for _, rates := range []map[string]float64{{"INFO": 1.5}, {"default": -0.1}, {"LOUD": 0.5}} {
+		if _, err := NewSampler(stochastic.Default, "s", config.SamplingConfig{Rates: rates}); err == nil {
+			t.Errorf("NewSampler(%v) succeeded, want error", rates)
+		}
+	}
+}
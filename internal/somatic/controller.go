@@ -1,7 +1,9 @@
 package somatic
 
 import (
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/sungp/gophership/internal/stochastic"
 )
@@ -15,38 +17,75 @@ type PressureProvider interface {
 
 // Controller manages the "biological" transitions between pressure zones.
 type Controller struct {
+	rt       *stochastic.Runtime
 	provider PressureProvider
 	curr     uint32 // Atomic stochastic.AmbientStatus
 	// overrideZone stores the manual override state.
 	// 0 = No override (use sensors)
 	// 1 = Green, 2 = Yellow, 3 = Red
 	overrideZone uint32
+
+	// mu guards the override details and hysteresis state below.
+	mu            sync.Mutex
+	override      Override
+	overrideGen   uint64 // Bumped on every set or clear, so stale TTL timers are ignored
+	overrideTimer *time.Timer
+
+	policy  *compiledPolicy
+	entered time.Time         // When curr was entered
+	recent  []time.Time       // Recent transition times, oldest first
+	trend   *stochastic.Trend // Occupancy trend; nil without prediction
+	now     func() time.Time
 }
 
+// NewController creates a controller using DefaultPolicy that votes in the
+// default runtime.
 func NewController(p PressureProvider) *Controller {
+	return NewControllerWithRuntime(stochastic.Default, p)
+}
+
+// NewControllerWithRuntime creates a controller using DefaultPolicy that
+// votes with rt's zone arbiter.
+func NewControllerWithRuntime(rt *stochastic.Runtime, p PressureProvider) *Controller {
 	c := &Controller{
+		rt:       rt,
 		provider: p,
+		policy:   DefaultPolicy().compile(),
+		now:      time.Now,
 	}
+	c.entered = c.now()
 	atomic.StoreUint32(&c.curr, uint32(stochastic.StatusGreen))
 	atomic.StoreUint32(&c.overrideZone, 0)
 	return c
 }
 
-// Override manually forces the controller into a specific state.
-func (c *Controller) Override(status stochastic.AmbientStatus) {
-	atomic.StoreUint32(&c.overrideZone, uint32(status)+1)
-	atomic.StoreUint32(&c.curr, uint32(status))
-	stochastic.MustSetAmbientStatus(status)
+// Runtime returns the runtime the controller votes in.
+func (c *Controller) Runtime() *stochastic.Runtime {
+	return c.rt
 }
 
-// ClearOverride removes any manual override and immediately reassesses state.
-func (c *Controller) ClearOverride() {
-	atomic.StoreUint32(&c.overrideZone, 0)
-	c.Reassess()
+// SetPolicy replaces the watermarks, dwell times and rate limit.
+func (c *Controller) SetPolicy(p Policy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.policy = p.compile()
+	c.trend = nil
+	if p.Prediction.Horizon > 0 {
+		c.trend = stochastic.NewTrend(p.Prediction.HalfLife)
+	}
+	c.mu.Unlock()
+	return nil
 }
 
-// Reassess evaluates system pressure and updates the global stochastic state.
-// It implements hysteresis to prevent rapid status oscillations.
+// Reassess evaluates buffer pressure, casts it as the buffer sensor's vote
+// with the zone arbiter and returns the buffer zone (or the override).
+// It implements hysteresis to prevent rapid status oscillations: separate
+// enter and exit watermarks per zone, a minimum dwell time in each zone and
+// a cap on transitions per window. Escalation to Red bypasses dwell and the
+// rate limit. With prediction enabled, occupancy trending toward Red within
+// the horizon holds at least Yellow.
 func (c *Controller) Reassess() stochastic.AmbientStatus {
 	override := atomic.LoadUint32(&c.overrideZone)
 	if override != 0 {
@@ -59,22 +98,44 @@ func (c *Controller) Reassess() stochastic.AmbientStatus {
 		return stochastic.StatusGreen
 	}
 
+	c.mu.Lock()
 	curr := stochastic.AmbientStatus(atomic.LoadUint32(&c.curr))
-	next := curr
-
 	// [NFR.P1] Optimization: Use integer math to avoid float64 overhead.
-	// High Watermark: 85% occupancy triggers emergency mode.
-	if depth*100 > capacity*85 {
-		next = stochastic.StatusRed
-	} else if depth*100 < capacity*20 {
-		// Low Watermark: Only recover to Green if we drop below 20%.
-		next = stochastic.StatusGreen
+	next := c.policy.target(curr, depth, capacity)
+	predicted := false
+	if c.trend != nil {
+		occ := float64(depth) / float64(capacity)
+		if stochastic.Forecast(stochastic.SensorBuffer, c.trend, c.policy.Prediction.Horizon, c.now(), occ, c.policy.RedEnter) && next == stochastic.StatusGreen {
+			next, predicted = stochastic.StatusYellow, true
+		}
 	}
-
 	if next != curr {
-		atomic.StoreUint32(&c.curr, uint32(next))
-		stochastic.MustSetAmbientStatus(next)
+		if now := c.now(); c.policy.allowed(curr, next, now, c.entered, c.recent) {
+			c.entered = now
+			if limit := c.policy.MaxTransitions; limit > 0 {
+				c.recent = append(c.recent, now)
+				if len(c.recent) > limit {
+					c.recent = c.recent[len(c.recent)-limit:]
+				}
+			}
+			atomic.StoreUint32(&c.curr, uint32(next))
+		} else {
+			next = curr
+		}
 	}
+	c.mu.Unlock()
 
+	reason := "Ingestion buffer occupancy"
+	if predicted && next == stochastic.StatusYellow {
+		reason = "Ingestion buffer trending toward Red"
+	}
+	// The vote is recast even without a transition so that the arbiter
+	// never holds a stale one.
+	c.rt.Zones().Cast(stochastic.Vote{
+		Sensor: stochastic.SensorBuffer,
+		Status: next,
+		Reason: reason,
+		Value:  float64(depth) / float64(capacity),
+	})
 	return next
 }
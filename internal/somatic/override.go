@@ -0,0 +1,288 @@
+package somatic
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sungp/gophership/internal/config"
+	"github.com/sungp/gophership/internal/stochastic"
+)
+
+// ScheduleTick is how often RunSchedule checks for windows starting or ending.
+const ScheduleTick = time.Second
+
+// scheduledBy prefixes the identity of overrides applied by a schedule.
+const scheduledBy = "schedule/"
+
+// Override pins the somatic zone regardless of sensors.
+type Override struct {
+	Zone   stochastic.AmbientStatus
+	Reason string
+	// By identifies who set the override as verified by the control plane:
+	// the client certificate or transport, or "schedule/<name>" for
+	// scheduled windows.
+	By string
+	// OnBehalfOf is the operator the caller claims to act for. It is not
+	// verified and never replaces By.
+	OnBehalfOf string
+	SetAt      time.Time
+	// Until clears the override automatically; zero keeps it until cleared.
+	Until time.Time
+}
+
+// Scheduled reports whether the override was applied by a schedule window.
+func (o Override) Scheduled() bool {
+	return strings.HasPrefix(o.By, scheduledBy)
+}
+
+// describe is the override's reason as shown on the arbiter's override vote.
+func (o Override) describe() string {
+	reason := o.Reason
+	if reason == "" {
+		reason = "Manual override"
+	}
+	switch {
+	case o.By != "" && o.OnBehalfOf != "":
+		reason += " (by " + o.By + " for " + o.OnBehalfOf + ")"
+	case o.By != "":
+		reason += " (by " + o.By + ")"
+	case o.OnBehalfOf != "":
+		reason += " (for " + o.OnBehalfOf + ")"
+	}
+	return reason
+}
+
+// Override manually forces the controller into a specific state until
+// ClearOverride.
+func (c *Controller) Override(status stochastic.AmbientStatus) {
+	c.SetOverride(Override{Zone: status})
+}
+
+// SetOverride forces the controller into o.Zone. A non-zero o.Until clears
+// the override automatically at that time; a zero o.SetAt is set to now.
+func (c *Controller) SetOverride(o Override) {
+	c.mu.Lock()
+	now := c.now()
+	if o.SetAt.IsZero() {
+		o.SetAt = now
+	}
+	c.entered = now
+	c.override = o
+	c.overrideGen++
+	if c.overrideTimer != nil {
+		c.overrideTimer.Stop()
+		c.overrideTimer = nil
+	}
+	if !o.Until.IsZero() {
+		gen := c.overrideGen
+		c.overrideTimer = time.AfterFunc(o.Until.Sub(now), func() { c.expireOverride(gen) })
+	}
+	atomic.StoreUint32(&c.overrideZone, uint32(o.Zone)+1)
+	atomic.StoreUint32(&c.curr, uint32(o.Zone))
+	c.mu.Unlock()
+
+	c.rt.Zones().Override(o.Zone, o.describe())
+}
+
+// ClearOverride removes any manual override and immediately reassesses state.
+func (c *Controller) ClearOverride() {
+	c.mu.Lock()
+	c.clearOverrideLocked()
+	c.mu.Unlock()
+
+	c.rt.Zones().ClearOverride()
+	c.Reassess()
+}
+
+func (c *Controller) clearOverrideLocked() {
+	c.override = Override{}
+	c.overrideGen++
+	if c.overrideTimer != nil {
+		c.overrideTimer.Stop()
+		c.overrideTimer = nil
+	}
+	atomic.StoreUint32(&c.overrideZone, 0)
+}
+
+// expireOverride clears the override set as generation gen, unless it has
+// since been replaced or cleared.
+func (c *Controller) expireOverride(gen uint64) {
+	c.mu.Lock()
+	if c.overrideGen != gen || atomic.LoadUint32(&c.overrideZone) == 0 {
+		c.mu.Unlock()
+		return
+	}
+	expired := c.override
+	c.clearOverrideLocked()
+	c.mu.Unlock()
+
+	log.Info().
+		Str("zone", expired.Zone.String()).
+		Str("by", expired.By).
+		Str("on_behalf_of", expired.OnBehalfOf).
+		Str("reason", expired.Reason).
+		Msg("Somatic zone override expired")
+	c.rt.Zones().ClearOverride()
+	c.Reassess()
+}
+
+// ActiveOverride returns the override in force, if any.
+func (c *Controller) ActiveOverride() (Override, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if atomic.LoadUint32(&c.overrideZone) == 0 {
+		return Override{}, false
+	}
+	return c.override, true
+}
+
+// Window is a recurring period during which the zone is forced, e.g. Yellow
+// during a nightly batch.
+type Window struct {
+	Name   string
+	Zone   stochastic.AmbientStatus
+	Reason string
+	// Start is the offset from local midnight at which the window opens;
+	// it may run past the following midnight.
+	Start    time.Duration
+	Duration time.Duration
+	// Days restricts the days on which the window opens; empty means daily.
+	Days []time.Weekday
+}
+
+// Validate checks that the window opens within a day and lasts at most one.
+func (w Window) Validate() error {
+	if w.Start < 0 || w.Start >= 24*time.Hour {
+		return fmt.Errorf("override window %q: start %s must be within a day", w.Name, w.Start)
+	}
+	if w.Duration <= 0 || w.Duration > 24*time.Hour {
+		return fmt.Errorf("override window %q: duration %s must be positive and at most 24h", w.Name, w.Duration)
+	}
+	return nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// BuildWindows converts configured override windows.
+func BuildWindows(cfgs []config.OverrideWindowConfig) ([]Window, error) {
+	windows := make([]Window, 0, len(cfgs))
+	for _, wc := range cfgs {
+		zone, err := stochastic.ParseStatus(wc.Zone)
+		if err != nil {
+			return nil, fmt.Errorf("override window %q: %w", wc.Name, err)
+		}
+		clock, err := time.Parse("15:04", wc.Start)
+		if err != nil {
+			return nil, fmt.Errorf("override window %q: start %q must be HH:MM", wc.Name, wc.Start)
+		}
+		w := Window{
+			Name:     wc.Name,
+			Zone:     zone,
+			Reason:   wc.Reason,
+			Start:    time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute,
+			Duration: wc.Duration,
+		}
+		for _, d := range wc.Days {
+			day, ok := weekdays[strings.ToLower(d)]
+			if !ok {
+				return nil, fmt.Errorf("override window %q: unknown day %q", wc.Name, d)
+			}
+			w.Days = append(w.Days, day)
+		}
+		if err := w.Validate(); err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// activeUntil reports whether the window is open at now and when it closes.
+func (w Window) activeUntil(now time.Time) (time.Time, bool) {
+	y, m, d := now.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	// A window that opened yesterday may still be open.
+	for _, day := range []time.Time{midnight, midnight.AddDate(0, 0, -1)} {
+		if !w.opensOn(day.Weekday()) {
+			continue
+		}
+		start := day.Add(w.Start)
+		end := start.Add(w.Duration)
+		if !now.Before(start) && now.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (w Window) opensOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// RunSchedule applies windows until ctx is done. While a window is open its
+// zone is forced until the window closes. Manual overrides take precedence:
+// a window does not replace one, but applies once it is cleared or expires.
+func (c *Controller) RunSchedule(ctx context.Context, windows []Window) {
+	if len(windows) == 0 {
+		return
+	}
+	ticker := time.NewTicker(ScheduleTick)
+	defer ticker.Stop()
+
+	c.applySchedule(windows)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.applySchedule(windows)
+		}
+	}
+}
+
+func (c *Controller) applySchedule(windows []Window) {
+	c.mu.Lock()
+	now := c.now()
+	cur, active := c.override, atomic.LoadUint32(&c.overrideZone) != 0
+	c.mu.Unlock()
+
+	if active && !cur.Scheduled() {
+		return
+	}
+	for _, w := range windows {
+		until, open := w.activeUntil(now)
+		if !open {
+			continue
+		}
+		by := scheduledBy + w.Name
+		if active && cur.By == by && cur.Until.Equal(until) {
+			return
+		}
+		reason := w.Reason
+		if reason == "" {
+			reason = "Scheduled override"
+		}
+		log.Info().
+			Str("window", w.Name).
+			Str("zone", w.Zone.String()).
+			Time("until", until).
+			Msg("Scheduled somatic zone override started")
+		c.SetOverride(Override{Zone: w.Zone, Reason: reason, By: by, Until: until})
+		return
+	}
+}
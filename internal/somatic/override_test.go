@@ -1,8 +1,11 @@
 package somatic
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/sungp/gophership/internal/config"
 	"github.com/sungp/gophership/internal/stochastic"
 )
 
@@ -53,3 +56,147 @@ func TestController_Override(t *testing.T) {
 		t.Errorf("expected status Red with high pressure, got %v", status)
 	}
 }
+
+func TestController_OverrideTTL(t *testing.T) {
+	stochastic.MustSetAmbientStatus(stochastic.StatusGreen)
+	t.Cleanup(func() { stochastic.MustSetAmbientStatus(stochastic.StatusGreen) })
+
+	c := NewController(&overrideMockProvider{cap: 100})
+	c.SetOverride(Override{
+		Zone:   stochastic.StatusRed,
+		Reason: "incident 42",
+		By:     "alice",
+		Until:  time.Now().Add(50 * time.Millisecond),
+	})
+
+	o, ok := c.ActiveOverride()
+	if !ok || o.Zone != stochastic.StatusRed || o.By != "alice" || o.SetAt.IsZero() {
+		t.Fatalf("ActiveOverride() = %+v, %v; want RED by alice", o, ok)
+	}
+	if d := stochastic.Zones.Decision(); d.Sensor != stochastic.SensorOverride || d.Reason != "incident 42 (by alice)" {
+		t.Errorf("arbiter decision = %q (%q), want override with reason and identity", d.Sensor, d.Reason)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := c.ActiveOverride(); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("override did not expire")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status := c.Reassess(); status != stochastic.StatusGreen {
+		t.Errorf("expected Green after the override expired, got %s", status)
+	}
+	if got := stochastic.GetAmbientStatus(); got != stochastic.StatusGreen {
+		t.Errorf("published status = %s after expiry, want GREEN", got)
+	}
+}
+
+func TestController_OverrideReplacedBeforeExpiry(t *testing.T) {
+	t.Cleanup(func() { stochastic.MustSetAmbientStatus(stochastic.StatusGreen) })
+
+	c := NewController(&overrideMockProvider{cap: 100})
+	c.SetOverride(Override{Zone: stochastic.StatusRed, Until: time.Now().Add(20 * time.Millisecond)})
+	c.SetOverride(Override{Zone: stochastic.StatusYellow}) // No TTL
+	time.Sleep(60 * time.Millisecond)
+
+	if o, ok := c.ActiveOverride(); !ok || o.Zone != stochastic.StatusYellow {
+		t.Errorf("ActiveOverride() = %+v, %v; the replaced override's TTL must not clear its successor", o, ok)
+	}
+	c.ClearOverride()
+}
+
+func TestWindow_ActiveUntil(t *testing.T) {
+	nightly := Window{Name: "batch", Start: 23 * time.Hour, Duration: 3 * time.Hour}
+	weekdays := Window{Name: "office", Start: 9 * time.Hour, Duration: 8 * time.Hour, Days: []time.Weekday{time.Monday}}
+	at := func(day, hour int) time.Time { return time.Date(2024, 1, day, hour, 30, 0, 0, time.UTC) } // Jan 1 2024 is a Monday
+
+	tests := []struct {
+		name      string
+		w         Window
+		now       time.Time
+		wantOpen  bool
+		wantUntil time.Time
+	}{
+		{"BeforeStart", nightly, at(1, 22), false, time.Time{}},
+		{"Open", nightly, at(1, 23), true, time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC)},
+		{"OpenPastMidnight", nightly, at(2, 1), true, time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC)},
+		{"Closed", nightly, at(2, 2), false, time.Time{}},
+		{"OnDay", weekdays, at(1, 10), true, time.Date(2024, 1, 1, 17, 0, 0, 0, time.UTC)},
+		{"OffDay", weekdays, at(2, 10), false, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, open := tt.w.activeUntil(tt.now)
+			if open != tt.wantOpen || !until.Equal(tt.wantUntil) {
+				t.Errorf("activeUntil(%s) = %s, %v; want %s, %v", tt.now, until, open, tt.wantUntil, tt.wantOpen)
+			}
+		})
+	}
+}
+
+func TestController_Schedule(t *testing.T) {
+	t.Cleanup(func() { stochastic.MustSetAmbientStatus(stochastic.StatusGreen) })
+
+	c := NewController(&overrideMockProvider{cap: 100})
+	now := time.Date(2024, 1, 1, 23, 30, 0, 0, time.Local)
+	c.now = func() time.Time { return now }
+	windows := []Window{{Name: "batch", Zone: stochastic.StatusYellow, Start: 23 * time.Hour, Duration: 3 * time.Hour}}
+
+	c.applySchedule(windows)
+	o, ok := c.ActiveOverride()
+	if !ok || o.Zone != stochastic.StatusYellow || o.By != "schedule/batch" || !o.Scheduled() {
+		t.Fatalf("ActiveOverride() = %+v, %v; want YELLOW by schedule/batch", o, ok)
+	}
+	if want := time.Date(2024, 1, 2, 2, 0, 0, 0, time.Local); !o.Until.Equal(want) {
+		t.Errorf("scheduled override until %s, want %s", o.Until, want)
+	}
+
+	// A manual override takes precedence over the window.
+	c.SetOverride(Override{Zone: stochastic.StatusRed, By: "bob"})
+	c.applySchedule(windows)
+	if o, _ := c.ActiveOverride(); o.By != "bob" {
+		t.Errorf("schedule replaced a manual override: %+v", o)
+	}
+
+	// Once cleared, the window applies again.
+	c.ClearOverride()
+	c.applySchedule(windows)
+	if o, ok := c.ActiveOverride(); !ok || !o.Scheduled() {
+		t.Errorf("schedule did not resume after the manual override was cleared: %+v, %v", o, ok)
+	}
+	c.ClearOverride()
+}
+
+func TestBuildWindows(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.OverrideWindowConfig
+		want    Window
+		wantErr bool
+	}{
+		{
+			name: "Nightly",
+			cfg:  config.OverrideWindowConfig{Name: "batch", Zone: "yellow", Start: "22:30", Duration: 2 * time.Hour, Days: []string{"Mon", "fri"}},
+			want: Window{Name: "batch", Zone: stochastic.StatusYellow, Start: 22*time.Hour + 30*time.Minute, Duration: 2 * time.Hour, Days: []time.Weekday{time.Monday, time.Friday}},
+		},
+		{name: "BadZone", cfg: config.OverrideWindowConfig{Zone: "blue", Start: "01:00", Duration: time.Hour}, wantErr: true},
+		{name: "BadStart", cfg: config.OverrideWindowConfig{Zone: "red", Start: "25:00", Duration: time.Hour}, wantErr: true},
+		{name: "BadDay", cfg: config.OverrideWindowConfig{Zone: "red", Start: "01:00", Duration: time.Hour, Days: []string{"someday"}}, wantErr: true},
+		{name: "NoDuration", cfg: config.OverrideWindowConfig{Zone: "red", Start: "01:00"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildWindows([]config.OverrideWindowConfig{tt.cfg})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildWindows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got[0], tt.want) {
+				t.Errorf("BuildWindows() = %+v, want %+v", got[0], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package somatic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sungp/gophership/internal/stochastic"
+)
+
+// Policy configures how buffer occupancy maps to zones. Watermarks are
+// fractions of buffer capacity (0-1): a zone is entered above its Enter mark
+// and left below its Exit mark, so occupancy between the two holds the
+// current zone.
+type Policy struct {
+	YellowEnter, YellowExit float64
+	RedEnter, RedExit       float64
+
+	// Dwell is the minimum time spent in a zone, indexed by zone, before the
+	// controller may leave it.
+	Dwell [3]time.Duration
+	// MaxTransitions caps transitions within any RateWindow. Zero disables
+	// the limit.
+	MaxTransitions int
+	RateWindow     time.Duration
+
+	// Prediction enters Yellow early when occupancy's rate of change
+	// projects reaching RedEnter within its horizon.
+	Prediction stochastic.PredictionConfig
+}
+
+// DefaultPolicy keeps the original 85% watermark for entering Red and 20%
+// for returning to Green, with a Yellow band in between. It has no dwell or
+// rate limit; deployments get those from the config defaults.
+func DefaultPolicy() Policy {
+	return Policy{
+		YellowEnter: 0.60,
+		YellowExit:  0.20,
+		RedEnter:    0.85,
+		RedExit:     0.40,
+	}
+}
+
+// Validate checks that each zone's exit mark lies below its enter mark and
+// that Red is entered above Yellow.
+func (p Policy) Validate() error {
+	for _, f := range []float64{p.YellowEnter, p.YellowExit, p.RedEnter, p.RedExit} {
+		if f < 0 || f > 1 {
+			return fmt.Errorf("somatic watermarks must be between 0 and 1, got %v", f)
+		}
+	}
+	if p.YellowExit >= p.YellowEnter {
+		return fmt.Errorf("yellow exit watermark %v must be below yellow enter %v", p.YellowExit, p.YellowEnter)
+	}
+	if p.RedExit >= p.RedEnter {
+		return fmt.Errorf("red exit watermark %v must be below red enter %v", p.RedExit, p.RedEnter)
+	}
+	if p.RedEnter <= p.YellowEnter {
+		return fmt.Errorf("red enter watermark %v must be above yellow enter %v", p.RedEnter, p.YellowEnter)
+	}
+	for _, d := range p.Dwell {
+		if d < 0 {
+			return fmt.Errorf("somatic dwell must not be negative, got %s", d)
+		}
+	}
+	if p.Prediction.Horizon < 0 || p.Prediction.HalfLife < 0 {
+		return fmt.Errorf("somatic prediction horizon and half-life must not be negative")
+	}
+	if p.MaxTransitions < 0 || (p.MaxTransitions > 0 && p.RateWindow <= 0) {
+		return fmt.Errorf("somatic rate limit needs a positive window, got %d per %s", p.MaxTransitions, p.RateWindow)
+	}
+	return nil
+}
+
+// compiledPolicy holds the watermarks in per-mille so Reassess stays on
+// integer math.
+type compiledPolicy struct {
+	Policy
+	yellowEnter, yellowExit int
+	redEnter, redExit       int
+}
+
+func (p Policy) compile() *compiledPolicy {
+	return &compiledPolicy{
+		Policy:      p,
+		yellowEnter: int(p.YellowEnter*1000 + 0.5),
+		yellowExit:  int(p.YellowExit*1000 + 0.5),
+		redEnter:    int(p.RedEnter*1000 + 0.5),
+		redExit:     int(p.RedExit*1000 + 0.5),
+	}
+}
+
+// target returns the zone that occupancy depth/capacity calls for, given
+// the current zone. Zones may be skipped in either direction.
+func (p *compiledPolicy) target(curr stochastic.AmbientStatus, depth, capacity int) stochastic.AmbientStatus {
+	occ := depth * 1000
+	switch {
+	case occ > capacity*p.redEnter:
+		return stochastic.StatusRed
+	case curr == stochastic.StatusRed && occ >= capacity*p.redExit:
+		return stochastic.StatusRed
+	case occ > capacity*p.yellowEnter:
+		return stochastic.StatusYellow
+	case curr != stochastic.StatusGreen && occ >= capacity*p.yellowExit:
+		return stochastic.StatusYellow
+	default:
+		return stochastic.StatusGreen
+	}
+}
+
+// allowed reports whether a transition from curr to next may happen at now,
+// given when curr was entered and the times of recent transitions, oldest
+// first. Escalation to Red is never delayed: shedding late costs more than
+// flapping.
+func (p *compiledPolicy) allowed(curr, next stochastic.AmbientStatus, now, entered time.Time, recent []time.Time) bool {
+	if next == stochastic.StatusRed {
+		return true
+	}
+	if int(curr) < len(p.Dwell) && now.Sub(entered) < p.Dwell[curr] {
+		return false
+	}
+	if p.MaxTransitions > 0 {
+		n := 0
+		for _, t := range recent {
+			if now.Sub(t) < p.RateWindow {
+				n++
+			}
+		}
+		if n >= p.MaxTransitions {
+			return false
+		}
+	}
+	return true
+}
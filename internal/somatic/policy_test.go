@@ -0,0 +1,177 @@
+package somatic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sungp/gophership/internal/stochastic"
+)
+
+func TestPolicy_Target(t *testing.T) {
+	p := DefaultPolicy().compile()
+	green, yellow, red := stochastic.StatusGreen, stochastic.StatusYellow, stochastic.StatusRed
+
+	tests := []struct {
+		name  string
+		curr  stochastic.AmbientStatus
+		depth int // Out of 1000
+		want  stochastic.AmbientStatus
+	}{
+		{"GreenHoldsBelowYellowEnter", green, 600, green},
+		{"GreenToYellow", green, 601, yellow},
+		{"GreenToRed", green, 900, red},
+		{"YellowHoldsInBand", yellow, 300, yellow},
+		{"YellowHoldsAtExit", yellow, 200, yellow},
+		{"YellowToGreen", yellow, 199, green},
+		{"YellowToRed", yellow, 851, red},
+		{"RedHoldsAboveExit", red, 500, red},
+		{"RedToYellow", red, 399, yellow},
+		{"RedHoldsAboveYellowEnter", red, 700, red},
+		{"RedToGreen", red, 100, green},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.target(tt.curr, tt.depth, 1000); got != tt.want {
+				t.Errorf("target(%s, %d/1000) = %s, want %s", tt.curr, tt.depth, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*Policy)
+		wantErr bool
+	}{
+		{"Default", func(*Policy) {}, false},
+		{"YellowExitAboveEnter", func(p *Policy) { p.YellowExit = 0.7 }, true},
+		{"RedExitAtEnter", func(p *Policy) { p.RedExit = 0.85 }, true},
+		{"RedBelowYellow", func(p *Policy) { p.RedEnter = 0.5; p.RedExit = 0.3 }, true},
+		{"OutOfRange", func(p *Policy) { p.RedEnter = 1.5 }, true},
+		{"NegativeDwell", func(p *Policy) { p.Dwell[stochastic.StatusRed] = -time.Second }, true},
+		{"RateLimitWithoutWindow", func(p *Policy) { p.MaxTransitions = 3 }, true},
+		{"RateLimit", func(p *Policy) { p.MaxTransitions = 3; p.RateWindow = time.Minute }, false},
+		{"NegativeHorizon", func(p *Policy) { p.Prediction.Horizon = -time.Second }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := DefaultPolicy()
+			tt.mutate(&p)
+			if err := p.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestController_DwellAndRateLimit(t *testing.T) {
+	stochastic.MustSetAmbientStatus(stochastic.StatusGreen)
+	t.Cleanup(func() { stochastic.MustSetAmbientStatus(stochastic.StatusGreen) })
+
+	policy := DefaultPolicy()
+	policy.Dwell = [3]time.Duration{time.Second, 5 * time.Second, 10 * time.Second}
+	policy.MaxTransitions = 3
+	policy.RateWindow = time.Minute
+
+	type step struct {
+		after time.Duration // Clock advance before reassessing
+		depth int           // Out of 100
+		want  stochastic.AmbientStatus
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "DwellDelaysDeescalation",
+			steps: []step{
+				{2 * time.Second, 70, stochastic.StatusYellow},
+				{time.Second, 10, stochastic.StatusYellow}, // Yellow dwell is 5s
+				{4 * time.Second, 10, stochastic.StatusGreen},
+			},
+		},
+		{
+			name: "RedBypassesDwell",
+			steps: []step{
+				{2 * time.Second, 70, stochastic.StatusYellow},
+				{0, 90, stochastic.StatusRed},
+				{5 * time.Second, 10, stochastic.StatusRed}, // Red dwell is 10s
+				{5 * time.Second, 10, stochastic.StatusGreen},
+			},
+		},
+		{
+			name: "RateLimitStopsFlapping",
+			steps: []step{
+				{2 * time.Second, 70, stochastic.StatusYellow},
+				{5 * time.Second, 10, stochastic.StatusGreen},
+				{time.Second, 70, stochastic.StatusYellow},
+				{5 * time.Second, 10, stochastic.StatusYellow}, // 3 transitions this minute
+				{50 * time.Second, 10, stochastic.StatusGreen},
+				{0, 95, stochastic.StatusRed}, // Red ignores the limit
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockProvider{cap: 100}
+			c := NewController(mock)
+			now := time.Unix(1000, 0)
+			c.now = func() time.Time { return now }
+			c.entered = now
+			if err := c.SetPolicy(policy); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, s := range tt.steps {
+				now = now.Add(s.after)
+				mock.depth = s.depth
+				if got := c.Reassess(); got != s.want {
+					t.Fatalf("step %d (%d%% at +%s): got %s, want %s", i, s.depth, s.after, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestController_Prediction(t *testing.T) {
+	stochastic.MustSetAmbientStatus(stochastic.StatusGreen)
+	t.Cleanup(func() { stochastic.MustSetAmbientStatus(stochastic.StatusGreen) })
+
+	mock := &mockProvider{cap: 100}
+	c := NewController(mock)
+	now := time.Unix(1000, 0)
+	c.now = func() time.Time { return now }
+	c.entered = now
+	policy := DefaultPolicy()
+	policy.Prediction = stochastic.PredictionConfig{Horizon: 10 * time.Second, HalfLife: time.Second}
+	if err := c.SetPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		depth int // Out of 100, one second apart
+		want  stochastic.AmbientStatus
+	}{
+		{10, stochastic.StatusGreen},
+		{20, stochastic.StatusYellow}, // +10%/s reaches 85% in 6.5s
+		{20, stochastic.StatusYellow}, // +5%/s needs 13s, but 20% holds Yellow
+		{10, stochastic.StatusGreen},  // Falling
+	}
+	for i, s := range steps {
+		now = now.Add(time.Second)
+		mock.depth = s.depth
+		if got := c.Reassess(); got != s.want {
+			t.Fatalf("step %d (%d%%): got %s, want %s", i, s.depth, got, s.want)
+		}
+	}
+}
+
+func TestController_SetPolicyRejectsInvalid(t *testing.T) {
+	c := NewController(&mockProvider{cap: 100})
+	bad := DefaultPolicy()
+	bad.YellowExit = 0.9
+	if err := c.SetPolicy(bad); err == nil {
+		t.Fatal("SetPolicy accepted an exit watermark above its enter watermark")
+	}
+}
@@ -0,0 +1,216 @@
+package stochastic
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Sensors that vote on the somatic zone.
+const (
+	SensorMemory     = "memory"
+	SensorCPU        = "cpu"
+	SensorPSI        = "psi"
+	SensorIngester   = "ingester"
+	SensorVault      = "vault"
+	SensorProcessor  = "processor"
+	SensorDownstream = "downstream"
+	SensorDisk       = "disk"
+	SensorBuffer     = "buffer"
+	SensorOverride   = "override"
+)
+
+// Vote is one sensor's view of the zone the engine should be in.
+type Vote struct {
+	Sensor string        `json:"sensor"`
+	Status AmbientStatus `json:"status"`
+	Reason string        `json:"reason,omitempty"`
+	// Value is the sensor's reading behind the vote, e.g. a utilisation or
+	// occupancy ratio.
+	Value float64 `json:"value"`
+}
+
+// Decision is the arbitrated zone and the vote that drove it.
+type Decision struct {
+	Status AmbientStatus
+	Sensor string // Empty while every sensor votes Green
+	Reason string
+	Since  time.Time
+}
+
+// Arbiter is the single writer of the global ambient status. Sensors cast
+// votes; the zone is the most severe vote, and a manual override beats
+// every sensor. Votes persist until the sensor votes again or is forgotten.
+type Arbiter struct {
+	rt       *Runtime // Runtime whose status the arbiter publishes
+	mu       sync.Mutex
+	votes    map[string]Vote
+	order    []string // Sensors in first-vote order, for stable tie-breaking
+	override *Vote
+	decision Decision
+	history  *History
+}
+
+// Zones arbitrates the default runtime's ambient status.
+var Zones = Default.Zones()
+
+// NewArbiter creates an arbiter with no votes, deciding Green, that publishes
+// to the default runtime. Runtime.Zones is the arbiter of another runtime.
+func NewArbiter() *Arbiter {
+	return newArbiter(Default)
+}
+
+func newArbiter(rt *Runtime) *Arbiter {
+	return &Arbiter{
+		rt:       rt,
+		votes:    make(map[string]Vote),
+		decision: Decision{Status: StatusGreen, Since: time.Now()},
+		history:  NewHistory(DefaultHistorySize),
+	}
+}
+
+// SetHistory replaces the transition history, e.g. with a persisted one.
+func (a *Arbiter) SetHistory(h *History) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.history = h
+}
+
+// History returns the record of zone transitions.
+func (a *Arbiter) History() *History {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.history
+}
+
+// Cast records votes and republishes the zone. It returns the new decision.
+func (a *Arbiter) Cast(votes ...Vote) Decision {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, v := range votes {
+		if _, ok := a.votes[v.Sensor]; !ok {
+			a.order = append(a.order, v.Sensor)
+		}
+		a.votes[v.Sensor] = v
+		a.rt.votes.WithLabelValues(v.Sensor).Set(float64(v.Status))
+	}
+	return a.decideLocked()
+}
+
+// Forget drops a sensor's vote.
+func (a *Arbiter) Forget(sensor string) Decision {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.votes[sensor]; ok {
+		delete(a.votes, sensor)
+		for i, s := range a.order {
+			if s == sensor {
+				a.order = append(a.order[:i], a.order[i+1:]...)
+				break
+			}
+		}
+		a.rt.votes.DeleteLabelValues(sensor)
+	}
+	return a.decideLocked()
+}
+
+// Override pins the zone regardless of sensor votes until ClearOverride.
+func (a *Arbiter) Override(status AmbientStatus, reason string) Decision {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.override = &Vote{Sensor: SensorOverride, Status: status, Reason: reason}
+	return a.decideLocked()
+}
+
+// ClearOverride returns control to the sensors.
+func (a *Arbiter) ClearOverride() Decision {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.override = nil
+	return a.decideLocked()
+}
+
+// Decision returns the current zone and the sensor that drove it.
+func (a *Arbiter) Decision() Decision {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.decision
+}
+
+// Votes returns the current votes in first-vote order.
+func (a *Arbiter) Votes() []Vote {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	out := make([]Vote, 0, len(a.order))
+	for _, s := range a.order {
+		out = append(out, a.votes[s])
+	}
+	return out
+}
+
+// snapshotLocked copies the votes, and the override if any, in first-vote order.
+func (a *Arbiter) snapshotLocked() []Vote {
+	out := make([]Vote, 0, len(a.order)+1)
+	if a.override != nil {
+		out = append(out, *a.override)
+	}
+	for _, s := range a.order {
+		out = append(out, a.votes[s])
+	}
+	return out
+}
+
+// decideLocked computes the zone and publishes it if it changed. When
+// several sensors share the worst vote, the current driver keeps the
+// decision so the reported cause does not flap.
+func (a *Arbiter) decideLocked() Decision {
+	var next Vote
+	if a.override != nil {
+		next = *a.override
+	} else {
+		next = Vote{Status: StatusGreen}
+		for _, s := range a.order {
+			v := a.votes[s]
+			if v.Status > next.Status || (v.Status == next.Status && v.Status != StatusGreen && s == a.decision.Sensor) {
+				next = v
+			}
+		}
+	}
+
+	prev := a.decision
+	if next.Status != prev.Status || next.Sensor != prev.Sensor {
+		a.decision = Decision{Status: next.Status, Sensor: next.Sensor, Reason: next.Reason, Since: prev.Since}
+		if next.Status != prev.Status {
+			a.decision.Since = time.Now()
+			a.history.Record(Transition{
+				At:     a.decision.Since,
+				From:   prev.Status,
+				To:     next.Status,
+				Sensor: next.Sensor,
+				Reason: next.Reason,
+				Votes:  a.snapshotLocked(),
+			})
+		}
+	} else {
+		a.decision.Reason = next.Reason
+	}
+
+	// Compare against the published state rather than prev, which may have
+	// been set directly (e.g. by tests) since the last decision.
+	if published := a.rt.Status(); published != next.Status {
+		log.Warn().
+			Str("prev", published.String()).
+			Str("curr", next.Status.String()).
+			Str("sensor", next.Sensor).
+			Str("reason", next.Reason).
+			Msg("Somatic zone transition")
+		a.rt.SetStatus(next.Status)
+	}
+	return a.decision
+}
@@ -0,0 +1,87 @@
+package stochastic
+
+import "testing"
+
+func TestArbiter_Decide(t *testing.T) {
+	MustSetAmbientStatus(StatusGreen)
+	t.Cleanup(func() { MustSetAmbientStatus(StatusGreen) })
+
+	tests := []struct {
+		name       string
+		votes      []Vote
+		wantStatus AmbientStatus
+		wantSensor string
+	}{
+		{"NoVotes", nil, StatusGreen, ""},
+		{"AllGreen", []Vote{{Sensor: SensorMemory}, {Sensor: SensorBuffer}}, StatusGreen, ""},
+		{"WorstWins", []Vote{
+			{Sensor: SensorMemory, Status: StatusYellow, Reason: "mem"},
+			{Sensor: SensorBuffer, Status: StatusRed, Reason: "buf"},
+			{Sensor: SensorCPU, Status: StatusYellow},
+		}, StatusRed, SensorBuffer},
+		{"TieGoesToFirstVoter", []Vote{
+			{Sensor: SensorCPU, Status: StatusYellow},
+			{Sensor: SensorDownstream, Status: StatusYellow},
+		}, StatusYellow, SensorCPU},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewArbiter()
+			d := a.Cast(tt.votes...)
+			if d.Status != tt.wantStatus || d.Sensor != tt.wantSensor {
+				t.Errorf("decision = %s by %q, want %s by %q", d.Status, d.Sensor, tt.wantStatus, tt.wantSensor)
+			}
+			if got := GetAmbientStatus(); got != tt.wantStatus {
+				t.Errorf("published status = %s, want %s", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestArbiter_Lifecycle(t *testing.T) {
+	MustSetAmbientStatus(StatusGreen)
+	t.Cleanup(func() { MustSetAmbientStatus(StatusGreen) })
+
+	a := NewArbiter()
+
+	// The two writers that used to race: resource sensing reports Green while
+	// the buffer reports Red. Neither overwrites the other.
+	a.Cast(Vote{Sensor: SensorBuffer, Status: StatusRed, Reason: "Ingestion buffer occupancy"})
+	a.Cast(Vote{Sensor: SensorMemory, Status: StatusGreen}, Vote{Sensor: SensorCPU, Status: StatusGreen})
+	if d := a.Decision(); d.Status != StatusRed || d.Sensor != SensorBuffer || d.Reason != "Ingestion buffer occupancy" {
+		t.Fatalf("decision = %+v, want Red by buffer", d)
+	}
+
+	// A later sensor reaching the same level does not take over as driver.
+	a.Cast(Vote{Sensor: SensorMemory, Status: StatusRed})
+	if d := a.Decision(); d.Sensor != SensorBuffer {
+		t.Errorf("driver = %q, want buffer to keep the decision", d.Sensor)
+	}
+	a.Cast(Vote{Sensor: SensorBuffer, Status: StatusGreen})
+	if d := a.Decision(); d.Status != StatusRed || d.Sensor != SensorMemory {
+		t.Errorf("decision = %+v, want Red by memory", d)
+	}
+
+	// An override beats every sensor until cleared.
+	a.Override(StatusGreen, "Manual override")
+	if d := a.Decision(); d.Status != StatusGreen || d.Sensor != SensorOverride || GetAmbientStatus() != StatusGreen {
+		t.Errorf("decision under override = %+v, published %s", d, GetAmbientStatus())
+	}
+	a.Cast(Vote{Sensor: SensorCPU, Status: StatusRed})
+	if GetAmbientStatus() != StatusGreen {
+		t.Error("a sensor vote overrode the manual override")
+	}
+	a.ClearOverride()
+	if d := a.Decision(); d.Status != StatusRed || d.Sensor != SensorMemory {
+		t.Errorf("decision after clearing = %+v, want Red by memory", d)
+	}
+
+	a.Forget(SensorMemory)
+	a.Forget(SensorCPU)
+	if d := a.Decision(); d.Status != StatusGreen || GetAmbientStatus() != StatusGreen {
+		t.Errorf("decision after forgetting = %+v", d)
+	}
+	if votes := a.Votes(); len(votes) != 1 || votes[0].Sensor != SensorBuffer {
+		t.Errorf("votes = %+v, want only buffer", votes)
+	}
+}
@@ -0,0 +1,249 @@
+package stochastic
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CPUSampleInterval is how often the background sampler reads CPU counters.
+const CPUSampleInterval = 2 * time.Second
+
+// clockTicks is USER_HZ, the unit of /proc/self/stat times. It is 100 on
+// every mainstream Linux architecture and cannot be queried without cgo.
+const clockTicks = 100
+
+// cpuCounters is one snapshot of the cumulative CPU counters.
+type cpuCounters struct {
+	at time.Time
+
+	hasHost             bool
+	hostTotal, hostIdle uint64 // Jiffies across all CPUs
+
+	hasProc   bool
+	procTicks uint64 // utime + stime of this process
+
+	hasCgroup   bool
+	cgUsageUsec uint64
+	cgPeriods   uint64
+	cgThrottled uint64
+	quotaCPUs   float64 // cpu.max quota in CPUs; 0 when unlimited
+}
+
+// cpuUsage is the utilisation between two snapshots, as ratios in [0, 1].
+type cpuUsage struct {
+	// Process is this process (or its cgroup, when one is detected) against
+	// the CPU capacity available to it: the cgroup quota, or every CPU.
+	Process float64
+	// Host is whole-machine utilisation from /proc/stat.
+	Host float64
+	// Throttled is the fraction of cgroup enforcement periods throttled.
+	Throttled float64
+}
+
+// score is the pressure score in percent: the worst of the three signals.
+func (u cpuUsage) score() uint64 {
+	s := max(u.Process, u.Host, u.Throttled) * 100
+	return uint64(min(max(s, 0), 100) + 0.5)
+}
+
+// cpuDelta computes utilisation between prev and cur.
+func cpuDelta(prev, cur cpuCounters, numCPU int) cpuUsage {
+	var u cpuUsage
+	wall := cur.at.Sub(prev.at).Seconds()
+	if wall <= 0 {
+		return u
+	}
+	capacity := float64(numCPU)
+	if cur.quotaCPUs > 0 && cur.quotaCPUs < capacity {
+		capacity = cur.quotaCPUs
+	}
+
+	if prev.hasHost && cur.hasHost && cur.hostTotal > prev.hostTotal {
+		total := float64(cur.hostTotal - prev.hostTotal)
+		idle := float64(sub(cur.hostIdle, prev.hostIdle))
+		u.Host = clampRatio(1 - idle/total)
+	}
+	switch {
+	case prev.hasCgroup && cur.hasCgroup:
+		used := float64(sub(cur.cgUsageUsec, prev.cgUsageUsec)) / 1e6
+		u.Process = clampRatio(used / wall / capacity)
+		if periods := sub(cur.cgPeriods, prev.cgPeriods); periods > 0 {
+			u.Throttled = clampRatio(float64(sub(cur.cgThrottled, prev.cgThrottled)) / float64(periods))
+		}
+	case prev.hasProc && cur.hasProc:
+		used := float64(sub(cur.procTicks, prev.procTicks)) / clockTicks
+		u.Process = clampRatio(used / wall / capacity)
+	}
+	return u
+}
+
+func sub(a, b uint64) uint64 {
+	if a < b {
+		return 0 // Counter reset (e.g. cgroup recreated)
+	}
+	return a - b
+}
+
+func clampRatio(f float64) float64 {
+	return min(max(f, 0), 1)
+}
+
+// cpuReader reads CPU counters from procfs and the cgroup v2 hierarchy.
+type cpuReader struct {
+	procRoot   string // Usually /proc
+	cgroupRoot string // Usually /sys/fs/cgroup
+	cgroupDir  string // Resolved cgroup of this process; empty if none
+}
+
+func newCPUReader(procRoot, cgroupRoot string) *cpuReader {
+	r := &cpuReader{procRoot: procRoot, cgroupRoot: cgroupRoot}
+	if data, err := os.ReadFile(filepath.Join(procRoot, "self", "cgroup")); err == nil {
+		if p, ok := cgroupV2Path(data); ok {
+			dir := filepath.Join(cgroupRoot, p)
+			if _, err := os.Stat(filepath.Join(dir, "cpu.stat")); err == nil {
+				r.cgroupDir = dir
+			}
+		}
+	}
+	return r
+}
+
+// read takes a snapshot. It fails only when no source is readable.
+func (r *cpuReader) read(now time.Time) (cpuCounters, error) {
+	c := cpuCounters{at: now}
+	if data, err := os.ReadFile(filepath.Join(r.procRoot, "stat")); err == nil {
+		c.hostTotal, c.hostIdle, err = parseProcStat(data)
+		c.hasHost = err == nil
+	}
+	if data, err := os.ReadFile(filepath.Join(r.procRoot, "self", "stat")); err == nil {
+		c.procTicks, err = parseSelfStat(data)
+		c.hasProc = err == nil
+	}
+	if r.cgroupDir != "" {
+		if data, err := os.ReadFile(filepath.Join(r.cgroupDir, "cpu.stat")); err == nil {
+			c.cgUsageUsec, c.cgPeriods, c.cgThrottled, err = parseCgroupCPUStat(data)
+			c.hasCgroup = err == nil
+		}
+		if data, err := os.ReadFile(filepath.Join(r.cgroupDir, "cpu.max")); err == nil {
+			c.quotaCPUs = parseCPUMax(data)
+		}
+	}
+	if !c.hasHost && !c.hasProc && !c.hasCgroup {
+		return c, fmt.Errorf("no CPU counters under %s", r.procRoot)
+	}
+	return c, nil
+}
+
+// cgroupV2Path extracts the unified hierarchy path ("0::/path") from /proc/self/cgroup.
+func cgroupV2Path(data []byte) (string, bool) {
+	for _, line := range strings.Split(string(data), "\n") {
+		if p, ok := strings.CutPrefix(line, "0::"); ok {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// parseProcStat returns total and idle (idle + iowait) jiffies from the
+// aggregate "cpu" line. guest time is already included in user time.
+func parseProcStat(data []byte) (total, idle uint64, err error) {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) < 5 || fields[0] != "cpu" {
+		return 0, 0, fmt.Errorf("unexpected /proc/stat format")
+	}
+	for i, f := range fields[1:] {
+		if i >= 8 { // user nice system idle iowait irq softirq steal
+			break
+		}
+		v, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		total += v
+		if i == 3 || i == 4 {
+			idle += v
+		}
+	}
+	return total, idle, nil
+}
+
+// parseSelfStat returns utime + stime in clock ticks. The command name may
+// contain spaces and parentheses, so fields are counted after the last ')'.
+func parseSelfStat(data []byte) (uint64, error) {
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return 0, fmt.Errorf("unexpected /proc/self/stat format")
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	// fields[0] is state (field 3); utime and stime are fields 14 and 15.
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("unexpected /proc/self/stat format")
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return utime + stime, nil
+}
+
+// parseCgroupCPUStat reads usage_usec, nr_periods and nr_throttled from cgroup v2 cpu.stat.
+func parseCgroupCPUStat(data []byte) (usage, periods, throttled uint64, err error) {
+	found := false
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		k, v, ok := strings.Cut(sc.Text(), " ")
+		if !ok {
+			continue
+		}
+		n, perr := strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+		if perr != nil {
+			continue
+		}
+		switch k {
+		case "usage_usec":
+			usage, found = n, true
+		case "nr_periods":
+			periods = n
+		case "nr_throttled":
+			throttled = n
+		}
+	}
+	if !found {
+		return 0, 0, 0, fmt.Errorf("usage_usec missing from cpu.stat")
+	}
+	return usage, periods, throttled, nil
+}
+
+// parseCPUMax converts cgroup v2 cpu.max ("<quota> <period>" or "max <period>")
+// to a number of CPUs, or 0 when unlimited.
+func parseCPUMax(data []byte) float64 {
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0
+	}
+	quota, err1 := strconv.ParseFloat(fields[0], 64)
+	period, err2 := strconv.ParseFloat(fields[1], 64)
+	if err1 != nil || err2 != nil || period <= 0 {
+		return 0
+	}
+	return quota / period
+}
+
+// goroutineScore is the fallback when no CPU counters are readable (e.g. on
+// non-Linux hosts): goroutine count as a proxy for scheduler pressure, with
+// more than 1000 goroutines mapping to 100.
+func goroutineScore() uint64 {
+	return min(uint64(runtime.NumGoroutine()/10), 100)
+}
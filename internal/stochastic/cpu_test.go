@@ -0,0 +1,111 @@
+package stochastic
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseCPUFiles(t *testing.T) {
+	total, idle, err := parseProcStat([]byte("cpu  100 5 50 800 20 3 2 0 7 0\ncpu0 50 2 25 400 10 1 1 0 0 0\n"))
+	if err != nil || total != 980 || idle != 820 {
+		t.Errorf("parseProcStat = %d, %d, %v; want 980, 820", total, idle, err)
+	}
+	if _, _, err := parseProcStat([]byte("intr 1 2 3")); err == nil {
+		t.Error("parseProcStat accepted a file without a cpu line")
+	}
+
+	// The command name contains spaces and a parenthesis.
+	ticks, err := parseSelfStat([]byte("1234 (gopher (ship) x) S 1 1234 1234 0 -1 4194560 500 0 0 0 150 30 0 0 20 0 12 0 100 0 0"))
+	if err != nil || ticks != 180 {
+		t.Errorf("parseSelfStat = %d, %v; want 180", ticks, err)
+	}
+
+	usage, periods, throttled, err := parseCgroupCPUStat([]byte("usage_usec 5000000\nuser_usec 4000000\nsystem_usec 1000000\nnr_periods 200\nnr_throttled 50\nthrottled_usec 900000\n"))
+	if err != nil || usage != 5000000 || periods != 200 || throttled != 50 {
+		t.Errorf("parseCgroupCPUStat = %d, %d, %d, %v", usage, periods, throttled, err)
+	}
+
+	for in, want := range map[string]float64{"max 100000\n": 0, "150000 100000\n": 1.5, "garbage": 0} {
+		if got := parseCPUMax([]byte(in)); got != want {
+			t.Errorf("parseCPUMax(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestCPUDelta(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	prev := cpuCounters{at: t0, hasHost: true, hostTotal: 1000, hostIdle: 800, hasProc: true, procTicks: 100}
+	cur := cpuCounters{at: t0.Add(2 * time.Second), hasHost: true, hostTotal: 1800, hostIdle: 1000, hasProc: true, procTicks: 500}
+
+	// Process: 4 CPU-seconds over 2s on 4 CPUs = 50%. Host: 1 - 200/800 = 75%.
+	u := cpuDelta(prev, cur, 4)
+	if math.Abs(u.Process-0.5) > 1e-9 || math.Abs(u.Host-0.75) > 1e-9 || u.Throttled != 0 {
+		t.Errorf("cpuDelta = %+v", u)
+	}
+	if u.score() != 75 {
+		t.Errorf("score = %d, want 75", u.score())
+	}
+
+	// A cgroup takes precedence over the process counters and is measured
+	// against its quota: 1.5 CPU-seconds/s against a 2-CPU quota is 75%.
+	prev.hasCgroup, prev.cgUsageUsec, prev.cgPeriods, prev.cgThrottled = true, 0, 100, 10
+	cur.hasCgroup, cur.cgUsageUsec, cur.cgPeriods, cur.cgThrottled = true, 3000000, 200, 100
+	cur.quotaCPUs = 2
+	u = cpuDelta(prev, cur, 8)
+	if math.Abs(u.Process-0.75) > 1e-9 || math.Abs(u.Throttled-0.9) > 1e-9 {
+		t.Errorf("cgroup cpuDelta = %+v", u)
+	}
+	if u.score() != 90 {
+		t.Errorf("throttling should dominate the score: %d", u.score())
+	}
+}
+
+func TestCPUReader(t *testing.T) {
+	dir := t.TempDir()
+	proc, cg := filepath.Join(dir, "proc"), filepath.Join(dir, "cgroup")
+	write := func(p, data string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(proc, "stat"), "cpu  100 0 100 800 0 0 0 0 0 0\n")
+	write(filepath.Join(proc, "self", "stat"), "1 (gs) S 0 0 0 0 0 0 0 0 0 0 10 5 0 0\n")
+	write(filepath.Join(proc, "self", "cgroup"), "0::/kubepods/pod1/ctr\n")
+	write(filepath.Join(cg, "kubepods", "pod1", "ctr", "cpu.stat"), "usage_usec 100\nnr_periods 4\nnr_throttled 1\n")
+	write(filepath.Join(cg, "kubepods", "pod1", "ctr", "cpu.max"), "50000 100000\n")
+
+	c, err := newCPUReader(proc, cg).read(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.hasHost || c.hostTotal != 1000 || !c.hasProc || c.procTicks != 15 || !c.hasCgroup || c.cgPeriods != 4 || c.quotaCPUs != 0.5 {
+		t.Errorf("read = %+v", c)
+	}
+
+	if _, err := newCPUReader(filepath.Join(dir, "missing"), cg).read(time.Now()); err == nil {
+		t.Error("read succeeded without any counters")
+	}
+}
+
+func TestSensingMonitor_CPUThresholds(t *testing.T) {
+	m := NewSensingMonitor(1024, 1<<40, 0.8, 0.95, 0, 0)
+	m.cpuLoad.Store(60)
+	if got := m.checkCPU(); got != StatusGreen {
+		t.Errorf("60%% with defaults = %s, want GREEN", got)
+	}
+	m.SetCPUThresholds(0.5, 0.7)
+	if got := m.checkCPU(); got != StatusYellow {
+		t.Errorf("60%% with 50/70 thresholds = %s, want YELLOW", got)
+	}
+	m.cpuLoad.Store(70)
+	if got := m.checkCPU(); got != StatusRed {
+		t.Errorf("70%% with 50/70 thresholds = %s, want RED", got)
+	}
+}
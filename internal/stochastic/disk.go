@@ -0,0 +1,122 @@
+package stochastic
+
+import (
+	"math"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DiskSampleInterval is how often the background sampler checks free space.
+const DiskSampleInterval = time.Second
+
+// Default disk thresholds on the share of a filesystem still available. The
+// vault is the reflex path's last resort, so the engine yields well before
+// its filesystem fills.
+const (
+	DefaultDiskYellowFree = 0.15
+	DefaultDiskRedFree    = 0.05
+)
+
+// DiskConfig configures the disk space sensor. Thresholds are free shares
+// (0-1); zero keeps the default.
+type DiskConfig struct {
+	YellowFree, RedFree float64
+}
+
+func (c DiskConfig) withDefaults() DiskConfig {
+	if c.YellowFree <= 0 {
+		c.YellowFree = DefaultDiskYellowFree
+	}
+	if c.RedFree <= 0 {
+		c.RedFree = DefaultDiskRedFree
+	}
+	return c
+}
+
+// status classifies the lowest free share across watched filesystems.
+func (c DiskConfig) status(free float64) AmbientStatus {
+	switch {
+	case free <= c.RedFree:
+		return StatusRed
+	case free <= c.YellowFree:
+		return StatusYellow
+	default:
+		return StatusGreen
+	}
+}
+
+// WatchDisk adds a directory whose filesystem the disk sensor votes on, e.g.
+// a vault WAL directory. Watching the same directory twice is a no-op.
+func (m *SensingMonitor) WatchDisk(dir string) {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	m.diskMu.Lock()
+	defer m.diskMu.Unlock()
+	for _, p := range m.diskPaths {
+		if p == dir {
+			return
+		}
+	}
+	m.diskPaths = append(m.diskPaths, dir)
+}
+
+// EnableDisk starts the disk space sensor. It is a no-op where free space
+// cannot be read (non-Linux builds).
+func (m *SensingMonitor) EnableDisk(cfg DiskConfig) {
+	cfg = cfg.withDefaults()
+	if !diskSupported {
+		log.Info().Msg("Disk space unavailable on this platform; disk sensing disabled")
+		return
+	}
+	m.sampleDisk(cfg)
+	m.diskEnabled.Store(true)
+	go func() {
+		ticker := time.NewTicker(DiskSampleInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			m.sampleDisk(cfg)
+		}
+	}()
+}
+
+// sampleDisk reads free space of every watched directory and records the
+// worst. Directories that cannot be read are skipped.
+func (m *SensingMonitor) sampleDisk(cfg DiskConfig) {
+	m.diskMu.Lock()
+	paths := append([]string(nil), m.diskPaths...)
+	m.diskMu.Unlock()
+
+	lowest, worst := 1.0, ""
+	for _, p := range paths {
+		avail, total, err := m.statfs(p)
+		if err != nil || total == 0 {
+			continue
+		}
+		free := float64(avail) / float64(total)
+		DiskFreeRatio.WithLabelValues(p).Set(free)
+		if free < lowest {
+			lowest, worst = free, p
+		}
+	}
+	m.diskUsed.Store(math.Float64bits(1 - lowest))
+	m.diskWorst.Store(&worst)
+	m.diskStatus.Store(uint32(cfg.status(lowest)))
+}
+
+func (m *SensingMonitor) checkDisk() AmbientStatus {
+	if !m.diskEnabled.Load() {
+		return StatusGreen
+	}
+	return AmbientStatus(m.diskStatus.Load())
+}
+
+// diskReason names the fullest watched directory.
+func (m *SensingMonitor) diskReason() string {
+	if p := m.diskWorst.Load(); p != nil && *p != "" {
+		return "Disk space low on " + *p
+	}
+	return "Disk space low"
+}
@@ -0,0 +1,18 @@
+//go:build linux
+// +build linux
+
+package stochastic
+
+import "syscall"
+
+const diskSupported = true
+
+// statfs returns the bytes available to unprivileged users and the total
+// size of the filesystem holding path.
+func statfs(path string) (avail, total uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	return st.Bavail * uint64(st.Bsize), st.Blocks * uint64(st.Bsize), nil
+}
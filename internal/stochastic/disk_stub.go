@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package stochastic
+
+import "errors"
+
+const diskSupported = false
+
+// statfs is unsupported on non-linux platforms.
+func statfs(path string) (avail, total uint64, err error) {
+	return 0, 0, errors.New("disk space sensing requires Linux")
+}
@@ -0,0 +1,78 @@
+package stochastic
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDiskConfig_Status(t *testing.T) {
+	cfg := DiskConfig{}.withDefaults()
+	tests := []struct {
+		name string
+		free float64
+		want AmbientStatus
+	}{
+		{"Plenty", 0.50, StatusGreen},
+		{"AboveYellow", 0.16, StatusGreen},
+		{"AtYellow", 0.15, StatusYellow},
+		{"AboveRed", 0.06, StatusYellow},
+		{"AtRed", 0.05, StatusRed},
+		{"Full", 0, StatusRed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.status(tt.free); got != tt.want {
+				t.Errorf("status(%.2f) = %s, want %s", tt.free, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSensingMonitor_DiskVote(t *testing.T) {
+	t.Parallel()
+
+	rt := NewRuntime()
+	m := NewSensingMonitor(1, 1<<40, 0.8, 0.9, 0, 0)
+	rt.SetMonitor(m)
+
+	free := map[string]uint64{"/vault": 50, "/fallback": 50}
+	m.statfs = func(path string) (uint64, uint64, error) {
+		avail, ok := free[path]
+		if !ok {
+			return 0, 0, errors.New("no such directory")
+		}
+		return avail, 100, nil
+	}
+	m.WatchDisk("/vault")
+	m.WatchDisk("/fallback")
+	m.WatchDisk("/vault")
+	m.WatchDisk("/missing")
+	m.EnableDisk(DiskConfig{})
+
+	diskVote := func() Vote {
+		for _, v := range rt.Zones().Votes() {
+			if v.Sensor == SensorDisk {
+				return v
+			}
+		}
+		t.Fatal("no disk vote")
+		return Vote{}
+	}
+
+	m.MustSense()
+	if v := diskVote(); v.Status != StatusGreen {
+		t.Errorf("half-free disks voted %s, want GREEN", v.Status)
+	}
+
+	// The fullest filesystem drives the vote and names the directory.
+	free["/fallback"] = 3
+	m.sampleDisk(DiskConfig{}.withDefaults())
+	m.MustSense()
+	v := diskVote()
+	if v.Status != StatusRed || v.Reason != "Disk space low on /fallback" || v.Value != 0.97 {
+		t.Errorf("full fallback disk voted %+v, want RED on /fallback at 0.97", v)
+	}
+	if got := rt.Status(); got != StatusRed {
+		t.Errorf("runtime status = %s, want RED", got)
+	}
+}
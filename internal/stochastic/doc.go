@@ -1,4 +1,7 @@
 // Package stochastic provides lazy, atomic environmental awareness for GopherShip.
 // It allows performance-critical modules to check global system state (pressure zones)
 // without incurring the cost of constant cache-line contention or heavy locking.
+//
+// That state belongs to a Runtime; the package-level functions operate on
+// Default.
 package stochastic
@@ -0,0 +1,106 @@
+package stochastic
+
+import (
+	"sort"
+	"time"
+)
+
+// DownstreamState mirrors the circuit breaker state of an exporter.
+type DownstreamState uint32
+
+const (
+	DownstreamHealthy  DownstreamState = 0 // Breaker closed: exports flowing
+	DownstreamProbing  DownstreamState = 1 // Breaker half-open: trial export in flight
+	DownstreamDegraded DownstreamState = 2 // Breaker open: exports diverted to the vault
+)
+
+func (s DownstreamState) String() string {
+	switch s {
+	case DownstreamHealthy:
+		return "HEALTHY"
+	case DownstreamProbing:
+		return "PROBING"
+	case DownstreamDegraded:
+		return "DOWN"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// DownstreamHealth is a point-in-time view of one exporter's delivery health.
+type DownstreamHealth struct {
+	Name                string
+	State               DownstreamState
+	ConsecutiveFailures uint32
+	LastError           string
+	Since               time.Time
+}
+
+// ReportDownstream records the health of a named exporter. It is called on
+// breaker transitions, not per export, so the mutex stays off the hot path.
+func (m *SensingMonitor) ReportDownstream(h DownstreamHealth) {
+	m.downstreamMu.Lock()
+	defer m.downstreamMu.Unlock()
+
+	if m.downstreams == nil {
+		m.downstreams = make(map[string]DownstreamHealth)
+	}
+	m.downstreams[h.Name] = h
+
+	var down int32
+	for _, d := range m.downstreams {
+		if d.State == DownstreamDegraded {
+			down++
+		}
+	}
+	m.downstreamsDown.Store(down)
+}
+
+// ReportDownstreamError attaches the most recent failure to a reported
+// exporter. The update happens under the same lock as ReportDownstream, so
+// it cannot overwrite a newer breaker transition with an older state.
+// Exporters that have not reported yet are ignored.
+func (m *SensingMonitor) ReportDownstreamError(name, lastError string) {
+	m.downstreamMu.Lock()
+	defer m.downstreamMu.Unlock()
+
+	if d, ok := m.downstreams[name]; ok {
+		d.LastError = lastError
+		m.downstreams[name] = d
+	}
+}
+
+// ForgetDownstream removes an exporter that has been shut down.
+func (m *SensingMonitor) ForgetDownstream(name string) {
+	m.downstreamMu.Lock()
+	defer m.downstreamMu.Unlock()
+
+	if d, ok := m.downstreams[name]; ok {
+		if d.State == DownstreamDegraded {
+			m.downstreamsDown.Add(-1)
+		}
+		delete(m.downstreams, name)
+	}
+}
+
+// Downstreams returns a snapshot of all reported exporters, sorted by name.
+func (m *SensingMonitor) Downstreams() []DownstreamHealth {
+	m.downstreamMu.Lock()
+	defer m.downstreamMu.Unlock()
+
+	out := make([]DownstreamHealth, 0, len(m.downstreams))
+	for _, d := range m.downstreams {
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// checkDownstream escalates to Yellow while any exporter is down: delivery debt
+// is accumulating in the vault, so background work should yield.
+func (m *SensingMonitor) checkDownstream() AmbientStatus {
+	if m.downstreamsDown.Load() > 0 {
+		return StatusYellow
+	}
+	return StatusGreen
+}
@@ -0,0 +1,146 @@
+package stochastic
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultHistorySize is the number of zone transitions kept in memory.
+const DefaultHistorySize = 256
+
+// Transition is one change of the arbitrated zone, with every sensor's vote
+// at that moment.
+type Transition struct {
+	At     time.Time     `json:"at"`
+	From   AmbientStatus `json:"from"`
+	To     AmbientStatus `json:"to"`
+	Sensor string        `json:"sensor,omitempty"`
+	Reason string        `json:"reason,omitempty"`
+	Votes  []Vote        `json:"votes,omitempty"`
+}
+
+// History is a bounded ring of zone transitions. When persisted, the ring is
+// rewritten to a JSON file in the background after each transition so that
+// it survives restarts.
+type History struct {
+	mu   sync.Mutex
+	ring []Transition
+	next int // Slot for the next transition
+	n    int // Transitions held
+
+	path  string
+	dirty chan struct{}
+}
+
+// NewHistory creates a history holding up to size transitions.
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	return &History{ring: make([]Transition, size)}
+}
+
+// Persist loads transitions previously saved at path and saves future ones
+// there. It must be called before the history is in use. A missing file is
+// not an error; an unreadable one is reported, and saving still replaces it.
+func (h *History) Persist(path string) error {
+	h.path = path
+	h.dirty = make(chan struct{}, 1)
+	go h.saveLoop()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var saved []Transition
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	for _, t := range saved {
+		h.add(t)
+	}
+	return nil
+}
+
+// Record appends a transition, evicting the oldest when full.
+func (h *History) Record(t Transition) {
+	h.mu.Lock()
+	h.add(t)
+	h.mu.Unlock()
+
+	if h.dirty != nil {
+		select {
+		case h.dirty <- struct{}{}:
+		default: // A save is already pending and will include t.
+		}
+	}
+}
+
+func (h *History) add(t Transition) {
+	h.ring[h.next] = t
+	h.next = (h.next + 1) % len(h.ring)
+	if h.n < len(h.ring) {
+		h.n++
+	}
+}
+
+// Recent returns up to limit transitions, newest first. A limit of zero or
+// less returns all of them.
+func (h *History) Recent(limit int) []Transition {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if limit <= 0 || limit > h.n {
+		limit = h.n
+	}
+	out := make([]Transition, 0, limit)
+	for i := 1; i <= limit; i++ {
+		out = append(out, h.ring[(h.next-i+len(h.ring))%len(h.ring)])
+	}
+	return out
+}
+
+func (h *History) saveLoop() {
+	for range h.dirty {
+		if err := h.save(); err != nil {
+			log.Warn().Err(err).Str("path", h.path).Msg("Failed to persist zone history")
+		}
+	}
+}
+
+// save writes the ring oldest first, via a temporary file and rename so a
+// crash never leaves a truncated file.
+func (h *History) save() error {
+	recent := h.Recent(0)
+	chronological := make([]Transition, len(recent))
+	for i, t := range recent {
+		chronological[len(recent)-1-i] = t
+	}
+	data, err := json.Marshal(chronological)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), h.path)
+}
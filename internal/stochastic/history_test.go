@@ -0,0 +1,98 @@
+package stochastic
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistory_Ring(t *testing.T) {
+	h := NewHistory(3)
+	if got := h.Recent(0); len(got) != 0 {
+		t.Fatalf("empty history returned %d transitions", len(got))
+	}
+
+	t0 := time.Unix(1000, 0)
+	for i := 0; i < 5; i++ {
+		h.Record(Transition{At: t0.Add(time.Duration(i) * time.Second), To: AmbientStatus(i % 3)})
+	}
+
+	got := h.Recent(0)
+	if len(got) != 3 {
+		t.Fatalf("Recent(0) returned %d transitions, want 3", len(got))
+	}
+	// Newest first; the two oldest were evicted.
+	for i, want := range []int64{1004, 1003, 1002} {
+		if got[i].At.Unix() != want {
+			t.Errorf("Recent(0)[%d] at %d, want %d", i, got[i].At.Unix(), want)
+		}
+	}
+	if got := h.Recent(2); len(got) != 2 || got[0].At.Unix() != 1004 {
+		t.Errorf("Recent(2) = %+v", got)
+	}
+}
+
+func TestArbiter_RecordsTransitions(t *testing.T) {
+	MustSetAmbientStatus(StatusGreen)
+	t.Cleanup(func() { MustSetAmbientStatus(StatusGreen) })
+
+	a := NewArbiter()
+	a.Cast(Vote{Sensor: SensorCPU, Status: StatusGreen, Value: 0.3})
+	a.Cast(Vote{Sensor: SensorMemory, Status: StatusRed, Reason: "Memory usage near limit", Value: 0.97})
+	a.Cast(Vote{Sensor: SensorBuffer, Status: StatusRed, Value: 0.9}) // Driver change only
+	a.Cast(Vote{Sensor: SensorMemory, Status: StatusYellow, Value: 0.85}, Vote{Sensor: SensorBuffer, Status: StatusGreen})
+
+	got := a.History().Recent(0)
+	if len(got) != 2 {
+		t.Fatalf("recorded %d transitions, want 2: %+v", len(got), got)
+	}
+	red := got[1]
+	if red.From != StatusGreen || red.To != StatusRed || red.Sensor != SensorMemory || red.Reason != "Memory usage near limit" {
+		t.Errorf("Red transition = %+v", red)
+	}
+	if len(red.Votes) != 2 || red.Votes[1].Sensor != SensorMemory || red.Votes[1].Value != 0.97 {
+		t.Errorf("Red transition votes = %+v", red.Votes)
+	}
+	if got[0].From != StatusRed || got[0].To != StatusYellow || got[0].Sensor != SensorMemory {
+		t.Errorf("Yellow transition = %+v", got[0])
+	}
+}
+
+func TestHistory_Persist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zones.json")
+
+	h := NewHistory(8)
+	if err := h.Persist(path); err != nil {
+		t.Fatalf("Persist on a missing file: %v", err)
+	}
+	at := time.Unix(1700000000, 0).UTC()
+	h.Record(Transition{At: at, From: StatusGreen, To: StatusRed, Sensor: SensorPSI, Votes: []Vote{{Sensor: SensorPSI, Status: StatusRed, Value: 0.42}}})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("history was not saved")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	restored := NewHistory(8)
+	if err := restored.Persist(path); err != nil {
+		t.Fatalf("Persist: %v", err)
+	}
+	got := restored.Recent(0)
+	if len(got) != 1 || !got[0].At.Equal(at) || got[0].To != StatusRed || got[0].Sensor != SensorPSI || got[0].Votes[0].Value != 0.42 {
+		t.Errorf("restored history = %+v", got)
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewHistory(8).Persist(path); err == nil {
+		t.Error("Persist accepted a corrupt file")
+	}
+}
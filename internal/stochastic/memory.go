@@ -0,0 +1,209 @@
+package stochastic
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxRAM is the memory limit assumed when none is configured and none
+// can be detected.
+const DefaultMaxRAM = 1024 * 1024 * 1024 // 1GB
+
+// cgroupV1Unlimited is the smallest memory.limit_in_bytes treated as "no limit";
+// the kernel reports PAGE_COUNTER_MAX rounded to the page size.
+const cgroupV1Unlimited = 1 << 62
+
+// Memory limit sources reported by detectLimit.
+const (
+	memSourceCgroupMax  = "cgroup memory.max"
+	memSourceCgroupHigh = "cgroup memory.high"
+	memSourceCgroupV1   = "cgroup memory.limit_in_bytes"
+	memSourceHost       = "host MemTotal"
+	memSourceDefault    = "default"
+	memSourceConfig     = "config"
+)
+
+// memReader detects the memory limit of the process's cgroup (v2, falling
+// back to the v1 memory controller) and reads the cgroup's working set.
+type memReader struct {
+	procRoot string // Usually /proc
+	v2Dir    string // Resolved cgroup v2 directory with memory files; empty if none
+	v1Dir    string // Resolved cgroup v1 memory controller directory; empty if none
+}
+
+func newMemReader(procRoot, cgroupRoot string) *memReader {
+	r := &memReader{procRoot: procRoot}
+	data, err := os.ReadFile(filepath.Join(procRoot, "self", "cgroup"))
+	if err != nil {
+		return r
+	}
+	if p, ok := cgroupV2Path(data); ok {
+		dir := filepath.Join(cgroupRoot, p)
+		if _, err := os.Stat(filepath.Join(dir, "memory.current")); err == nil {
+			r.v2Dir = dir
+			return r
+		}
+	}
+	if p, ok := cgroupV1MemoryPath(data); ok {
+		// Inside a cgroup namespace the path is often not visible under the
+		// mount, which is then rooted at the container's own cgroup.
+		for _, dir := range []string{filepath.Join(cgroupRoot, "memory", p), filepath.Join(cgroupRoot, "memory")} {
+			if _, err := os.Stat(filepath.Join(dir, "memory.usage_in_bytes")); err == nil {
+				r.v1Dir = dir
+				break
+			}
+		}
+	}
+	return r
+}
+
+// cgroupLimit returns the cgroup's effective memory limit: the lower of
+// memory.max and memory.high on v2, where reclaim and throttling start at
+// memory.high, or memory.limit_in_bytes on v1. It returns 0 when unlimited.
+func (r *memReader) cgroupLimit() (uint64, string) {
+	switch {
+	case r.v2Dir != "":
+		limit, source := readLimitFile(filepath.Join(r.v2Dir, "memory.max")), memSourceCgroupMax
+		if high := readLimitFile(filepath.Join(r.v2Dir, "memory.high")); high != 0 && (limit == 0 || high < limit) {
+			limit, source = high, memSourceCgroupHigh
+		}
+		return limit, source
+	case r.v1Dir != "":
+		limit := readLimitFile(filepath.Join(r.v1Dir, "memory.limit_in_bytes"))
+		if limit >= cgroupV1Unlimited {
+			limit = 0
+		}
+		return limit, memSourceCgroupV1
+	}
+	return 0, ""
+}
+
+// detectLimit returns the cgroup limit, else the host's total memory, else
+// DefaultMaxRAM, along with where it came from.
+func (r *memReader) detectLimit() (uint64, string) {
+	if limit, source := r.cgroupLimit(); limit != 0 {
+		return limit, source
+	}
+	if data, err := os.ReadFile(filepath.Join(r.procRoot, "meminfo")); err == nil {
+		if total := parseMemInfoTotal(data); total != 0 {
+			return total, memSourceHost
+		}
+	}
+	return DefaultMaxRAM, memSourceDefault
+}
+
+// usage returns the cgroup's working set: charged memory minus inactive file
+// cache, which the kernel reclaims before it would OOM. This is the figure
+// the kubelet and the OOM killer effectively act on.
+func (r *memReader) usage() (uint64, bool) {
+	var current uint64
+	var inactiveKey string
+	var stat []byte
+	switch {
+	case r.v2Dir != "":
+		current = readLimitFile(filepath.Join(r.v2Dir, "memory.current"))
+		inactiveKey = "inactive_file"
+		stat, _ = os.ReadFile(filepath.Join(r.v2Dir, "memory.stat"))
+	case r.v1Dir != "":
+		current = readLimitFile(filepath.Join(r.v1Dir, "memory.usage_in_bytes"))
+		inactiveKey = "total_inactive_file"
+		stat, _ = os.ReadFile(filepath.Join(r.v1Dir, "memory.stat"))
+	default:
+		return 0, false
+	}
+	if current == 0 {
+		return 0, false
+	}
+	if inactive, ok := parseMemoryStat(stat, inactiveKey); ok && inactive < current {
+		current -= inactive
+	}
+	return current, true
+}
+
+// cgroupV1MemoryPath extracts the memory controller's path from a
+// "N:memory:/path" (or "N:a,memory:/path") line of /proc/self/cgroup.
+func cgroupV1MemoryPath(data []byte) (string, bool) {
+	for _, line := range strings.Split(string(data), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		for _, c := range strings.Split(parts[1], ",") {
+			if c == "memory" {
+				return parts[2], true
+			}
+		}
+	}
+	return "", false
+}
+
+// readLimitFile reads a single-number cgroup file, returning 0 for "max" or
+// when the file is missing or malformed.
+func readLimitFile(path string) uint64 {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// parseMemoryStat returns the value of key from a cgroup memory.stat file.
+func parseMemoryStat(data []byte, key string) (uint64, bool) {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		k, v, ok := strings.Cut(sc.Text(), " ")
+		if !ok || k != key {
+			continue
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// parseMemInfoTotal returns MemTotal from /proc/meminfo in bytes.
+func parseMemInfoTotal(data []byte) uint64 {
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		v, ok := strings.CutPrefix(sc.Text(), "MemTotal:")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(v)
+		if len(fields) == 0 {
+			return 0
+		}
+		kb, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return 0
+		}
+		return kb * 1024
+	}
+	return 0
+}
+
+// ApplyGoMemoryLimit sets the Go runtime's soft memory limit (GOMEMLIMIT) to
+// the Red threshold of the memory limit, so the GC works harder as the engine
+// approaches Red instead of letting the heap grow into the cgroup's OOM
+// killer. An explicit GOMEMLIMIT environment variable takes precedence. It
+// returns the limit applied, or 0 if none was.
+func (m *SensingMonitor) ApplyGoMemoryLimit() int64 {
+	if os.Getenv("GOMEMLIMIT") != "" {
+		return 0
+	}
+	limit := int64(float64(m.maxRAM) * m.redRAMPerc)
+	if limit <= 0 {
+		return 0
+	}
+	debug.SetMemoryLimit(limit)
+	return limit
+}
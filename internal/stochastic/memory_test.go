@@ -0,0 +1,153 @@
+package stochastic
+
+import (
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMemReader(t *testing.T) {
+	const meminfo = "MemTotal:       16384000 kB\nMemFree:         1000 kB\n"
+
+	tests := []struct {
+		name       string
+		proc       map[string]string
+		cgroup     map[string]string
+		wantLimit  uint64
+		wantSource string
+		wantUsage  uint64 // 0 when no cgroup usage is available
+	}{
+		{
+			name: "V2Max",
+			proc: map[string]string{"self/cgroup": "0::/kubepods/pod1\n", "meminfo": meminfo},
+			cgroup: map[string]string{
+				"kubepods/pod1/memory.max":     "536870912\n",
+				"kubepods/pod1/memory.high":    "max\n",
+				"kubepods/pod1/memory.current": "300000000\n",
+				"kubepods/pod1/memory.stat":    "anon 200000000\nfile 100000000\ninactive_file 50000000\n",
+			},
+			wantLimit: 536870912, wantSource: memSourceCgroupMax, wantUsage: 250000000,
+		},
+		{
+			name: "V2HighBelowMax",
+			proc: map[string]string{"self/cgroup": "0::/app\n"},
+			cgroup: map[string]string{
+				"app/memory.max":     "max\n",
+				"app/memory.high":    "268435456\n",
+				"app/memory.current": "1000\n",
+			},
+			wantLimit: 268435456, wantSource: memSourceCgroupHigh, wantUsage: 1000,
+		},
+		{
+			name:       "V2Unlimited",
+			proc:       map[string]string{"self/cgroup": "0::/\n", "meminfo": meminfo},
+			cgroup:     map[string]string{"memory.max": "max\n", "memory.current": "1000\n"},
+			wantLimit:  16384000 * 1024,
+			wantSource: memSourceHost, wantUsage: 1000,
+		},
+		{
+			// Hybrid hierarchy: the unified mount has no memory controller and
+			// the namespaced v1 path is not visible under the mount.
+			name: "V1Namespaced",
+			proc: map[string]string{"self/cgroup": "4:memory:/docker/abc\n0::/\n", "meminfo": meminfo},
+			cgroup: map[string]string{
+				"memory/memory.limit_in_bytes": "1073741824\n",
+				"memory/memory.usage_in_bytes": "600000000\n",
+				"memory/memory.stat":           "inactive_file 1\ntotal_inactive_file 100000000\n",
+			},
+			wantLimit: 1073741824, wantSource: memSourceCgroupV1, wantUsage: 500000000,
+		},
+		{
+			name: "V1Unlimited",
+			proc: map[string]string{"self/cgroup": "3:cpuacct,memory:/\n", "meminfo": meminfo},
+			cgroup: map[string]string{
+				"memory/memory.limit_in_bytes": "9223372036854771712\n",
+				"memory/memory.usage_in_bytes": "42\n",
+			},
+			wantLimit: 16384000 * 1024, wantSource: memSourceHost, wantUsage: 42,
+		},
+		{
+			name:      "Nothing",
+			wantLimit: DefaultMaxRAM, wantSource: memSourceDefault,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proc, cgroup := t.TempDir(), t.TempDir()
+			writeFiles(t, proc, tt.proc)
+			writeFiles(t, cgroup, tt.cgroup)
+
+			r := newMemReader(proc, cgroup)
+			limit, source := r.detectLimit()
+			if limit != tt.wantLimit || source != tt.wantSource {
+				t.Errorf("detectLimit = %d (%s), want %d (%s)", limit, source, tt.wantLimit, tt.wantSource)
+			}
+			usage, ok := r.usage()
+			if ok != (tt.wantUsage != 0) || usage != tt.wantUsage {
+				t.Errorf("usage = %d, %v; want %d", usage, ok, tt.wantUsage)
+			}
+		})
+	}
+}
+
+func TestSensingMonitor_CgroupMemory(t *testing.T) {
+	MustSetAmbientStatus(StatusGreen)
+	t.Cleanup(func() { MustSetAmbientStatus(StatusGreen) })
+
+	cgroup := t.TempDir()
+	proc := t.TempDir()
+	writeFiles(t, proc, map[string]string{"self/cgroup": "0::/app\n"})
+	writeFiles(t, cgroup, map[string]string{
+		"app/memory.max":     "1000000\n",
+		"app/memory.current": "850000\n",
+	})
+
+	monitor := NewSensingMonitor(1024, 1<<40, 0.80, 0.95, 0, 0)
+	monitor.cpuYellowPerc, monitor.cpuRedPerc = 101, 101
+	monitor.mem = newMemReader(proc, cgroup)
+	monitor.maxRAM, monitor.memSource = monitor.mem.detectLimit()
+
+	// 85% of the cgroup limit, whatever the Go runtime itself holds.
+	monitor.MustSense()
+	if status := GetAmbientStatus(); status != StatusYellow {
+		t.Errorf("Expected StatusYellow at 85%% of memory.max, got %s", status)
+	}
+	if limit, source := monitor.MemoryLimit(); limit != 1000000 || source != memSourceCgroupMax {
+		t.Errorf("MemoryLimit = %d (%s)", limit, source)
+	}
+}
+
+func TestApplyGoMemoryLimit(t *testing.T) {
+	prev := debug.SetMemoryLimit(-1)
+	t.Cleanup(func() { debug.SetMemoryLimit(prev) })
+	t.Setenv("GOMEMLIMIT", "")
+
+	maxRAM := uint64(1 << 30)
+	monitor := NewSensingMonitor(1024, maxRAM, 0.80, 0.95, 0, 0)
+	want := int64(float64(maxRAM) * 0.95)
+	if got := monitor.ApplyGoMemoryLimit(); got != want {
+		t.Errorf("ApplyGoMemoryLimit = %d, want %d", got, want)
+	}
+	if got := debug.SetMemoryLimit(-1); got != want {
+		t.Errorf("runtime memory limit = %d, want %d", got, want)
+	}
+
+	t.Setenv("GOMEMLIMIT", "2GiB")
+	if got := monitor.ApplyGoMemoryLimit(); got != 0 {
+		t.Errorf("ApplyGoMemoryLimit overrode GOMEMLIMIT: %d", got)
+	}
+}
@@ -16,16 +16,19 @@ var (
 
 	// IngesterZone tracks the current somatic zone.
 	// 0: Green, 1: Yellow, 2: Red.
-	IngesterZone = prometheus.NewGauge(prometheus.GaugeOpts{
-		Name: "gophership_ingester_zone_index",
-		Help: "Current somatic zone index (0: Green, 1: Yellow, 2: Red).",
-	})
+	IngesterZone = newZoneGauge()
+
+	// ZoneVotes tracks each sensor's latest zone vote.
+	ZoneVotes = newZoneVotes()
+
+	// TimeToRedSeconds is each trending sensor's projected time to reach Red.
+	TimeToRedSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gophership_zone_time_to_red_seconds",
+		Help: "Projected time until a sensor reaches Red at its EWMA rate of change; absent while not approaching Red.",
+	}, []string{"sensor"})
 
 	// SomaticPivotsTotal tracks the total number of fallback triggers.
-	SomaticPivotsTotal = prometheus.NewCounter(prometheus.CounterOpts{
-		Name: "gophership_somatic_pivots_total",
-		Help: "Total number of somatic pivots (reflex triggers).",
-	})
+	SomaticPivotsTotal = newSomaticPivots()
 
 	// IngesterUsageBytes tracks active memory usage of the ingester.
 	IngesterUsageBytes = prometheus.NewGauge(prometheus.GaugeOpts{
@@ -38,24 +41,119 @@ var (
 		Name: "gophership_vault_usage_bytes",
 		Help: "Active memory usage of the Vault (WALsegments + blocks) in bytes.",
 	})
+
+	// MemoryLimitBytes is the memory limit the monitor measures against.
+	MemoryLimitBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gophership_memory_limit_bytes",
+		Help: "Memory limit used for somatic zoning: configured, cgroup or host total, in bytes.",
+	})
+
+	// MemoryUsageBytes is the memory usage compared against MemoryLimitBytes.
+	MemoryUsageBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gophership_memory_usage_bytes",
+		Help: "Memory usage used for somatic zoning: cgroup working set or Go runtime footprint, in bytes.",
+	})
+
+	// ProcessCPUUtilization is this process's (or its cgroup's) CPU use against its quota.
+	ProcessCPUUtilization = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gophership_cpu_process_utilization_ratio",
+		Help: "CPU utilisation of the process or its cgroup relative to the available CPU quota (0-1).",
+	})
+
+	// HostCPUUtilization is whole-machine CPU utilisation.
+	HostCPUUtilization = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gophership_cpu_host_utilization_ratio",
+		Help: "Host CPU utilisation from /proc/stat (0-1).",
+	})
+
+	// CPUThrottledRatio is the share of cgroup CPU periods that were throttled.
+	CPUThrottledRatio = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gophership_cpu_throttled_ratio",
+		Help: "Fraction of cgroup CPU enforcement periods in which the process was throttled (0-1).",
+	})
+
+	// PSIStallRatio is the avg10 pressure stall share per resource and kind.
+	PSIStallRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gophership_psi_stall_avg10_ratio",
+		Help: "Share of the last 10s in which some or all tasks stalled on a resource, from PSI (0-1).",
+	}, []string{"resource", "kind"})
+
+	// DiskFreeRatio is the available share of each watched filesystem.
+	DiskFreeRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gophership_disk_free_ratio",
+		Help: "Share of the filesystem holding a watched directory still available (0-1).",
+	}, []string{"path"})
+
+	// PSITriggersTotal counts kernel PSI trigger events.
+	PSITriggersTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gophership_psi_triggers_total",
+		Help: "Total number of kernel PSI memory pressure trigger events.",
+	})
+
+	// ProcessorUsageBytes tracks memory held by processor state such as dedup caches.
+	ProcessorUsageBytes = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gophership_processor_usage_bytes",
+		Help: "Memory held by processor state (e.g. dedup caches) in bytes.",
+	})
 )
 
 func init() {
 	// Register metrics with the custom registry.
 	Registry.MustRegister(IngesterZone)
 	Registry.MustRegister(SomaticPivotsTotal)
+	Registry.MustRegister(ZoneVotes)
+	Registry.MustRegister(TimeToRedSeconds)
 	Registry.MustRegister(IngesterUsageBytes)
 	Registry.MustRegister(VaultUsageBytes)
+	Registry.MustRegister(ProcessorUsageBytes)
+	Registry.MustRegister(MemoryLimitBytes)
+	Registry.MustRegister(MemoryUsageBytes)
+	Registry.MustRegister(ProcessCPUUtilization)
+	Registry.MustRegister(HostCPUUtilization)
+	Registry.MustRegister(CPUThrottledRatio)
+	Registry.MustRegister(PSIStallRatio)
+	Registry.MustRegister(PSITriggersTotal)
+	Registry.MustRegister(DiskFreeRatio)
 
 	// Initialize the zone to Green (0).
 	IngesterZone.Set(0)
 }
 
-// StartMetricsServer starts a Prometheus metrics server on the specified address.
+// newZoneGauge, newZoneVotes and newSomaticPivots create the zone metrics,
+// once per Runtime so that each engine in a process reports its own zone.
+func newZoneGauge() prometheus.Gauge {
+	return prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gophership_ingester_zone_index",
+		Help: "Current somatic zone index (0: Green, 1: Yellow, 2: Red).",
+	})
+}
+
+func newZoneVotes() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gophership_zone_vote_index",
+		Help: "Latest zone vote per sensor (0: Green, 1: Yellow, 2: Red).",
+	}, []string{"sensor"})
+}
+
+func newSomaticPivots() prometheus.Counter {
+	return prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gophership_somatic_pivots_total",
+		Help: "Total number of somatic pivots (reflex triggers).",
+	})
+}
+
+// StartMetricsServer starts a Prometheus metrics server for the default
+// runtime on the specified address.
 // It returns a cleanup function that should be called during graceful shutdown.
 func StartMetricsServer(ctx context.Context, addr string) func() {
+	return Default.StartMetricsServer(ctx, addr)
+}
+
+// StartMetricsServer serves the runtime's registry on addr. It returns a
+// cleanup function that should be called during graceful shutdown.
+func (rt *Runtime) StartMetricsServer(ctx context.Context, addr string) func() {
 	mux := http.NewServeMux()
-	mux.Handle("/metrics", promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+	mux.Handle("/metrics", promhttp.HandlerFor(rt.registry, promhttp.HandlerOpts{}))
 
 	server := &http.Server{
 		Addr:         addr,
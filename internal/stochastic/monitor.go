@@ -1,13 +1,21 @@
 package stochastic
 
 import (
+	"math"
 	"runtime"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
+// Default CPU pressure score thresholds (percent).
+const (
+	DefaultCPUYellowPerc = 75
+	DefaultCPURedPerc    = 90
+)
+
 // SensingMonitor provides a zero-allocation, lock-free operational counter
 // to trigger environmental sensing every N operations.
 type SensingMonitor struct {
@@ -23,9 +31,36 @@ type SensingMonitor struct {
 	yellowRAMPerc float64
 	redRAMPerc    float64
 	maxRAM        uint64
-
-	// CPU State (Windows simplified)
-	cpuLoad atomic.Uint64 // Multiplied by 100 for integer precision
+	memSource     string     // Where maxRAM came from
+	mem           *memReader // Set when maxRAM is a cgroup limit, to measure against it
+
+	// CPU pressure score (0-100) from the background sampler
+	cpuLoad       atomic.Uint64
+	cpuYellowPerc float64
+	cpuRedPerc    float64
+
+	// Pressure stall information, enabled via EnablePSI.
+	psiEnabled     atomic.Bool
+	psiStatus      atomic.Uint32 // AmbientStatus from the last PSI sample
+	psiTriggeredAt atomic.Int64  // UnixNano of the last kernel trigger
+	psiHold        atomic.Int64  // How long a trigger holds Yellow
+	psiPeak        atomic.Uint64 // Float64 bits of the worst avg10 ratio
+
+	// Disk space, enabled via EnableDisk; diskMu guards the watched paths.
+	diskMu      sync.Mutex
+	diskPaths   []string
+	diskEnabled atomic.Bool
+	diskStatus  atomic.Uint32          // AmbientStatus from the last sample
+	diskUsed    atomic.Uint64          // Float64 bits of the fullest filesystem's used share
+	diskWorst   atomic.Pointer[string] // Fullest watched directory
+	statfs      func(path string) (avail, total uint64, err error)
+
+	// Predictive pressure, enabled via SetPrediction. The trends are nil
+	// while prediction is off; predictMu guards them.
+	predictMu     sync.Mutex
+	horizon       time.Duration
+	memTrend      *Trend
+	ingesterTrend *Trend
 
 	// Component Budgets (Bytes)
 	ingesterBudget uint64
@@ -37,13 +72,30 @@ type SensingMonitor struct {
 	vaultYellow    uint64
 	vaultRed       uint64
 
+	// Processor state (dedup caches etc.); budget set via SetProcessorBudget.
+	processorBudget uint64
+	processorYellow uint64
+	processorRed    uint64
+
 	// Component Usage (Bytes) - Atomic for zero-allocation tracking
 	ingesterUsage atomic.Int64
 	vaultUsage    atomic.Int64
+	procUsage     atomic.Int64
+
+	// Downstream exporter health, reported on circuit breaker transitions.
+	downstreamMu    sync.Mutex
+	downstreams     map[string]DownstreamHealth
+	downstreamsDown atomic.Int32
+
+	// zones receives the votes; Zones unless bound by Runtime.SetMonitor.
+	zones *Arbiter
 }
 
 // NewSensingMonitor creates a new monitor with the specified limits and thresholds.
 // yellowPerc and redPerc should be between 0 and 1.0 (e.g. 0.80 for 80%).
+// A zero maxRAM detects the limit from the process's cgroup, falling back to
+// the host's total memory; usage is then the cgroup's working set rather
+// than the Go runtime's footprint.
 func NewSensingMonitor(limit uint64, maxRAM uint64, yellowPerc, redPerc float64, ingesterBudget, vaultBudget uint64) *SensingMonitor {
 	var mask uint64
 	if (limit != 0) && ((limit & (limit - 1)) == 0) {
@@ -62,9 +114,24 @@ func NewSensingMonitor(limit uint64, maxRAM uint64, yellowPerc, redPerc float64,
 		ingesterRed:    uint64(float64(ingesterBudget) * 0.95),
 		vaultYellow:    uint64(float64(vaultBudget) * 0.80),
 		vaultRed:       uint64(float64(vaultBudget) * 0.95),
+		cpuYellowPerc:  DefaultCPUYellowPerc,
+		cpuRedPerc:     DefaultCPURedPerc,
+		memSource:      memSourceConfig,
+		zones:          Zones,
+		statfs:         statfs,
 	}
 
-	// Start background CPU sampler for Windows
+	if maxRAM == 0 {
+		mem := newMemReader("/proc", "/sys/fs/cgroup")
+		m.maxRAM, m.memSource = mem.detectLimit()
+		if m.memSource != memSourceHost && m.memSource != memSourceDefault {
+			m.mem = mem
+		}
+		log.Info().Uint64("max_ram", m.maxRAM).Str("source", m.memSource).Msg("Detected memory limit")
+	}
+	MemoryLimitBytes.Set(float64(m.maxRAM))
+
+	// Start background CPU sampler
 	go m.sampleCPU()
 
 	return m
@@ -82,45 +149,87 @@ func (m *SensingMonitor) ShouldCheck() bool {
 	return (val % m.limit) == 0
 }
 
-// MustSense Environment performs the actual sensing and updates the global ambient status.
+// MustSense Environment performs the actual sensing and casts each sensor's
+// vote with the zone arbiter, which updates the global ambient status.
 func (m *SensingMonitor) MustSense() {
-	memStatus := m.checkMemory()
+	memUsage := m.memoryUsage()
+	memStatus := m.checkMemory(memUsage)
 	cpuStatus := m.checkCPU()
+	psiStatus := m.checkPSI()
 	ingesterStatus := m.checkIngester()
 	vaultStatus := m.checkVault()
+	procStatus := m.checkProcessor()
+	downstreamStatus := m.checkDownstream()
+	diskStatus := m.checkDisk()
 
 	// Update Metrics (AC5)
 	IngesterUsageBytes.Set(float64(m.ingesterUsage.Load()))
 	VaultUsageBytes.Set(float64(m.vaultUsage.Load()))
+	ProcessorUsageBytes.Set(float64(m.procUsage.Load()))
 
-	// Escalation logic: StatusRed > StatusYellow > StatusGreen
-	finalStatus := StatusGreen
-	reason := ""
-	if memStatus == StatusRed || cpuStatus == StatusRed || ingesterStatus == StatusRed || vaultStatus == StatusRed {
-		finalStatus = StatusRed
-		reason = "Critical resource pressure"
-	} else if memStatus == StatusYellow || cpuStatus == StatusYellow || ingesterStatus == StatusYellow || vaultStatus == StatusYellow {
-		finalStatus = StatusYellow
-		reason = "High resource pressure"
+	memRatio := ratio(int64(memUsage), m.maxRAM)
+	ingesterRatio := ratio(m.ingesterUsage.Load(), m.ingesterBudget)
+	memReason, ingesterReason := "Memory usage near limit", "Ingester budget"
+	if m.predict(m.memTrend, SensorMemory, memRatio, m.redRAMPerc, &memStatus) {
+		memReason = "Memory usage trending toward Red"
+	}
+	if m.ingesterBudget != 0 && m.predict(m.ingesterTrend, SensorIngester, ingesterRatio, float64(m.ingesterRed)/float64(m.ingesterBudget), &ingesterStatus) {
+		ingesterReason = "Ingester usage trending toward Red"
 	}
 
-	// Update global state if changed
-	prev := GetAmbientStatus()
-	if finalStatus != prev {
-		log.Warn().
-			Str("prev", prev.String()).
-			Str("curr", finalStatus.String()).
-			Str("reason", reason).
-			Msg("Somatic zone transition triggered by environment sensing")
-		MustSetAmbientStatus(finalStatus)
+	m.zones.Cast(
+		Vote{Sensor: SensorMemory, Status: memStatus, Reason: memReason, Value: memRatio},
+		Vote{Sensor: SensorCPU, Status: cpuStatus, Reason: "CPU pressure", Value: float64(m.cpuLoad.Load()) / 100},
+		Vote{Sensor: SensorPSI, Status: psiStatus, Reason: "Pressure stall", Value: math.Float64frombits(m.psiPeak.Load())},
+		Vote{Sensor: SensorIngester, Status: ingesterStatus, Reason: ingesterReason, Value: ingesterRatio},
+		Vote{Sensor: SensorVault, Status: vaultStatus, Reason: "Vault budget", Value: ratio(m.vaultUsage.Load(), m.vaultBudget)},
+		Vote{Sensor: SensorProcessor, Status: procStatus, Reason: "Processor state budget", Value: ratio(m.procUsage.Load(), m.processorBudget)},
+		Vote{Sensor: SensorDownstream, Status: downstreamStatus, Reason: "Downstream exporter unavailable", Value: float64(m.downstreamsDown.Load())},
+		Vote{Sensor: SensorDisk, Status: diskStatus, Reason: m.diskReason(), Value: math.Float64frombits(m.diskUsed.Load())},
+	)
+}
+
+// SetPrediction enables predictive pressure on memory and ingester usage:
+// once a sensor's EWMA rate of change projects reaching its Red threshold
+// within cfg.Horizon, it votes Yellow while still below its Yellow threshold.
+// A zero Horizon disables it. It must be called before sensing starts.
+func (m *SensingMonitor) SetPrediction(cfg PredictionConfig) {
+	m.horizon = cfg.Horizon
+	m.memTrend, m.ingesterTrend = nil, nil
+	if cfg.Horizon > 0 {
+		m.memTrend = NewTrend(cfg.HalfLife)
+		m.ingesterTrend = NewTrend(cfg.HalfLife)
 	}
 }
 
-func (m *SensingMonitor) checkMemory() AmbientStatus {
-	var ms runtime.MemStats
-	runtime.ReadMemStats(&ms)
+// predict feeds a utilisation ratio into trend and escalates a Green status
+// to Yellow when the ratio is projected to reach red within the horizon. It
+// reports whether it escalated.
+func (m *SensingMonitor) predict(trend *Trend, sensor string, value, red float64, status *AmbientStatus) bool {
+	if trend == nil {
+		return false
+	}
+	m.predictMu.Lock()
+	soon := Forecast(sensor, trend, m.horizon, time.Now(), value, red)
+	m.predictMu.Unlock()
 
-	usage := ms.Sys // Use Sys as a proxy for total process footprint
+	if soon && *status == StatusGreen {
+		*status = StatusYellow
+		return true
+	}
+	return false
+}
+
+// ratio is usage as a fraction of budget, or 0 without a budget.
+func ratio(usage int64, budget uint64) float64 {
+	if budget == 0 {
+		return 0
+	}
+	return float64(usage) / float64(budget)
+}
+
+func (m *SensingMonitor) checkMemory(usage uint64) AmbientStatus {
+	MemoryUsageBytes.Set(float64(usage))
 	redThreshold := uint64(float64(m.maxRAM) * m.redRAMPerc)
 	yellowThreshold := uint64(float64(m.maxRAM) * m.yellowRAMPerc)
 
@@ -133,34 +242,79 @@ func (m *SensingMonitor) checkMemory() AmbientStatus {
 	return StatusGreen
 }
 
+// memoryUsage is the cgroup working set when maxRAM is a cgroup limit, and
+// otherwise MemStats.Sys as a proxy for the total process footprint.
+func (m *SensingMonitor) memoryUsage() uint64 {
+	if m.mem != nil {
+		if usage, ok := m.mem.usage(); ok {
+			return usage
+		}
+	}
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.Sys
+}
+
+// MemoryLimit returns the memory limit sensing measures against and where it
+// came from ("config", a cgroup file, "host MemTotal" or "default").
+func (m *SensingMonitor) MemoryLimit() (uint64, string) {
+	return m.maxRAM, m.memSource
+}
+
 func (m *SensingMonitor) checkCPU() AmbientStatus {
 	// Simple threshold check against our background sampler
-	load := m.cpuLoad.Load()
-	if load >= 90 {
+	load := float64(m.cpuLoad.Load())
+	if load >= m.cpuRedPerc {
 		return StatusRed
 	}
-	if load >= 75 {
+	if load >= m.cpuYellowPerc {
 		return StatusYellow
 	}
 	return StatusGreen
 }
 
+// SetCPUThresholds sets the CPU pressure at which the monitor reports Yellow
+// and Red, between 0 and 1.0 like the RAM thresholds. Zero keeps the default.
+// It must be called before sensing starts.
+func (m *SensingMonitor) SetCPUThresholds(yellow, red float64) {
+	if yellow > 0 {
+		m.cpuYellowPerc = yellow * 100
+	}
+	if red > 0 {
+		m.cpuRedPerc = red * 100
+	}
+}
+
+// sampleCPU derives the pressure score from procfs and cgroup v2 counters:
+// the worst of process (or cgroup) utilisation against its CPU quota, host
+// utilisation and the throttled share of cgroup periods. Without readable
+// counters it falls back to a goroutine-count heuristic.
 func (m *SensingMonitor) sampleCPU() {
-	// Enhanced CPU sampling model for Windows (Proxy via Goroutines)
-	// While LoadAvg is unavailable natively, Goroutine count is a high-fidelity
-	// signal for somatic pressure within a Go process.
-	ticker := time.NewTicker(2 * time.Second)
+	ticker := time.NewTicker(CPUSampleInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		// Heuristic: maps NumGoroutine to a 0-100 "load" score.
-		// > 1000 goroutines starts impacting scheduler efficiency.
-		count := runtime.NumGoroutine()
-		score := uint64(count / 10)
-		if score > 100 {
-			score = 100
+	reader := newCPUReader("/proc", "/sys/fs/cgroup")
+	prev, err := reader.read(time.Now())
+	if err != nil {
+		log.Debug().Err(err).Msg("CPU counters unavailable; using goroutine heuristic for CPU pressure")
+	}
+
+	for now := range ticker.C {
+		if err != nil {
+			m.cpuLoad.Store(goroutineScore())
+			continue
+		}
+		cur, rerr := reader.read(now)
+		if rerr != nil {
+			continue
 		}
-		m.cpuLoad.Store(score)
+		usage := cpuDelta(prev, cur, runtime.NumCPU())
+		prev = cur
+
+		ProcessCPUUtilization.Set(usage.Process)
+		HostCPUUtilization.Set(usage.Host)
+		CPUThrottledRatio.Set(usage.Throttled)
+		m.cpuLoad.Store(usage.score())
 	}
 }
 
@@ -174,6 +328,19 @@ func (m *SensingMonitor) ReportVaultUsage(delta int64) {
 	m.vaultUsage.Add(delta)
 }
 
+// SetProcessorBudget sets the memory budget for state held by processors.
+// It must be called before sensing starts. Zero disables the check.
+func (m *SensingMonitor) SetProcessorBudget(budget uint64) {
+	m.processorBudget = budget
+	m.processorYellow = uint64(float64(budget) * 0.80)
+	m.processorRed = uint64(float64(budget) * 0.95)
+}
+
+// ReportProcessorUsage updates the atomic usage counter for processor state.
+func (m *SensingMonitor) ReportProcessorUsage(delta int64) {
+	m.procUsage.Add(delta)
+}
+
 func (m *SensingMonitor) checkIngester() AmbientStatus {
 	usage := uint64(m.ingesterUsage.Load())
 	if m.ingesterBudget == 0 {
@@ -204,9 +371,24 @@ func (m *SensingMonitor) checkVault() AmbientStatus {
 	return StatusGreen
 }
 
+func (m *SensingMonitor) checkProcessor() AmbientStatus {
+	usage := uint64(m.procUsage.Load())
+	if m.processorBudget == 0 {
+		return StatusGreen
+	}
+
+	if usage >= m.processorRed {
+		return StatusRed
+	}
+	if usage >= m.processorYellow {
+		return StatusYellow
+	}
+	return StatusGreen
+}
+
 // Telemetry returns the current resource consumption and pressure score.
 func (m *SensingMonitor) Telemetry() (usage, heap uint64, score uint32) {
-	usage = uint64(m.ingesterUsage.Load() + m.vaultUsage.Load())
+	usage = uint64(m.ingesterUsage.Load() + m.vaultUsage.Load() + m.procUsage.Load())
 
 	var ms runtime.MemStats
 	runtime.ReadMemStats(&ms)
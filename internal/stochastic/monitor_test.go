@@ -89,6 +89,23 @@ func TestSensingMonitor_ComponentBudgets(t *testing.T) {
 	if status != StatusYellow {
 		t.Errorf("Expected StatusYellow due to vault budget breach, got %s", status.String())
 	}
+
+	// Reset
+	MustSetAmbientStatus(StatusGreen)
+	monitor.ReportVaultUsage(-85 * 1024)
+
+	// Case 3: Processor state breach Red (20KB > 19KB), e.g. a dedup cache
+	monitor.SetProcessorBudget(20 * 1024)
+	monitor.ReportProcessorUsage(20 * 1024)
+	if monitor.ShouldCheck() {
+		monitor.MustSense()
+	}
+
+	status = GetAmbientStatus()
+	if status != StatusRed {
+		t.Errorf("Expected StatusRed due to processor budget breach, got %s", status.String())
+	}
+	MustSetAmbientStatus(StatusGreen)
 }
 
 func BenchmarkSensingMonitor_ShouldCheck(t *testing.B) {
@@ -98,3 +115,38 @@ func BenchmarkSensingMonitor_ShouldCheck(t *testing.B) {
 		monitor.ShouldCheck()
 	}
 }
+
+func TestSensingMonitor_DownstreamHealth(t *testing.T) {
+	MustSetAmbientStatus(StatusGreen)
+	t.Cleanup(func() { MustSetAmbientStatus(StatusGreen) })
+
+	monitor := NewSensingMonitor(1024, 1<<40, 0.80, 0.95, 0, 0)
+
+	monitor.ReportDownstream(DownstreamHealth{Name: "archive", State: DownstreamDegraded, ConsecutiveFailures: 5})
+	monitor.MustSense()
+	if status := GetAmbientStatus(); status != StatusYellow {
+		t.Errorf("Expected StatusYellow while a downstream is down, got %s", status)
+	}
+
+	monitor.ReportDownstream(DownstreamHealth{Name: "archive", State: DownstreamHealthy})
+	monitor.MustSense()
+	if status := GetAmbientStatus(); status != StatusGreen {
+		t.Errorf("Expected StatusGreen after downstream recovery, got %s", status)
+	}
+
+	// An error attaches to the latest state rather than replaying an older one.
+	monitor.ReportDownstreamError("archive", "connection refused")
+	if d := monitor.Downstreams(); len(d) != 1 || d[0].State != DownstreamHealthy || d[0].LastError != "connection refused" {
+		t.Errorf("Expected healthy archive with last error, got %+v", d)
+	}
+	monitor.ReportDownstreamError("unknown", "ignored")
+	if d := monitor.Downstreams(); len(d) != 1 {
+		t.Errorf("Expected errors for unreported exporters to be ignored, got %+v", d)
+	}
+
+	monitor.ReportDownstream(DownstreamHealth{Name: "loki", State: DownstreamDegraded})
+	monitor.ForgetDownstream("loki")
+	if n := monitor.downstreamsDown.Load(); n != 0 {
+		t.Errorf("Expected no down downstreams after ForgetDownstream, got %d", n)
+	}
+}
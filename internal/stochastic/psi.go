@@ -0,0 +1,257 @@
+package stochastic
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// PSISampleInterval is how often the background sampler reads pressure files.
+// The kernel recomputes the averages every 2s, so faster polling gains nothing;
+// sub-second reaction comes from the optional trigger.
+const PSISampleInterval = time.Second
+
+// Default PSI thresholds on the avg10 share of wall time (percent) in which
+// some or all tasks stalled on a resource. They sit well below the levels at
+// which the kernel reclaims aggressively or the OOM killer steps in.
+const (
+	DefaultPSISomeYellow = 10
+	DefaultPSISomeRed    = 40
+	DefaultPSIFullYellow = 5
+	DefaultPSIFullRed    = 20
+)
+
+// psiResources are the PSI files read, in /proc/pressure and as <name>.pressure
+// in the cgroup v2 directory.
+var psiResources = [...]string{"cpu", "memory", "io"}
+
+// psiLine is one "some" or "full" line of a pressure file.
+type psiLine struct {
+	Avg10, Avg60, Avg300 float64 // Percent of wall time stalled
+	Total                uint64  // Cumulative stall time in microseconds
+}
+
+// psiStats is the content of one pressure file. HasFull is false for the
+// system-wide cpu file on kernels before 5.13.
+type psiStats struct {
+	Some, Full psiLine
+	HasFull    bool
+}
+
+// PSIConfig configures the pressure stall sensor. Thresholds are avg10
+// percentages; zero keeps the default. A zero TriggerStall disables the
+// kernel trigger.
+type PSIConfig struct {
+	SomeYellow, SomeRed float64
+	FullYellow, FullRed float64
+
+	// TriggerStall and TriggerWindow arm a kernel trigger on memory "some"
+	// pressure: it fires once tasks stall for TriggerStall within any
+	// TriggerWindow, and the monitor holds at least Yellow for one window.
+	TriggerStall  time.Duration
+	TriggerWindow time.Duration
+}
+
+// status classifies the worst pressure across resources.
+func (c PSIConfig) status(stats map[string]psiStats) AmbientStatus {
+	status := StatusGreen
+	for _, s := range stats {
+		switch {
+		case s.Some.Avg10 >= c.SomeRed, s.HasFull && s.Full.Avg10 >= c.FullRed:
+			return StatusRed
+		case s.Some.Avg10 >= c.SomeYellow, s.HasFull && s.Full.Avg10 >= c.FullYellow:
+			status = StatusYellow
+		}
+	}
+	return status
+}
+
+// withDefaults fills unset thresholds.
+func (c PSIConfig) withDefaults() PSIConfig {
+	if c.SomeYellow <= 0 {
+		c.SomeYellow = DefaultPSISomeYellow
+	}
+	if c.SomeRed <= 0 {
+		c.SomeRed = DefaultPSISomeRed
+	}
+	if c.FullYellow <= 0 {
+		c.FullYellow = DefaultPSIFullYellow
+	}
+	if c.FullRed <= 0 {
+		c.FullRed = DefaultPSIFullRed
+	}
+	if c.TriggerStall > 0 && c.TriggerWindow <= 0 {
+		c.TriggerWindow = 2 * time.Second
+	}
+	return c
+}
+
+// psiReader reads pressure files, preferring the process's cgroup v2
+// directory so that pressure is measured against the container's own limits.
+type psiReader struct {
+	paths map[string]string // Resource name to pressure file
+}
+
+func newPSIReader(procRoot, cgroupRoot string) *psiReader {
+	var cgroupDir string
+	if data, err := os.ReadFile(filepath.Join(procRoot, "self", "cgroup")); err == nil {
+		if p, ok := cgroupV2Path(data); ok {
+			cgroupDir = filepath.Join(cgroupRoot, p)
+		}
+	}
+
+	r := &psiReader{paths: make(map[string]string, len(psiResources))}
+	for _, res := range psiResources {
+		candidates := []string{filepath.Join(procRoot, "pressure", res)}
+		if cgroupDir != "" {
+			candidates = append([]string{filepath.Join(cgroupDir, res+".pressure")}, candidates...)
+		}
+		for _, path := range candidates {
+			if _, err := os.Stat(path); err == nil {
+				r.paths[res] = path
+				break
+			}
+		}
+	}
+	return r
+}
+
+// read parses every available pressure file. It fails only when none is readable.
+func (r *psiReader) read() (map[string]psiStats, error) {
+	stats := make(map[string]psiStats, len(r.paths))
+	for res, path := range r.paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		s, err := parsePSI(data)
+		if err != nil {
+			continue
+		}
+		stats[res] = s
+	}
+	if len(stats) == 0 {
+		return nil, fmt.Errorf("no readable PSI files")
+	}
+	return stats, nil
+}
+
+// parsePSI parses a pressure file:
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func parsePSI(data []byte) (psiStats, error) {
+	var s psiStats
+	hasSome := false
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		var line psiLine
+		for _, f := range fields[1:] {
+			k, v, ok := strings.Cut(f, "=")
+			if !ok {
+				return s, fmt.Errorf("malformed PSI field %q", f)
+			}
+			var err error
+			switch k {
+			case "avg10":
+				line.Avg10, err = strconv.ParseFloat(v, 64)
+			case "avg60":
+				line.Avg60, err = strconv.ParseFloat(v, 64)
+			case "avg300":
+				line.Avg300, err = strconv.ParseFloat(v, 64)
+			case "total":
+				line.Total, err = strconv.ParseUint(v, 10, 64)
+			}
+			if err != nil {
+				return s, fmt.Errorf("malformed PSI field %q: %w", f, err)
+			}
+		}
+		switch fields[0] {
+		case "some":
+			s.Some, hasSome = line, true
+		case "full":
+			s.Full, s.HasFull = line, true
+		}
+	}
+	if !hasSome {
+		return s, fmt.Errorf("PSI \"some\" line missing")
+	}
+	return s, nil
+}
+
+// EnablePSI starts the pressure stall sensor. It is a no-op on kernels
+// without PSI (before 4.20, or booted with psi=0).
+func (m *SensingMonitor) EnablePSI(cfg PSIConfig) {
+	cfg = cfg.withDefaults()
+	reader := newPSIReader("/proc", "/sys/fs/cgroup")
+	if _, err := reader.read(); err != nil {
+		log.Info().Err(err).Msg("PSI unavailable; pressure stall sensing disabled")
+		return
+	}
+	m.psiEnabled.Store(true)
+	go m.samplePSI(reader, cfg)
+
+	if cfg.TriggerStall > 0 {
+		path, ok := reader.paths["memory"]
+		if !ok {
+			return
+		}
+		m.psiHold.Store(int64(cfg.TriggerWindow))
+		err := watchPSI(path, cfg.TriggerStall, cfg.TriggerWindow, func() {
+			PSITriggersTotal.Inc()
+			m.psiTriggeredAt.Store(time.Now().UnixNano())
+			m.MustSense()
+		})
+		if err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("Failed to arm PSI trigger; relying on polling")
+		}
+	}
+}
+
+func (m *SensingMonitor) samplePSI(reader *psiReader, cfg PSIConfig) {
+	ticker := time.NewTicker(PSISampleInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		stats, err := reader.read()
+		if err != nil {
+			continue
+		}
+		var peak float64
+		for res, s := range stats {
+			PSIStallRatio.WithLabelValues(res, "some").Set(s.Some.Avg10 / 100)
+			peak = max(peak, s.Some.Avg10/100)
+			if s.HasFull {
+				PSIStallRatio.WithLabelValues(res, "full").Set(s.Full.Avg10 / 100)
+				peak = max(peak, s.Full.Avg10/100)
+			}
+		}
+		m.psiPeak.Store(math.Float64bits(peak))
+		m.psiStatus.Store(uint32(cfg.status(stats)))
+	}
+}
+
+func (m *SensingMonitor) checkPSI() AmbientStatus {
+	if !m.psiEnabled.Load() {
+		return StatusGreen
+	}
+	status := AmbientStatus(m.psiStatus.Load())
+	if at := m.psiTriggeredAt.Load(); at != 0 && status == StatusGreen {
+		if time.Since(time.Unix(0, at)) < time.Duration(m.psiHold.Load()) {
+			status = StatusYellow
+		}
+	}
+	return status
+}
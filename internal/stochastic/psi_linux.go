@@ -0,0 +1,68 @@
+//go:build linux
+// +build linux
+
+package stochastic
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// watchPSI arms a kernel PSI trigger on the pressure file at path and calls
+// fire from a background goroutine each time "some" stall time exceeds stall
+// within window. Unprivileged processes need a window that is a multiple of 2s.
+func watchPSI(path string, stall, window time.Duration, fire func()) error {
+	fd, err := syscall.Open(path, syscall.O_RDWR|syscall.O_NONBLOCK|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	trigger := fmt.Sprintf("some %d %d", stall.Microseconds(), window.Microseconds())
+	if _, err := syscall.Write(fd, append([]byte(trigger), 0)); err != nil {
+		syscall.Close(fd)
+		return fmt.Errorf("write trigger %q: %w", trigger, err)
+	}
+
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		syscall.Close(fd)
+		return err
+	}
+	ev := syscall.EpollEvent{Events: syscall.EPOLLPRI, Fd: int32(fd)}
+	if err := syscall.EpollCtl(epfd, syscall.EPOLL_CTL_ADD, fd, &ev); err != nil {
+		syscall.Close(epfd)
+		syscall.Close(fd)
+		return err
+	}
+
+	go func() {
+		defer syscall.Close(fd)
+		defer syscall.Close(epfd)
+
+		events := make([]syscall.EpollEvent, 1)
+		for {
+			n, err := syscall.EpollWait(epfd, events, -1)
+			if err == syscall.EINTR {
+				continue
+			}
+			if err != nil {
+				log.Warn().Err(err).Str("path", path).Msg("PSI trigger wait failed")
+				return
+			}
+			if n == 0 {
+				continue
+			}
+			if events[0].Events&syscall.EPOLLERR != 0 {
+				// The cgroup was removed under us.
+				log.Warn().Str("path", path).Msg("PSI trigger disarmed by the kernel")
+				return
+			}
+			if events[0].Events&syscall.EPOLLPRI != 0 {
+				fire()
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package stochastic
+
+import (
+	"errors"
+	"time"
+)
+
+// watchPSI is unsupported on non-linux platforms.
+func watchPSI(path string, stall, window time.Duration, fire func()) error {
+	return errors.New("PSI triggers require Linux")
+}
@@ -0,0 +1,130 @@
+package stochastic
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParsePSI(t *testing.T) {
+	s, err := parsePSI([]byte("some avg10=12.50 avg60=3.10 avg300=0.80 total=123456\nfull avg10=4.00 avg60=1.00 avg300=0.20 total=6543\n"))
+	if err != nil {
+		t.Fatalf("parsePSI: %v", err)
+	}
+	if s.Some.Avg10 != 12.5 || s.Some.Avg60 != 3.1 || s.Some.Total != 123456 || !s.HasFull || s.Full.Avg10 != 4 {
+		t.Errorf("parsePSI = %+v", s)
+	}
+
+	// The system-wide cpu file has no "full" line on older kernels.
+	s, err = parsePSI([]byte("some avg10=1.00 avg60=0.00 avg300=0.00 total=10\n"))
+	if err != nil || s.HasFull {
+		t.Errorf("parsePSI(cpu) = %+v, %v", s, err)
+	}
+
+	for _, in := range []string{"", "full avg10=1.00 avg60=0 avg300=0 total=0\n", "some avg10=abc\n", "some avg10\n"} {
+		if _, err := parsePSI([]byte(in)); err == nil {
+			t.Errorf("parsePSI(%q) accepted malformed input", in)
+		}
+	}
+}
+
+func TestPSIConfigStatus(t *testing.T) {
+	cfg := PSIConfig{}.withDefaults()
+	line := func(avg10 float64) psiLine { return psiLine{Avg10: avg10} }
+
+	tests := []struct {
+		name  string
+		stats map[string]psiStats
+		want  AmbientStatus
+	}{
+		{"Calm", map[string]psiStats{"memory": {Some: line(1), Full: line(0.5), HasFull: true}}, StatusGreen},
+		{"SomeYellow", map[string]psiStats{"cpu": {Some: line(15)}, "memory": {Some: line(1), HasFull: true}}, StatusYellow},
+		{"FullYellow", map[string]psiStats{"memory": {Some: line(6), Full: line(6), HasFull: true}}, StatusYellow},
+		{"FullRed", map[string]psiStats{"io": {Some: line(25), Full: line(22), HasFull: true}}, StatusRed},
+		{"SomeRed", map[string]psiStats{"cpu": {Some: line(50)}, "memory": {Some: line(12), HasFull: true}}, StatusRed},
+		// A missing full line must not be read as zero-threshold breach.
+		{"NoFull", map[string]psiStats{"cpu": {Some: line(2)}}, StatusGreen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.status(tt.stats); got != tt.want {
+				t.Errorf("status = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPSIReader(t *testing.T) {
+	proc := t.TempDir()
+	cgroup := t.TempDir()
+	write := func(path, data string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(proc, "self", "cgroup"), "0::/pod/app\n")
+	write(filepath.Join(proc, "pressure", "memory"), "some avg10=1.00 avg60=0 avg300=0 total=1\nfull avg10=0 avg60=0 avg300=0 total=0\n")
+	write(filepath.Join(proc, "pressure", "cpu"), "some avg10=2.00 avg60=0 avg300=0 total=2\n")
+	write(filepath.Join(cgroup, "pod", "app", "memory.pressure"), "some avg10=30.00 avg60=0 avg300=0 total=3\nfull avg10=8.00 avg60=0 avg300=0 total=1\n")
+
+	r := newPSIReader(proc, cgroup)
+	stats, err := r.read()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	// The cgroup's file wins over the host's; cpu falls back to /proc; io is absent.
+	if s := stats["memory"]; s.Some.Avg10 != 30 || s.Full.Avg10 != 8 {
+		t.Errorf("memory = %+v, want the cgroup's pressure", s)
+	}
+	if s := stats["cpu"]; s.Some.Avg10 != 2 {
+		t.Errorf("cpu = %+v, want the host's pressure", s)
+	}
+	if _, ok := stats["io"]; ok {
+		t.Error("io reported without a pressure file")
+	}
+
+	if _, err := newPSIReader(t.TempDir(), t.TempDir()).read(); err == nil {
+		t.Error("read succeeded without any pressure files")
+	}
+}
+
+func TestSensingMonitor_PSI(t *testing.T) {
+	MustSetAmbientStatus(StatusGreen)
+	t.Cleanup(func() { MustSetAmbientStatus(StatusGreen) })
+
+	monitor := NewSensingMonitor(1024, 1<<40, 0.80, 0.95, 0, 0)
+	monitor.cpuYellowPerc, monitor.cpuRedPerc = 101, 101
+
+	// Disabled PSI never escalates, whatever the stored status.
+	monitor.psiStatus.Store(uint32(StatusRed))
+	monitor.MustSense()
+	if status := GetAmbientStatus(); status != StatusGreen {
+		t.Errorf("Expected StatusGreen with PSI disabled, got %s", status)
+	}
+
+	monitor.psiEnabled.Store(true)
+	monitor.MustSense()
+	if status := GetAmbientStatus(); status != StatusRed {
+		t.Errorf("Expected StatusRed on memory stall, got %s", status)
+	}
+
+	// A recent trigger holds Yellow for one window even if the last sample was calm.
+	monitor.psiStatus.Store(uint32(StatusGreen))
+	monitor.psiHold.Store(int64(time.Minute))
+	monitor.psiTriggeredAt.Store(time.Now().UnixNano())
+	monitor.MustSense()
+	if status := GetAmbientStatus(); status != StatusYellow {
+		t.Errorf("Expected StatusYellow after a PSI trigger, got %s", status)
+	}
+
+	monitor.psiTriggeredAt.Store(time.Now().Add(-2 * time.Minute).UnixNano())
+	monitor.MustSense()
+	if status := GetAmbientStatus(); status != StatusGreen {
+		t.Errorf("Expected StatusGreen once the trigger window passed, got %s", status)
+	}
+}
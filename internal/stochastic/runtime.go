@@ -0,0 +1,200 @@
+package stochastic
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Runtime is the state of one engine: its ambient status and the listeners
+// notified of changes, the zone arbiter deciding that status, the sensing
+// monitor voting on it and the Prometheus registry exporting it. Components
+// given a Runtime share nothing with those given another, so several engines
+// (or parallel tests) can run in one process.
+//
+// Default backs the package-level functions and variables (GetAmbientStatus,
+// MustSetAmbientStatus, SubscribeStatus, Monitor, Zones, Registry). Other
+// packages register their component metrics on Default's registry at init and
+// on another runtime's registry, through Collectors, once a component is built
+// on it. Host sensor gauges and trend projections remain process-wide and are
+// exported by Default's registry only.
+type Runtime struct {
+	status uint32 // Atomic AmbientStatus, the "Lazy Atomic" read on hot paths
+
+	// mu protects the zone gauge, the status listeners and, for Default,
+	// the package-level Monitor.
+	mu        sync.Mutex
+	listeners []chan AmbientStatus
+
+	monitor  atomic.Pointer[SensingMonitor]
+	zones    *Arbiter
+	registry *prometheus.Registry
+
+	// collectors holds the metric sets other packages registered on the
+	// runtime's registry, keyed by package.
+	collectorsMu sync.Mutex
+	collectors   map[any]any
+
+	zone   prometheus.Gauge
+	votes  *prometheus.GaugeVec
+	pivots prometheus.Counter
+}
+
+// Default is the process-wide runtime used by the package-level functions.
+var Default = newRuntime(Registry, IngesterZone, ZoneVotes, SomaticPivotsTotal)
+
+// NewRuntime creates an independent runtime in Green, with its own arbiter
+// and a registry exporting its zone metrics.
+func NewRuntime() *Runtime {
+	rt := newRuntime(prometheus.NewRegistry(), newZoneGauge(), newZoneVotes(), newSomaticPivots())
+	rt.registry.MustRegister(rt.zone, rt.votes, rt.pivots)
+	return rt
+}
+
+func newRuntime(reg *prometheus.Registry, zone prometheus.Gauge, votes *prometheus.GaugeVec, pivots prometheus.Counter) *Runtime {
+	rt := &Runtime{registry: reg, zone: zone, votes: votes, pivots: pivots}
+	rt.zones = newArbiter(rt)
+	return rt
+}
+
+// Status returns the runtime's current pressure state.
+func (rt *Runtime) Status() AmbientStatus {
+	return AmbientStatus(atomic.LoadUint32(&rt.status))
+}
+
+// SetStatus updates the pressure state and notifies listeners of a change
+// without blocking on them.
+func (rt *Runtime) SetStatus(status AmbientStatus) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	prev := atomic.SwapUint32(&rt.status, uint32(status))
+	if uint32(status) != prev {
+		rt.zone.Set(float64(status))
+		// Notify listeners of the state transition
+		for _, ch := range rt.listeners {
+			select {
+			case ch <- status:
+			default: // Non-blocking to prevent slow listeners from hanging core reflexes
+			}
+		}
+	}
+}
+
+// Subscribe returns a channel that receives the status whenever it changes,
+// and a function that unsubscribes and closes the channel.
+func (rt *Runtime) Subscribe() (chan AmbientStatus, func()) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	ch := make(chan AmbientStatus, 1)
+	rt.listeners = append(rt.listeners, ch)
+
+	cleanup := func() {
+		rt.mu.Lock()
+		defer rt.mu.Unlock()
+		for i, l := range rt.listeners {
+			if l == ch {
+				rt.listeners = append(rt.listeners[:i], rt.listeners[i+1:]...)
+				close(ch)
+				break
+			}
+		}
+	}
+
+	return ch, cleanup
+}
+
+// ThrottleMultiplier returns a sleep factor for background work in the
+// current status: 1 in Green, 2 in Yellow and 50 in Red.
+func (rt *Runtime) ThrottleMultiplier() float64 {
+	switch rt.Status() {
+	case StatusYellow:
+		return 2.0
+	case StatusRed:
+		return 50.0 // Effective suspension for background tasks
+	default:
+		return 1.0
+	}
+}
+
+// IncrementPressure raises the status by one zone, up to Red.
+func (rt *Runtime) IncrementPressure() {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	curr := atomic.LoadUint32(&rt.status)
+	if curr < uint32(StatusRed) {
+		next := curr + 1
+		atomic.StoreUint32(&rt.status, next)
+		rt.zone.Set(float64(next))
+	}
+}
+
+// DecrementPressure lowers the status by one zone, down to Green.
+func (rt *Runtime) DecrementPressure() {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	curr := atomic.LoadUint32(&rt.status)
+	if curr > uint32(StatusGreen) {
+		next := curr - 1
+		atomic.StoreUint32(&rt.status, next)
+		rt.zone.Set(float64(next))
+	}
+}
+
+// Monitor returns the runtime's sensing monitor, or nil if none is set.
+func (rt *Runtime) Monitor() *SensingMonitor {
+	return rt.monitor.Load()
+}
+
+// SetMonitor installs m as the runtime's monitor and makes it vote with the
+// runtime's arbiter. It must be called before m starts sensing. For Default
+// it also updates the package-level Monitor.
+func (rt *Runtime) SetMonitor(m *SensingMonitor) {
+	if m != nil {
+		m.zones = rt.zones
+	}
+	rt.monitor.Store(m)
+	if rt == Default {
+		rt.mu.Lock()
+		Monitor = m
+		rt.mu.Unlock()
+	}
+}
+
+// Zones returns the arbiter deciding the runtime's status.
+func (rt *Runtime) Zones() *Arbiter {
+	return rt.zones
+}
+
+// Registry returns the registry exporting the runtime's metrics.
+func (rt *Runtime) Registry() *prometheus.Registry {
+	return rt.registry
+}
+
+// Collectors returns the metric set stored under key, creating it with
+// register on the runtime's registry the first time. Packages use it so
+// components built on the same runtime share one set of collectors and those
+// on different runtimes never do.
+func (rt *Runtime) Collectors(key any, register func(*prometheus.Registry) any) any {
+	rt.collectorsMu.Lock()
+	defer rt.collectorsMu.Unlock()
+
+	if c, ok := rt.collectors[key]; ok {
+		return c
+	}
+	if rt.collectors == nil {
+		rt.collectors = make(map[any]any)
+	}
+	c := register(rt.registry)
+	rt.collectors[key] = c
+	return c
+}
+
+// SomaticPivots counts the runtime's reflex triggers.
+func (rt *Runtime) SomaticPivots() prometheus.Counter {
+	return rt.pivots
+}
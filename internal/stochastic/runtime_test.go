@@ -0,0 +1,99 @@
+package stochastic
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRuntime_Isolation(t *testing.T) {
+	t.Parallel()
+
+	a, b := NewRuntime(), NewRuntime()
+	chA, cleanupA := a.Subscribe()
+	defer cleanupA()
+	chB, cleanupB := b.Subscribe()
+	defer cleanupB()
+
+	a.Zones().Cast(Vote{Sensor: SensorBuffer, Status: StatusRed, Reason: "Buffer full"})
+
+	if got := a.Status(); got != StatusRed {
+		t.Errorf("runtime a status = %s; want RED", got)
+	}
+	if got := b.Status(); got != StatusGreen {
+		t.Errorf("runtime b status = %s; want GREEN", got)
+	}
+	if got := <-chA; got != StatusRed {
+		t.Errorf("runtime a listener got %s; want RED", got)
+	}
+	select {
+	case got := <-chB:
+		t.Errorf("runtime b listener got %s; want no change", got)
+	default:
+	}
+	if n := len(b.Zones().History().Recent(0)); n != 0 {
+		t.Errorf("runtime b recorded %d transitions; want none", n)
+	}
+	if got := testutil.ToFloat64(a.zone); got != float64(StatusRed) {
+		t.Errorf("runtime a zone gauge = %v; want 2", got)
+	}
+	if got := testutil.ToFloat64(b.zone); got != float64(StatusGreen) {
+		t.Errorf("runtime b zone gauge = %v; want 0", got)
+	}
+	if a.Registry() == b.Registry() || a.Registry() == Default.Registry() {
+		t.Error("runtimes share a registry")
+	}
+}
+
+func TestRuntime_SetMonitor(t *testing.T) {
+	t.Parallel()
+
+	rt := NewRuntime()
+	budget := uint64(1000)
+	m := NewSensingMonitor(1, 1024*1024*1024*1024, 0.8, 0.9, budget, 0)
+	rt.SetMonitor(m)
+	if rt.Monitor() != m {
+		t.Fatal("Monitor() did not return the installed monitor")
+	}
+
+	// The monitor votes with its runtime's arbiter, not the default one.
+	m.ReportIngesterUsage(int64(budget))
+	m.MustSense()
+	if got := rt.Status(); got != StatusRed {
+		t.Errorf("runtime status = %s; want RED", got)
+	}
+	for _, v := range Zones.Votes() {
+		if v.Sensor == SensorIngester && v.Value == 1 {
+			t.Errorf("default arbiter received the runtime's ingester vote")
+		}
+	}
+}
+
+func TestRuntime_Collectors(t *testing.T) {
+	t.Parallel()
+
+	type key struct{}
+	calls := 0
+	register := func(reg *prometheus.Registry) any {
+		calls++
+		c := prometheus.NewCounter(prometheus.CounterOpts{Name: "gophership_test_runtime_collector_total", Help: "Test."})
+		reg.MustRegister(c)
+		return c
+	}
+
+	a, b := NewRuntime(), NewRuntime()
+	first := a.Collectors(key{}, register)
+	if again := a.Collectors(key{}, register); again != first {
+		t.Error("a runtime returned a second set of collectors for the same key")
+	}
+	if other := b.Collectors(key{}, register); other == first {
+		t.Error("two runtimes share collectors")
+	}
+	if calls != 2 {
+		t.Errorf("register called %d times, want once per runtime", calls)
+	}
+	if n, err := testutil.GatherAndCount(a.Registry(), "gophership_test_runtime_collector_total"); err != nil || n != 1 {
+		t.Errorf("collector not exported by its runtime: %d, %v", n, err)
+	}
+}
@@ -1,8 +1,8 @@
 package stochastic
 
 import (
-	"sync"
-	"sync/atomic"
+	"fmt"
+	"strings"
 )
 
 // AmbientStatus represents the global system pressure state (Green, Yellow, Red).
@@ -27,21 +27,42 @@ func (s AmbientStatus) String() string {
 	}
 }
 
-// globalState is the "Lazy Atomic" counter updated by an observer goroutine.
-var globalState uint32
+// MarshalText encodes the status by name, e.g. in persisted zone history.
+func (s AmbientStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
 
-// stateMu protects the Prometheus Gauge and status listeners.
-var stateMu sync.Mutex
+// UnmarshalText decodes a status name.
+func (s *AmbientStatus) UnmarshalText(text []byte) error {
+	status, err := ParseStatus(string(text))
+	if err != nil {
+		return err
+	}
+	*s = status
+	return nil
+}
 
-// statusListeners stores channels for event-driven updates (Watch).
-var statusListeners []chan AmbientStatus
+// ParseStatus maps a case-insensitive zone name ("green", "yellow", "red") to its status.
+func ParseStatus(s string) (AmbientStatus, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "green":
+		return StatusGreen, nil
+	case "yellow":
+		return StatusYellow, nil
+	case "red":
+		return StatusRed, nil
+	default:
+		return StatusGreen, fmt.Errorf("unknown somatic zone %q", s)
+	}
+}
 
-// Monitor is the global host and component sensor.
+// Monitor is the default runtime's host and component sensor, kept for
+// callers that predate Runtime. It is updated by Default.SetMonitor.
 var Monitor *SensingMonitor
 
-// GetAmbientStatus returns the current global pressure state.
+// GetAmbientStatus returns the default runtime's pressure state.
 func GetAmbientStatus() AmbientStatus {
-	return AmbientStatus(atomic.LoadUint32(&globalState))
+	return Default.Status()
 }
 
 // ThrottleMultiplier returns a sleep factor based on the current ambient status.
@@ -49,87 +70,31 @@ func GetAmbientStatus() AmbientStatus {
 // - StatusYellow: 2.0 (Throttled)
 // - StatusRed: 50.0 (Deep Sleep/Suspended)
 func ThrottleMultiplier() float64 {
-	status := GetAmbientStatus()
-	switch status {
-	case StatusYellow:
-		return 2.0
-	case StatusRed:
-		return 50.0 // Effective suspension for background tasks
-	default:
-		return 1.0
-	}
+	return Default.ThrottleMultiplier()
 }
 
-// MustSetAmbientStatus updates the global pressure state. Matches hot path naming.
+// MustSetAmbientStatus updates the default runtime's pressure state. Matches hot path naming.
 func MustSetAmbientStatus(status AmbientStatus) {
-	stateMu.Lock()
-	defer stateMu.Unlock()
-
-	prev := atomic.SwapUint32(&globalState, uint32(status))
-	if uint32(status) != prev {
-		IngesterZone.Set(float64(status))
-		// Notify listeners of the state transition
-		for _, ch := range statusListeners {
-			select {
-			case ch <- status:
-			default: // Non-blocking to prevent slow listeners from hanging core reflexes
-			}
-		}
-	}
+	Default.SetStatus(status)
 }
 
-// SubscribeStatus returns a channel that receives updates whenever the ambient status changes.
+// SubscribeStatus returns a channel that receives updates whenever the default
+// runtime's ambient status changes.
 func SubscribeStatus() (chan AmbientStatus, func()) {
-	stateMu.Lock()
-	defer stateMu.Unlock()
-
-	ch := make(chan AmbientStatus, 1)
-	statusListeners = append(statusListeners, ch)
-
-	cleanup := func() {
-		stateMu.Lock()
-		defer stateMu.Unlock()
-		for i, l := range statusListeners {
-			if l == ch {
-				statusListeners = append(statusListeners[:i], statusListeners[i+1:]...)
-				close(ch)
-				break
-			}
-		}
-	}
-
-	return ch, cleanup
+	return Default.Subscribe()
 }
 
-// SetGlobalMonitor initializes the global monitoring instance.
+// SetGlobalMonitor installs the default runtime's monitor.
 func SetGlobalMonitor(m *SensingMonitor) {
-	stateMu.Lock()
-	defer stateMu.Unlock()
-	Monitor = m
+	Default.SetMonitor(m)
 }
 
-// IncrementPressure increases the global pressure state (up to Red).
+// IncrementPressure increases the default runtime's pressure state (up to Red).
 func IncrementPressure() {
-	stateMu.Lock()
-	defer stateMu.Unlock()
-
-	curr := atomic.LoadUint32(&globalState)
-	if curr < uint32(StatusRed) {
-		next := curr + 1
-		atomic.StoreUint32(&globalState, next)
-		IngesterZone.Set(float64(next))
-	}
+	Default.IncrementPressure()
 }
 
-// DecrementPressure decreases the global pressure state (down to Green).
+// DecrementPressure decreases the default runtime's pressure state (down to Green).
 func DecrementPressure() {
-	stateMu.Lock()
-	defer stateMu.Unlock()
-
-	curr := atomic.LoadUint32(&globalState)
-	if curr > uint32(StatusGreen) {
-		next := curr - 1
-		atomic.StoreUint32(&globalState, next)
-		IngesterZone.Set(float64(next))
-	}
+	Default.DecrementPressure()
 }
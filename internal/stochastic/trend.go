@@ -0,0 +1,109 @@
+package stochastic
+
+import (
+	"math"
+	"time"
+)
+
+// DefaultTrendHalfLife is the age at which a rate-of-change sample counts
+// half as much as the newest one.
+const DefaultTrendHalfLife = 5 * time.Second
+
+// trendMinInterval is the shortest gap between samples that updates a trend.
+// Closer samples are dominated by noise and would turn a single burst into a
+// steep slope.
+const trendMinInterval = 100 * time.Millisecond
+
+// maxProjection bounds time-to-Red projections; slower trends are treated as
+// not approaching Red at all.
+const maxProjection = 24 * time.Hour
+
+// PredictionConfig enables predictive pressure: when a utilisation trend
+// projects reaching Red within Horizon, the sensor votes Yellow early.
+type PredictionConfig struct {
+	Horizon  time.Duration // Zero disables prediction
+	HalfLife time.Duration // Zero uses DefaultTrendHalfLife
+}
+
+// Trend is an exponentially weighted moving average (EWMA) of a value's rate
+// of change. Samples may arrive at irregular intervals; each is weighted by
+// the time elapsed since the previous one. It is not safe for concurrent use.
+type Trend struct {
+	halfLife time.Duration
+	value    float64
+	rate     float64 // Smoothed change per second
+	at       time.Time
+	rated    bool // rate holds at least one sample
+}
+
+// NewTrend creates a trend whose samples halve in weight every halfLife.
+func NewTrend(halfLife time.Duration) *Trend {
+	if halfLife <= 0 {
+		halfLife = DefaultTrendHalfLife
+	}
+	return &Trend{halfLife: halfLife}
+}
+
+// Observe records value v at time at. It reports whether the sample updated
+// the trend; samples closer than 100ms to the previous one are ignored.
+func (t *Trend) Observe(at time.Time, v float64) bool {
+	if t.at.IsZero() {
+		t.value, t.at = v, at
+		return true
+	}
+	dt := at.Sub(t.at)
+	if dt < trendMinInterval {
+		return false
+	}
+	rate := (v - t.value) / dt.Seconds()
+	if t.rated {
+		alpha := 1 - math.Exp(-math.Ln2*dt.Seconds()/t.halfLife.Seconds())
+		t.rate += alpha * (rate - t.rate)
+	} else {
+		t.rate, t.rated = rate, true
+	}
+	t.value, t.at = v, at
+	return true
+}
+
+// Rate returns the smoothed change per second.
+func (t *Trend) Rate() float64 {
+	return t.rate
+}
+
+// TimeTo projects how long the value takes to reach target at the smoothed
+// rate. It returns zero once the target is reached, and false while the value
+// is not approaching it or would take longer than a day.
+func (t *Trend) TimeTo(target float64) (time.Duration, bool) {
+	if !t.rated {
+		return 0, false
+	}
+	if t.value >= target {
+		return 0, true
+	}
+	if t.rate <= 0 {
+		return 0, false
+	}
+	secs := (target - t.value) / t.rate
+	if secs > maxProjection.Seconds() {
+		return 0, false
+	}
+	return time.Duration(secs * float64(time.Second)), true
+}
+
+// Forecast feeds a utilisation sample into a sensor's trend and exports the
+// projected time to reach red as gophership_zone_time_to_red_seconds. It
+// reports whether the projection falls within horizon, in which case a
+// sensor still voting Green should vote Yellow.
+func Forecast(sensor string, t *Trend, horizon time.Duration, at time.Time, value, red float64) bool {
+	updated := t.Observe(at, value)
+	eta, ok := t.TimeTo(red)
+	if updated {
+		if ok {
+			TimeToRedSeconds.WithLabelValues(sensor).Set(eta.Seconds())
+		} else {
+			TimeToRedSeconds.DeleteLabelValues(sensor)
+		}
+	}
+	return ok && eta <= horizon
+}
@@ -0,0 +1,85 @@
+package stochastic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrend_TimeTo(t *testing.T) {
+	type sample struct {
+		after time.Duration // Since the previous sample
+		value float64
+	}
+	tests := []struct {
+		name    string
+		samples []sample
+		want    time.Duration
+		wantOK  bool
+	}{
+		{"SingleSample", []sample{{0, 0.5}}, 0, false},
+		{"Rising", []sample{{0, 0.2}, {time.Second, 0.3}}, 6 * time.Second, true},
+		{"Falling", []sample{{0, 0.5}, {time.Second, 0.4}}, 0, false},
+		{"Flat", []sample{{0, 0.5}, {time.Second, 0.5}}, 0, false},
+		{"Reached", []sample{{0, 0.8}, {time.Second, 0.95}}, 0, true},
+		{"TooSlow", []sample{{0, 0.1}, {time.Hour, 0.1001}}, 0, false},
+		{"IgnoresCloseSamples", []sample{{0, 0.2}, {time.Second, 0.3}, {10 * time.Millisecond, 0.1}}, 6 * time.Second, true},
+		{"SmoothsBurst", []sample{{0, 0.2}, {time.Second, 0.3}, {time.Second, 0.3}}, 12 * time.Second, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A half-life equal to the sample spacing weighs the newest rate by one half.
+			tr := NewTrend(time.Second)
+			at := time.Unix(1000, 0)
+			for _, s := range tt.samples {
+				at = at.Add(s.after)
+				tr.Observe(at, s.value)
+			}
+			got, ok := tr.TimeTo(0.9)
+			if ok != tt.wantOK || (ok && (got-tt.want).Abs() > time.Millisecond) {
+				t.Errorf("TimeTo(0.9) = %s, %v; want %s, %v (rate %.3f/s)", got, ok, tt.want, tt.wantOK, tr.Rate())
+			}
+		})
+	}
+}
+
+func TestSensingMonitor_Prediction(t *testing.T) {
+	MustSetAmbientStatus(StatusGreen)
+	t.Cleanup(func() { MustSetAmbientStatus(StatusGreen) })
+
+	budget := uint64(1000)
+	monitor := NewSensingMonitor(1, 1024*1024*1024*1024, 0.8, 0.9, budget, 0)
+	monitor.SetPrediction(PredictionConfig{Horizon: 30 * time.Second, HalfLife: 100 * time.Millisecond})
+
+	ingesterVote := func() Vote {
+		for _, v := range Zones.Votes() {
+			if v.Sensor == SensorIngester {
+				return v
+			}
+		}
+		t.Fatal("no ingester vote")
+		return Vote{}
+	}
+
+	// 40% then 50% of the budget 150ms later projects reaching Red (95%)
+	// in under a second, well within the horizon, while still below the
+	// Yellow threshold (80%).
+	monitor.ReportIngesterUsage(400)
+	monitor.MustSense()
+	if v := ingesterVote(); v.Status != StatusGreen {
+		t.Fatalf("first sample voted %s, want GREEN", v.Status)
+	}
+	time.Sleep(150 * time.Millisecond)
+	monitor.ReportIngesterUsage(100)
+	monitor.MustSense()
+	if v := ingesterVote(); v.Status != StatusYellow || v.Reason != "Ingester usage trending toward Red" {
+		t.Errorf("rising usage voted %s (%q), want YELLOW trending toward Red", v.Status, v.Reason)
+	}
+
+	// Usage falling back reverses the trend.
+	time.Sleep(150 * time.Millisecond)
+	monitor.ReportIngesterUsage(-400)
+	monitor.MustSense()
+	if v := ingesterVote(); v.Status != StatusGreen {
+		t.Errorf("falling usage voted %s, want GREEN", v.Status)
+	}
+}
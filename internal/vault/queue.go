@@ -0,0 +1,325 @@
+package vault
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sungp/gophership/internal/buffer"
+	"github.com/sungp/gophership/internal/stochastic"
+)
+
+const (
+	// QueueCursorFile stores the position of the oldest unacknowledged item.
+	QueueCursorFile = "queue.cursor"
+	// itemHeaderSize is the big-endian length prefix written before each item.
+	itemHeaderSize = 4
+)
+
+// ErrQueueEmpty is returned by Peek when there is nothing to deliver.
+var ErrQueueEmpty = errors.New("vault: queue empty")
+
+// errEndOfSegment marks the end of the written region of a segment.
+var errEndOfSegment = errors.New("vault: end of segment")
+
+// queuePos addresses a block boundary: every item starts on one because
+// Append flushes the block after each item.
+type queuePos struct {
+	segment uint64
+	offset  int64
+}
+
+// Queue is a durable FIFO on top of WAL segments. Items are length-prefixed
+// and flushed on Append; Peek returns the oldest item and Ack reclaims it,
+// deleting segments once every item in them has been acknowledged. Anything
+// not acknowledged before a restart is delivered again (at-least-once).
+//
+// The directory must be dedicated to the queue: plain WAL data has no item
+// framing and cannot be read back as a queue.
+type Queue struct {
+	mu      sync.Mutex
+	wal     *WAL
+	dir     string
+	head    queuePos
+	next    queuePos
+	peeked  bool
+	pending int
+	scratch []byte
+}
+
+// OpenQueue opens (or creates) a queue in dir and counts the items left
+// unacknowledged by a previous run. Its segments count against the default
+// runtime.
+func OpenQueue(dir string, segmentSize int64) (*Queue, error) {
+	return OpenQueueWithRuntime(stochastic.Default, dir, segmentSize)
+}
+
+// OpenQueueWithRuntime is OpenQueue with the segments counted against rt's
+// monitor.
+func OpenQueueWithRuntime(rt *stochastic.Runtime, dir string, segmentSize int64) (*Queue, error) {
+	w, err := NewWALWithRuntime(rt, dir, segmentSize)
+	if err != nil {
+		return nil, err
+	}
+	q := &Queue{wal: w, dir: dir}
+	if err := q.loadCursor(); err != nil {
+		w.Close()
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for pos := q.head; ; {
+		_, next, err := q.readLocked(pos)
+		if err != nil {
+			break
+		}
+		q.pending++
+		pos = next
+	}
+	if q.pending > 0 {
+		log.Info().Str("dir", dir).Int("pending", q.pending).Msg("Persistent queue recovered unacknowledged items")
+	}
+	return q, nil
+}
+
+// Append durably enqueues a copy of data.
+func (q *Queue) Append(data []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	framed := buffer.MustAcquire(itemHeaderSize + len(data))
+	*framed = binary.BigEndian.AppendUint32((*framed)[:0], uint32(len(data)))
+	*framed = append(*framed, data...)
+	q.wal.MustWrite(framed) // Releases framed
+	if err := q.wal.Flush(); err != nil {
+		return fmt.Errorf("queue flush: %w", err)
+	}
+	q.pending++
+	return nil
+}
+
+// Len returns the number of unacknowledged items.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pending
+}
+
+// Peek returns the oldest unacknowledged item. The slice is only valid until
+// the next call to Peek.
+func (q *Queue) Peek() ([]byte, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending == 0 {
+		return nil, ErrQueueEmpty
+	}
+	item, next, err := q.readLocked(q.head)
+	if err != nil {
+		return nil, err
+	}
+	q.next = next
+	q.peeked = true
+	return item, nil
+}
+
+// Ack acknowledges the item returned by the last Peek, persists the cursor and
+// removes segments that no longer hold pending items.
+func (q *Queue) Ack() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.peeked {
+		return errors.New("vault: ack without peek")
+	}
+	q.peeked = false
+	q.head = q.next
+	q.pending--
+	if err := q.saveCursorLocked(); err != nil {
+		return err
+	}
+	q.removeConsumedLocked()
+	return nil
+}
+
+// Close flushes the active segment and persists the cursor.
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return errors.Join(q.wal.Close(), q.saveCursorLocked())
+}
+
+type queueSegment struct {
+	index uint64
+	path  string
+}
+
+// segmentsLocked lists the queue segments in write order.
+func (q *Queue) segmentsLocked() ([]queueSegment, error) {
+	paths, err := q.wal.ListSegmentsOrdered()
+	if err != nil {
+		return nil, err
+	}
+	segs := make([]queueSegment, 0, len(paths))
+	for _, p := range paths {
+		segs = append(segs, queueSegment{index: segmentIndex(p), path: p})
+	}
+	sort.Slice(segs, func(i, j int) bool { return segs[i].index < segs[j].index })
+	return segs, nil
+}
+
+// readLocked decodes the item starting at pos, following it across segment
+// boundaries, and returns it with the position just past it. A damaged block
+// (e.g. a tail torn by a crash) ends its segment and discards the partial item.
+func (q *Queue) readLocked(pos queuePos) ([]byte, queuePos, error) {
+	segs, err := q.segmentsLocked()
+	if err != nil {
+		return nil, pos, err
+	}
+
+	out := q.scratch[:0]
+	defer func() { q.scratch = out[:0] }()
+	need := -1
+	for _, seg := range segs {
+		if seg.index < pos.segment {
+			continue
+		}
+		off := int64(0)
+		if seg.index == pos.segment {
+			off = pos.offset
+		}
+
+		f, err := os.Open(seg.path)
+		if err != nil {
+			return nil, pos, err
+		}
+		for {
+			blockPtr, n, total, err := readBlockAt(f, off)
+			if err != nil {
+				if !errors.Is(err, errEndOfSegment) {
+					log.Warn().Err(err).Str("path", seg.path).Int64("offset", off).Msg("Persistent queue skipping damaged segment tail")
+					out, need = out[:0], -1
+				}
+				break
+			}
+			off += int64(total)
+			out = append(out, (*blockPtr)[:n]...)
+			ReleaseUncompressed(blockPtr)
+
+			if need < 0 && len(out) >= itemHeaderSize {
+				need = itemHeaderSize + int(binary.BigEndian.Uint32(out))
+			}
+			if need >= 0 && len(out) >= need {
+				f.Close()
+				return out[itemHeaderSize:need], queuePos{segment: seg.index, offset: off}, nil
+			}
+		}
+		f.Close()
+	}
+	return nil, pos, ErrQueueEmpty
+}
+
+// readBlockAt decodes the framed block at off into a pooled buffer that the
+// caller must release with ReleaseUncompressed. It returns the buffer, the
+// uncompressed length and the frame size.
+func readBlockAt(f *os.File, off int64) (*[]byte, int, int, error) {
+	var hdr [HeaderSize]byte
+	if _, err := f.ReadAt(hdr[:], off); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, 0, 0, errEndOfSegment
+		}
+		return nil, 0, 0, err
+	}
+	if binary.BigEndian.Uint32(hdr[0:4]) == 0 {
+		return nil, 0, 0, errEndOfSegment // Zero-filled remainder of a mapped segment
+	}
+
+	compLen := binary.BigEndian.Uint32(hdr[8:12])
+	framePtr := compPool.Get().(*[]byte)
+	defer compPool.Put(framePtr)
+	if int(HeaderSize+compLen) > len(*framePtr) {
+		return nil, 0, 0, fmt.Errorf("block length %d exceeds bound", compLen)
+	}
+	frame := (*framePtr)[:HeaderSize+compLen]
+	if _, err := f.ReadAt(frame, off); err != nil {
+		return nil, 0, 0, err
+	}
+
+	uncompPtr, total, n, err := DecompressBlock(frame)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return uncompPtr, n, total, nil
+}
+
+// removeConsumedLocked deletes segments that lie entirely before the head.
+func (q *Queue) removeConsumedLocked() {
+	segs, err := q.segmentsLocked()
+	if err != nil {
+		return
+	}
+	q.wal.mu.Lock()
+	active := q.wal.index
+	q.wal.mu.Unlock()
+
+	for _, seg := range segs {
+		if seg.index >= q.head.segment || seg.index == active {
+			break
+		}
+		if err := os.Remove(seg.path); err != nil {
+			log.Error().Err(err).Str("path", seg.path).Msg("Failed to remove acknowledged queue segment")
+			continue
+		}
+		log.Debug().Str("path", seg.path).Msg("Acknowledged queue segment reclaimed")
+	}
+}
+
+func (q *Queue) loadCursor() error {
+	data, err := os.ReadFile(filepath.Join(q.dir, QueueCursorFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read queue cursor: %w", err)
+	}
+	if len(data) != 16 {
+		return fmt.Errorf("corrupt queue cursor (%d bytes)", len(data))
+	}
+	q.head = queuePos{
+		segment: binary.BigEndian.Uint64(data[0:8]),
+		offset:  int64(binary.BigEndian.Uint64(data[8:16])),
+	}
+	return nil
+}
+
+// saveCursorLocked writes the cursor atomically via rename.
+func (q *Queue) saveCursorLocked() error {
+	var data [16]byte
+	binary.BigEndian.PutUint64(data[0:8], q.head.segment)
+	binary.BigEndian.PutUint64(data[8:16], uint64(q.head.offset))
+
+	path := filepath.Join(q.dir, QueueCursorFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data[:], 0644); err != nil {
+		return fmt.Errorf("write queue cursor: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("commit queue cursor: %w", err)
+	}
+	return nil
+}
+
+// segmentIndex parses the monotonic index from a segment file name.
+func segmentIndex(path string) uint64 {
+	var ts int64
+	var idx uint64
+	name := strings.TrimSuffix(filepath.Base(path), WALFileSuffix)
+	fmt.Sscanf(name, WALFilePrefix+"%d-%d", &ts, &idx)
+	return idx
+}
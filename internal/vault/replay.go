@@ -115,13 +115,13 @@ func (r *Replayer) streamSegment(ctx context.Context, path string, sink func([]b
 		offset += int64(total)
 
 		// Throttling logic based on Stochastic pressure (AC1, AC4)
-		mult := stochastic.ThrottleMultiplier()
+		mult := r.wal.rt.ThrottleMultiplier()
 		wait := r.throttle
 
 		if mult > 1.0 {
 			wait = time.Duration(float64(r.throttle) * mult)
 			// Ensure visibility of the throttle event (AC5)
-			status := stochastic.GetAmbientStatus()
+			status := r.wal.rt.Status()
 
 			// Base throttle for Yellow if none configured
 			if r.throttle == 0 && status == stochastic.StatusYellow {
@@ -22,6 +22,7 @@ const (
 )
 
 type Segment struct {
+	rt      *stochastic.Runtime
 	file    *os.File
 	mmap    mmap.MMap
 	writeAt int64
@@ -30,6 +31,7 @@ type Segment struct {
 }
 
 type WAL struct {
+	rt            *stochastic.Runtime // Runtime whose monitor tracks vault usage
 	mu            sync.Mutex
 	dir           string
 	segmentSize   int64
@@ -41,7 +43,14 @@ type WAL struct {
 	currBlockOff int
 }
 
+// NewWAL opens a WAL in dir that reports usage to the default runtime.
 func NewWAL(dir string, segmentSize int64) (*WAL, error) {
+	return NewWALWithRuntime(stochastic.Default, dir, segmentSize)
+}
+
+// NewWALWithRuntime opens a WAL in dir that reports usage to rt's monitor;
+// replays from it are throttled by rt's status.
+func NewWALWithRuntime(rt *stochastic.Runtime, dir string, segmentSize int64) (*WAL, error) {
 	if segmentSize < int64(DefaultBlockSize+HeaderSize) {
 		segmentSize = DefaultSegmentSize
 	}
@@ -51,6 +60,7 @@ func NewWAL(dir string, segmentSize int64) (*WAL, error) {
 	}
 
 	w := &WAL{
+		rt:          rt,
 		dir:         dir,
 		segmentSize: segmentSize,
 	}
@@ -77,9 +87,10 @@ func NewWAL(dir string, segmentSize int64) (*WAL, error) {
 	w.currBlock = blockPool.Get().(*[]byte)
 	w.currBlockOff = 0
 
-	// Report initial usage
-	if stochastic.Monitor != nil {
-		stochastic.Monitor.ReportVaultUsage(int64(DefaultBlockSize))
+	// Report initial usage and vote on the directory's free space
+	if m := rt.Monitor(); m != nil {
+		m.ReportVaultUsage(int64(DefaultBlockSize))
+		m.WatchDisk(dir)
 	}
 
 	log.Info().Str("dir", dir).Uint64("start_index", w.index).Msg("WAL initialized")
@@ -128,6 +139,20 @@ func (w *WAL) MustWrite(data *[]byte) {
 	buffer.MustRelease(data)
 }
 
+// Flush compresses the partially filled block into the active segment and
+// syncs the mapping, making everything written so far durable.
+func (w *WAL) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	if err := w.flushBlockLocked(); err != nil {
+		return err
+	}
+	return w.activeSegment.mmap.Flush()
+}
+
 func (w *WAL) flushBlockLocked() error {
 	if w.currBlockOff == 0 {
 		return nil
@@ -176,11 +201,11 @@ func (w *WAL) rotateLocked() error {
 		f.Close()
 		return err
 	}
-	w.activeSegment = &Segment{file: f, mmap: m, size: w.segmentSize, path: path, writeAt: 0}
+	w.activeSegment = &Segment{rt: w.rt, file: f, mmap: m, size: w.segmentSize, path: path, writeAt: 0}
 
 	// Report usage increase
-	if stochastic.Monitor != nil {
-		stochastic.Monitor.ReportVaultUsage(w.segmentSize)
+	if m := w.rt.Monitor(); m != nil {
+		m.ReportVaultUsage(w.segmentSize)
 	}
 	return nil
 }
@@ -204,8 +229,8 @@ func (w *WAL) Close() error {
 		ReleaseUncompressed(w.currBlock)
 		w.currBlock = nil
 		// Report usage reduction
-		if stochastic.Monitor != nil {
-			stochastic.Monitor.ReportVaultUsage(-int64(DefaultBlockSize))
+		if m := w.rt.Monitor(); m != nil {
+			m.ReportVaultUsage(-int64(DefaultBlockSize))
 		}
 	}
 	return nil
@@ -219,8 +244,8 @@ func (s *Segment) close() error {
 	err := s.file.Close()
 
 	// Report usage reduction
-	if stochastic.Monitor != nil {
-		stochastic.Monitor.ReportVaultUsage(-s.size)
+	if m := s.rt.Monitor(); m != nil {
+		m.ReportVaultUsage(-s.size)
 	}
 	return err
 }
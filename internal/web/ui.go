@@ -19,12 +19,21 @@ var upgrader = websocket.Upgrader{
 type MetricsServer struct {
 	hub    *Hub
 	assets fs.FS
+	rt     *stochastic.Runtime
 }
 
+// NewMetricsServer creates a dashboard server for the default runtime.
 func NewMetricsServer(assets fs.FS) *MetricsServer {
+	return NewMetricsServerWithRuntime(stochastic.Default, assets)
+}
+
+// NewMetricsServerWithRuntime creates a dashboard server streaming rt's zone
+// and telemetry.
+func NewMetricsServerWithRuntime(rt *stochastic.Runtime, assets fs.FS) *MetricsServer {
 	return &MetricsServer{
 		hub:    NewHub(),
 		assets: assets,
+		rt:     rt,
 	}
 }
 
@@ -38,16 +47,19 @@ func (s *MetricsServer) Start(ctx context.Context, addr string) error {
 	}
 	staticHandler := http.FileServer(http.FS(distDir))
 
-	// 2. Setup WebSocket Endpoint
-	http.HandleFunc("/ws", s.handleWS)
+	// 2. Setup WebSocket Endpoint. Each server has its own mux so that
+	// several can run in one process.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", s.handleWS)
 
 	// 3. Setup Root Handler
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		staticHandler.ServeHTTP(w, r)
 	})
 
 	server := &http.Server{
-		Addr: addr,
+		Addr:    addr,
+		Handler: mux,
 	}
 
 	// Metrics Stream Loop
@@ -94,7 +106,7 @@ func (s *MetricsServer) streamMetrics(ctx context.Context) {
 			return
 		case <-ticker.C:
 			// 1. Get Real Somatic Status
-			status := stochastic.GetAmbientStatus()
+			status := s.rt.Status()
 
 			// 2. Get Runtime Stats (Hardware Honesty)
 			var ms runtime.MemStats
@@ -105,15 +117,16 @@ func (s *MetricsServer) streamMetrics(ctx context.Context) {
 			// 3. Get Monitor Telemetry
 			var totalUsage, heapObjects uint64
 			var pressureScore uint32
-			if stochastic.Monitor != nil {
-				totalUsage, heapObjects, pressureScore = stochastic.Monitor.Telemetry()
+			monitor := s.rt.Monitor()
+			if monitor != nil {
+				totalUsage, heapObjects, pressureScore = monitor.Telemetry()
 			} else {
 				heapObjects = ms.HeapObjects
 			}
 
 			// 4. Calculate RAM % (Hardware Honest)
 			ramUsage := 0.0
-			if stochastic.Monitor != nil {
+			if monitor != nil {
 				// Assuming maxRAM is configured
 				// Simplified for demo: use a base or actual Sys/maxRAM
 				ramUsage = (float64(ms.Sys) / 1024 / 1024 / 1024) * 10.0 // Scaled for demo impact
@@ -0,0 +1,65 @@
+package otel
+
+import (
+	v1 "go.opentelemetry.io/proto/otlp/common/v1"
+)
+
+// FindAttribute returns the first attribute with the given key, or nil.
+func FindAttribute(kvs []*v1.KeyValue, key string) *v1.KeyValue {
+	for _, kv := range kvs {
+		if kv != nil && kv.Key == key {
+			return kv
+		}
+	}
+	return nil
+}
+
+// SetAttribute replaces the value of key, or appends a pooled KeyValue when the
+// key is absent. The replaced value is returned to the pool; value is owned by
+// the attribute from now on.
+func SetAttribute(kvs []*v1.KeyValue, key string, value *v1.AnyValue) []*v1.KeyValue {
+	if kv := FindAttribute(kvs, key); kv != nil {
+		ReleaseAnyValue(kv.Value)
+		kv.Value = value
+		return kvs
+	}
+	return append(kvs, NewKeyValue(key, value))
+}
+
+// DeleteAttribute removes every attribute with the given key and returns the
+// KeyValues to the pool. The slice is compacted in place.
+func DeleteAttribute(kvs []*v1.KeyValue, key string) []*v1.KeyValue {
+	out := kvs[:0]
+	for _, kv := range kvs {
+		if kv != nil && kv.Key == key {
+			releaseKeyValue(kv)
+			continue
+		}
+		out = append(out, kv)
+	}
+	clear(kvs[len(out):])
+	return out
+}
+
+// RenameAttribute moves the value of from to to, replacing any existing
+// attribute named to. It reports whether from was present.
+func RenameAttribute(kvs []*v1.KeyValue, from, to string) ([]*v1.KeyValue, bool) {
+	kv := FindAttribute(kvs, from)
+	if kv == nil {
+		return kvs, false
+	}
+	if from == to {
+		return kvs, true
+	}
+	out := kvs[:0]
+	for _, other := range kvs {
+		if other != nil && other != kv && other.Key == to {
+			releaseKeyValue(other)
+			continue
+		}
+		out = append(out, other)
+	}
+	clear(kvs[len(out):])
+	kv.Key = to
+	return out, true
+}
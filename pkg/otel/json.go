@@ -0,0 +1,133 @@
+package otel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	v1 "go.opentelemetry.io/proto/otlp/common/v1"
+)
+
+// ParseJSONValue decodes a JSON document into an AnyValue tree built from the
+// pooled AnyValue/KeyValue structures: objects become KvlistValue, arrays
+// ArrayValue, integral numbers IntValue and other numbers DoubleValue.
+func ParseJSONValue(data []byte) (*v1.AnyValue, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	v, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		ReleaseAnyValue(v)
+		return nil, fmt.Errorf("trailing data after JSON value")
+	}
+	return v, nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (*v1.AnyValue, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		av := anyValuePool.Get().(*v1.AnyValue)
+		switch t {
+		case '{':
+			kvs := &v1.KeyValueList{}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					releaseList(kvs.Values)
+					anyValuePool.Put(av)
+					return nil, err
+				}
+				val, err := decodeJSONValue(dec)
+				if err != nil {
+					releaseList(kvs.Values)
+					anyValuePool.Put(av)
+					return nil, err
+				}
+				kvs.Values = append(kvs.Values, NewKeyValue(keyTok.(string), val))
+			}
+			av.Value = &v1.AnyValue_KvlistValue{KvlistValue: kvs}
+		case '[':
+			arr := &v1.ArrayValue{}
+			for dec.More() {
+				val, err := decodeJSONValue(dec)
+				if err != nil {
+					for _, e := range arr.Values {
+						ReleaseAnyValue(e)
+					}
+					anyValuePool.Put(av)
+					return nil, err
+				}
+				arr.Values = append(arr.Values, val)
+			}
+			av.Value = &v1.AnyValue_ArrayValue{ArrayValue: arr}
+		default:
+			anyValuePool.Put(av)
+			return nil, fmt.Errorf("unexpected delimiter %q", t)
+		}
+		// Consume the closing delimiter.
+		if _, err := dec.Token(); err != nil {
+			ReleaseAnyValue(av)
+			return nil, err
+		}
+		return av, nil
+	case string:
+		return NewStringValue(t), nil
+	case json.Number:
+		if i, err := strconv.ParseInt(string(t), 10, 64); err == nil {
+			return NewIntValue(i), nil
+		}
+		if f, err := strconv.ParseFloat(string(t), 64); err == nil {
+			return NewDoubleValue(f), nil
+		}
+		return nil, fmt.Errorf("invalid number %q", t)
+	case bool:
+		return NewBoolValue(t), nil
+	}
+	// JSON null
+	av := anyValuePool.Get().(*v1.AnyValue)
+	av.Value = nil
+	return av, nil
+}
+
+// NewKeyValue returns a pooled KeyValue that takes ownership of value.
+func NewKeyValue(key string, value *v1.AnyValue) *v1.KeyValue {
+	kv := keyValuePool.Get().(*v1.KeyValue)
+	kv.Key = key
+	kv.Value = value
+	return kv
+}
+
+// ReleaseAnyValue returns an AnyValue tree to the pools.
+func ReleaseAnyValue(av *v1.AnyValue) {
+	if av == nil {
+		return
+	}
+	switch v := av.Value.(type) {
+	case *v1.AnyValue_StringValue:
+		stringValuePool.Put(v)
+	case *v1.AnyValue_KvlistValue:
+		releaseList(v.KvlistValue.GetValues())
+	case *v1.AnyValue_ArrayValue:
+		for _, e := range v.ArrayValue.GetValues() {
+			ReleaseAnyValue(e)
+		}
+	}
+	av.Value = nil
+	anyValuePool.Put(av)
+}
+
+func releaseList(kvs []*v1.KeyValue) {
+	for _, kv := range kvs {
+		releaseKeyValue(kv)
+	}
+}
@@ -0,0 +1,59 @@
+package otel
+
+import (
+	"testing"
+
+	v1 "go.opentelemetry.io/proto/otlp/common/v1"
+)
+
+func TestParseJSONValue(t *testing.T) {
+	av, err := ParseJSONValue([]byte(`{"msg":"hi","n":3,"f":1.5,"ok":true,"nil":null,"tags":["a",2],"ctx":{"user":"bob"}}`))
+	if err != nil {
+		t.Fatalf("ParseJSONValue: %v", err)
+	}
+	defer ReleaseAnyValue(av)
+
+	kvs := av.GetKvlistValue().GetValues()
+	if len(kvs) != 7 {
+		t.Fatalf("got %d keys, want 7", len(kvs))
+	}
+	get := func(k string) *v1.AnyValue {
+		for _, kv := range kvs {
+			if kv.Key == k {
+				return kv.Value
+			}
+		}
+		t.Fatalf("key %q missing", k)
+		return nil
+	}
+
+	if got := get("msg").GetStringValue(); got != "hi" {
+		t.Errorf("msg = %q", got)
+	}
+	if got := get("n").GetIntValue(); got != 3 {
+		t.Errorf("n = %d", got)
+	}
+	if got := get("f").GetDoubleValue(); got != 1.5 {
+		t.Errorf("f = %v", got)
+	}
+	if !get("ok").GetBoolValue() {
+		t.Error("ok = false")
+	}
+	if get("nil").Value != nil {
+		t.Errorf("nil = %v", get("nil").Value)
+	}
+	if arr := get("tags").GetArrayValue().GetValues(); len(arr) != 2 || arr[1].GetIntValue() != 2 {
+		t.Errorf("tags = %v", arr)
+	}
+	if got := get("ctx").GetKvlistValue().GetValues()[0].Value.GetStringValue(); got != "bob" {
+		t.Errorf("ctx.user = %q", got)
+	}
+}
+
+func TestParseJSONValue_Invalid(t *testing.T) {
+	for _, in := range []string{`{"a":`, `{"a":1} trailing`, `]`, ``} {
+		if _, err := ParseJSONValue([]byte(in)); err == nil {
+			t.Errorf("ParseJSONValue(%q) succeeded, want error", in)
+		}
+	}
+}
@@ -74,21 +74,7 @@ func ReleaseLogRecord(lr *logsv1.LogRecord) {
 
 // AddAttribute adds a key-value pair to the LogRecord in a pooled fashion.
 func AddAttribute(lr *logsv1.LogRecord, key, value string) {
-	kv := keyValuePool.Get().(*v1.KeyValue)
-	kv.Key = key
-
-	if kv.Value == nil {
-		kv.Value = anyValuePool.Get().(*v1.AnyValue)
-	}
-
-	sv, ok := kv.Value.Value.(*v1.AnyValue_StringValue)
-	if !ok {
-		sv = stringValuePool.Get().(*v1.AnyValue_StringValue)
-		kv.Value.Value = sv
-	}
-	sv.StringValue = value
-
-	lr.Attributes = append(lr.Attributes, kv)
+	lr.Attributes = append(lr.Attributes, NewKeyValue(key, NewStringValue(value)))
 }
 
 // MapResourceLogs encapsulates a set of logs into the OTel Resource/Scope structure (AC1).
@@ -170,13 +156,7 @@ func releaseKeyValue(kv *v1.KeyValue) {
 	if kv == nil {
 		return
 	}
-	if kv.Value != nil {
-		if sv, ok := kv.Value.Value.(*v1.AnyValue_StringValue); ok {
-			stringValuePool.Put(sv)
-			kv.Value.Value = nil
-		}
-		anyValuePool.Put(kv.Value)
-		kv.Value = nil
-	}
+	ReleaseAnyValue(kv.Value) // Recurses into kvlist/array values
+	kv.Value = nil
 	keyValuePool.Put(kv)
 }
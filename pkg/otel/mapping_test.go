@@ -114,3 +114,37 @@ func BenchmarkMapResourceLogs(b *testing.B) {
 		ReleaseResourceLogs(rl)
 	}
 }
+
+func TestAttributeHelpers(t *testing.T) {
+	lr := MapLogRecord(time.Now(), logsv1.SeverityNumber_SEVERITY_NUMBER_INFO, "msg")
+	AddAttribute(lr, "svc", "checkout")
+	AddAttribute(lr, "service.name", "stale")
+	AddAttribute(lr, "tmp", "x")
+
+	var ok bool
+	if lr.Attributes, ok = RenameAttribute(lr.Attributes, "svc", "service.name"); !ok {
+		t.Fatal("rename reported missing key")
+	}
+	lr.Attributes = DeleteAttribute(lr.Attributes, "tmp")
+	lr.Attributes = SetAttribute(lr.Attributes, "env", NewStringValue("prod"))
+	lr.Attributes = SetAttribute(lr.Attributes, "env", NewStringValue("staging"))
+
+	got := map[string]string{}
+	for _, kv := range lr.Attributes {
+		got[kv.Key] = kv.Value.GetStringValue()
+	}
+	want := map[string]string{"service.name": "checkout", "env": "staging"}
+	if len(got) != len(want) || len(lr.Attributes) != len(want) {
+		t.Fatalf("attributes = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+	if FindAttribute(lr.Attributes, "svc") != nil {
+		t.Error("renamed key still present")
+	}
+
+	ReleaseLogRecord(lr)
+}
@@ -0,0 +1,51 @@
+package otel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+// ParseSeverity maps a severity name (TRACE, DEBUG, INFO, WARN, ERROR, FATAL,
+// case-insensitive, with common aliases) or an OTel severity number (1-24) to
+// the corresponding SeverityNumber.
+func ParseSeverity(s string) (logsv1.SeverityNumber, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 || n > 24 {
+			return logsv1.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, fmt.Errorf("severity number %d out of range 1-24", n)
+		}
+		return logsv1.SeverityNumber(n), nil
+	}
+
+	switch strings.ToUpper(s) {
+	case "TRACE":
+		return logsv1.SeverityNumber_SEVERITY_NUMBER_TRACE, nil
+	case "DEBUG":
+		return logsv1.SeverityNumber_SEVERITY_NUMBER_DEBUG, nil
+	case "INFO", "INFORMATION", "NOTICE":
+		return logsv1.SeverityNumber_SEVERITY_NUMBER_INFO, nil
+	case "WARN", "WARNING":
+		return logsv1.SeverityNumber_SEVERITY_NUMBER_WARN, nil
+	case "ERROR", "ERR":
+		return logsv1.SeverityNumber_SEVERITY_NUMBER_ERROR, nil
+	case "FATAL", "CRITICAL", "CRIT", "PANIC", "EMERG", "ALERT":
+		return logsv1.SeverityNumber_SEVERITY_NUMBER_FATAL, nil
+	default:
+		return logsv1.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, fmt.Errorf("unknown severity %q", s)
+	}
+}
+
+// RecordSeverity returns the record's severity number, falling back to its
+// severity text when the number is unspecified.
+func RecordSeverity(lr *logsv1.LogRecord) logsv1.SeverityNumber {
+	if n := lr.GetSeverityNumber(); n != logsv1.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED {
+		return n
+	}
+	if n, err := ParseSeverity(lr.GetSeverityText()); err == nil {
+		return n
+	}
+	return logsv1.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED
+}
@@ -0,0 +1,36 @@
+package otel
+
+import (
+	"testing"
+
+	logsv1 "go.opentelemetry.io/proto/otlp/logs/v1"
+)
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    logsv1.SeverityNumber
+		wantErr bool
+	}{
+		{"info", logsv1.SeverityNumber_SEVERITY_NUMBER_INFO, false},
+		{"WARNING", logsv1.SeverityNumber_SEVERITY_NUMBER_WARN, false},
+		{" error ", logsv1.SeverityNumber_SEVERITY_NUMBER_ERROR, false},
+		{"critical", logsv1.SeverityNumber_SEVERITY_NUMBER_FATAL, false},
+		{"18", logsv1.SeverityNumber_SEVERITY_NUMBER_ERROR2, false},
+		{"25", logsv1.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, true},
+		{"verbose", logsv1.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSeverity(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSeverity(%q) = %v, %v; want %v, err=%v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRecordSeverity_FallsBackToText(t *testing.T) {
+	lr := &logsv1.LogRecord{SeverityText: "WARN"}
+	if got := RecordSeverity(lr); got != logsv1.SeverityNumber_SEVERITY_NUMBER_WARN {
+		t.Errorf("RecordSeverity = %v; want WARN", got)
+	}
+}
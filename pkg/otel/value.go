@@ -0,0 +1,35 @@
+package otel
+
+import (
+	v1 "go.opentelemetry.io/proto/otlp/common/v1"
+)
+
+// NewStringValue returns a pooled AnyValue holding s.
+func NewStringValue(s string) *v1.AnyValue {
+	av := anyValuePool.Get().(*v1.AnyValue)
+	sv := stringValuePool.Get().(*v1.AnyValue_StringValue)
+	sv.StringValue = s
+	av.Value = sv
+	return av
+}
+
+// NewIntValue returns a pooled AnyValue holding i.
+func NewIntValue(i int64) *v1.AnyValue {
+	av := anyValuePool.Get().(*v1.AnyValue)
+	av.Value = &v1.AnyValue_IntValue{IntValue: i}
+	return av
+}
+
+// NewDoubleValue returns a pooled AnyValue holding f.
+func NewDoubleValue(f float64) *v1.AnyValue {
+	av := anyValuePool.Get().(*v1.AnyValue)
+	av.Value = &v1.AnyValue_DoubleValue{DoubleValue: f}
+	return av
+}
+
+// NewBoolValue returns a pooled AnyValue holding b.
+func NewBoolValue(b bool) *v1.AnyValue {
+	av := anyValuePool.Get().(*v1.AnyValue)
+	av.Value = &v1.AnyValue_BoolValue{BoolValue: b}
+	return av
+}
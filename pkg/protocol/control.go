@@ -48,11 +48,15 @@ func (*PingResponse) ProtoMessage() {}
 
 // StatusResponse represents the engine's internal health telemetry.
 type StatusResponse struct {
-	Zone             SomaticZone `protobuf:"varint,1,opt,name=zone,proto3,enum=gophership.protocol.v1.SomaticZone" json:"zone,omitempty"`
-	PressureScore    uint32      `protobuf:"varint,2,opt,name=pressure_score,json=pressureScore,proto3" json:"pressure_score,omitempty"`
-	MemoryUsageBytes uint64      `protobuf:"varint,3,opt,name=memory_usage_bytes,json=memoryUsageBytes,proto3" json:"memory_usage_bytes,omitempty"`
-	HeapObjects      uint64      `protobuf:"varint,4,opt,name=heap_objects,json=heapObjects,proto3" json:"heap_objects,omitempty"`
-	GoroutineCount   uint32      `protobuf:"varint,5,opt,name=goroutine_count,json=goroutineCount,proto3" json:"goroutine_count,omitempty"`
+	Zone             SomaticZone         `protobuf:"varint,1,opt,name=zone,proto3,enum=gophership.protocol.v1.SomaticZone" json:"zone,omitempty"`
+	PressureScore    uint32              `protobuf:"varint,2,opt,name=pressure_score,json=pressureScore,proto3" json:"pressure_score,omitempty"`
+	MemoryUsageBytes uint64              `protobuf:"varint,3,opt,name=memory_usage_bytes,json=memoryUsageBytes,proto3" json:"memory_usage_bytes,omitempty"`
+	HeapObjects      uint64              `protobuf:"varint,4,opt,name=heap_objects,json=heapObjects,proto3" json:"heap_objects,omitempty"`
+	GoroutineCount   uint32              `protobuf:"varint,5,opt,name=goroutine_count,json=goroutineCount,proto3" json:"goroutine_count,omitempty"`
+	Downstreams      []*DownstreamStatus `protobuf:"bytes,6,rep,name=downstreams,proto3" json:"downstreams,omitempty"`
+	ZoneDriver       string              `protobuf:"bytes,7,opt,name=zone_driver,json=zoneDriver,proto3" json:"zone_driver,omitempty"`
+	ZoneReason       string              `protobuf:"bytes,8,opt,name=zone_reason,json=zoneReason,proto3" json:"zone_reason,omitempty"`
+	Override         *ZoneOverride       `protobuf:"bytes,9,opt,name=override,proto3" json:"override,omitempty"`
 }
 
 func (x *StatusResponse) Reset() {
@@ -65,6 +69,46 @@ func (x *StatusResponse) String() string {
 
 func (*StatusResponse) ProtoMessage() {}
 
+// DownstreamState mirrors an exporter's circuit breaker state.
+type DownstreamState int32
+
+const (
+	DownstreamState_DOWNSTREAM_HEALTHY DownstreamState = 0
+	DownstreamState_DOWNSTREAM_PROBING DownstreamState = 1
+	DownstreamState_DOWNSTREAM_DOWN    DownstreamState = 2
+)
+
+func (s DownstreamState) String() string {
+	switch s {
+	case DownstreamState_DOWNSTREAM_HEALTHY:
+		return "HEALTHY"
+	case DownstreamState_DOWNSTREAM_PROBING:
+		return "PROBING"
+	case DownstreamState_DOWNSTREAM_DOWN:
+		return "DOWN"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// DownstreamStatus reports the delivery health of a single exporter.
+type DownstreamStatus struct {
+	Name                string          `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	State               DownstreamState `protobuf:"varint,2,opt,name=state,proto3,enum=gophership.protocol.v1.DownstreamStatus_DownstreamState" json:"state,omitempty"`
+	ConsecutiveFailures uint32          `protobuf:"varint,3,opt,name=consecutive_failures,json=consecutiveFailures,proto3" json:"consecutive_failures,omitempty"`
+	LastError           string          `protobuf:"bytes,4,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+}
+
+func (x *DownstreamStatus) Reset() {
+	*x = DownstreamStatus{}
+}
+
+func (x *DownstreamStatus) String() string {
+	return x.Name + "=" + x.State.String()
+}
+
+func (*DownstreamStatus) ProtoMessage() {}
+
 // WatchStatusRequest specifies the refresh interval for telemetry streams.
 type WatchStatusRequest struct {
 	RefreshIntervalMs uint32 `protobuf:"varint,1,opt,name=refresh_interval_ms,json=refreshIntervalMs,proto3" json:"refresh_interval_ms,omitempty"`
@@ -80,9 +124,13 @@ func (x *WatchStatusRequest) String() string {
 
 func (*WatchStatusRequest) ProtoMessage() {}
 
-// OverrideSomaticZoneRequest specifies the target somatic zone for a manual override.
+// OverrideSomaticZoneRequest specifies the target somatic zone for a manual
+// override, with an optional TTL, reason and operator identity.
 type OverrideSomaticZoneRequest struct {
-	Zone SomaticZone `protobuf:"varint,1,opt,name=zone,proto3" json:"zone,omitempty"`
+	Zone        SomaticZone `protobuf:"varint,1,opt,name=zone,proto3" json:"zone,omitempty"`
+	TtlSeconds  uint32      `protobuf:"varint,2,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	Reason      string      `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	RequestedBy string      `protobuf:"bytes,4,opt,name=requested_by,json=requestedBy,proto3" json:"requested_by,omitempty"`
 }
 
 func (x *OverrideSomaticZoneRequest) Reset() {
@@ -95,12 +143,101 @@ func (x *OverrideSomaticZoneRequest) String() string {
 
 func (*OverrideSomaticZoneRequest) ProtoMessage() {}
 
+// ZoneOverride describes the override in force.
+type ZoneOverride struct {
+	Zone            SomaticZone `protobuf:"varint,1,opt,name=zone,proto3,enum=gophership.protocol.v1.SomaticZone" json:"zone,omitempty"`
+	Reason          string      `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	SetBy           string      `protobuf:"bytes,3,opt,name=set_by,json=setBy,proto3" json:"set_by,omitempty"`
+	SetAtUnixNano   int64       `protobuf:"varint,4,opt,name=set_at_unix_nano,json=setAtUnixNano,proto3" json:"set_at_unix_nano,omitempty"`
+	ExpiresUnixNano int64       `protobuf:"varint,5,opt,name=expires_unix_nano,json=expiresUnixNano,proto3" json:"expires_unix_nano,omitempty"`
+	RequestedBy     string      `protobuf:"bytes,6,opt,name=requested_by,json=requestedBy,proto3" json:"requested_by,omitempty"`
+}
+
+func (x *ZoneOverride) Reset() {
+	*x = ZoneOverride{}
+}
+
+func (x *ZoneOverride) String() string {
+	return x.Zone.String()
+}
+
+func (*ZoneOverride) ProtoMessage() {}
+
+// ZoneHistoryRequest limits the number of transitions returned.
+type ZoneHistoryRequest struct {
+	Limit uint32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+}
+
+func (x *ZoneHistoryRequest) Reset() {
+	*x = ZoneHistoryRequest{}
+}
+
+func (x *ZoneHistoryRequest) String() string {
+	return "ZoneHistoryRequest"
+}
+
+func (*ZoneHistoryRequest) ProtoMessage() {}
+
+// ZoneHistoryResponse lists recent zone transitions, newest first.
+type ZoneHistoryResponse struct {
+	Transitions []*ZoneTransition `protobuf:"bytes,1,rep,name=transitions,proto3" json:"transitions,omitempty"`
+}
+
+func (x *ZoneHistoryResponse) Reset() {
+	*x = ZoneHistoryResponse{}
+}
+
+func (x *ZoneHistoryResponse) String() string {
+	return "ZoneHistoryResponse"
+}
+
+func (*ZoneHistoryResponse) ProtoMessage() {}
+
+// ZoneTransition is one somatic zone change and the votes behind it.
+type ZoneTransition struct {
+	TimestampUnixNano int64         `protobuf:"varint,1,opt,name=timestamp_unix_nano,json=timestampUnixNano,proto3" json:"timestamp_unix_nano,omitempty"`
+	From              SomaticZone   `protobuf:"varint,2,opt,name=from,proto3,enum=gophership.protocol.v1.SomaticZone" json:"from,omitempty"`
+	To                SomaticZone   `protobuf:"varint,3,opt,name=to,proto3,enum=gophership.protocol.v1.SomaticZone" json:"to,omitempty"`
+	Sensor            string        `protobuf:"bytes,4,opt,name=sensor,proto3" json:"sensor,omitempty"`
+	Reason            string        `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	Votes             []*SensorVote `protobuf:"bytes,6,rep,name=votes,proto3" json:"votes,omitempty"`
+}
+
+func (x *ZoneTransition) Reset() {
+	*x = ZoneTransition{}
+}
+
+func (x *ZoneTransition) String() string {
+	return x.From.String() + "->" + x.To.String()
+}
+
+func (*ZoneTransition) ProtoMessage() {}
+
+// SensorVote is one sensor's zone vote and the reading behind it.
+type SensorVote struct {
+	Sensor string      `protobuf:"bytes,1,opt,name=sensor,proto3" json:"sensor,omitempty"`
+	Zone   SomaticZone `protobuf:"varint,2,opt,name=zone,proto3,enum=gophership.protocol.v1.SomaticZone" json:"zone,omitempty"`
+	Reason string      `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	Value  float64     `protobuf:"fixed64,4,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *SensorVote) Reset() {
+	*x = SensorVote{}
+}
+
+func (x *SensorVote) String() string {
+	return x.Sensor + "=" + x.Zone.String()
+}
+
+func (*SensorVote) ProtoMessage() {}
+
 // ControlServiceClient is the client API for ControlService.
 type ControlServiceClient interface {
 	Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*PingResponse, error)
 	GetSomaticStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StatusResponse, error)
 	WatchSomaticStatus(ctx context.Context, in *WatchStatusRequest, opts ...grpc.CallOption) (ControlService_WatchSomaticStatusClient, error)
 	OverrideSomaticZone(ctx context.Context, in *OverrideSomaticZoneRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetZoneHistory(ctx context.Context, in *ZoneHistoryRequest, opts ...grpc.CallOption) (*ZoneHistoryResponse, error)
 }
 
 type ControlService_WatchSomaticStatusClient interface {
@@ -170,12 +307,22 @@ func (c *controlServiceClient) OverrideSomaticZone(ctx context.Context, in *Over
 	return out, nil
 }
 
+func (c *controlServiceClient) GetZoneHistory(ctx context.Context, in *ZoneHistoryRequest, opts ...grpc.CallOption) (*ZoneHistoryResponse, error) {
+	out := new(ZoneHistoryResponse)
+	err := c.cc.Invoke(ctx, "/gophership.protocol.v1.ControlService/GetZoneHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ControlServiceServer is the server API for ControlService.
 type ControlServiceServer interface {
 	Ping(context.Context, *emptypb.Empty) (*PingResponse, error)
 	GetSomaticStatus(context.Context, *emptypb.Empty) (*StatusResponse, error)
 	WatchSomaticStatus(*WatchStatusRequest, ControlService_WatchSomaticStatusServer) error
 	OverrideSomaticZone(context.Context, *OverrideSomaticZoneRequest) (*emptypb.Empty, error)
+	GetZoneHistory(context.Context, *ZoneHistoryRequest) (*ZoneHistoryResponse, error)
 }
 
 type ControlService_WatchSomaticStatusServer interface {
@@ -212,6 +359,10 @@ var ControlService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "OverrideSomaticZone",
 			Handler:    _ControlService_OverrideSomaticZone_Handler,
 		},
+		{
+			MethodName: "GetZoneHistory",
+			Handler:    _ControlService_GetZoneHistory_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -284,3 +435,21 @@ func _ControlService_OverrideSomaticZone_Handler(srv interface{}, ctx context.Co
 	}
 	return interceptor(ctx, in, info, handler)
 }
+
+func _ControlService_GetZoneHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ZoneHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServiceServer).GetZoneHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gophership.protocol.v1.ControlService/GetZoneHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServiceServer).GetZoneHistory(ctx, req.(*ZoneHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}